@@ -0,0 +1,746 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/auth"
+	"github.com/standardbeagle/slop-mcp/internal/cache"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/overrides"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
+)
+
+// Doctor check results, from best to worst.
+const (
+	doctorOK   = "ok"
+	doctorSkip = "skip"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// doctorCheck is the outcome of one check of one MCP.
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"` // what to do about a warn or fail
+}
+
+// doctorMCP is the diagnosis of one configured MCP.
+type doctorMCP struct {
+	Name            string        `json:"name"`
+	Type            string        `json:"type"`
+	Source          string        `json:"source"`
+	File            string        `json:"file,omitempty"`
+	Profile         string        `json:"profile,omitempty"`
+	Status          string        `json:"status"` // worst check status
+	ConnectMillis   int64         `json:"connect_ms,omitempty"`
+	ToolCount       *int          `json:"tool_count,omitempty"`        // live tools; nil when not connected
+	CachedToolCount *int          `json:"cached_tool_count,omitempty"` // tools in the cache entry; nil without one
+	Checks          []doctorCheck `json:"checks"`
+}
+
+// doctorReport is the output of `slop-mcp mcp doctor`.
+type doctorReport struct {
+	MCPs     []doctorMCP `json:"mcps"`
+	Failures int         `json:"failures"`
+	Warnings int         `json:"warnings"`
+}
+
+// doctorEnv holds what the checks read besides the MCP config itself.
+type doctorEnv struct {
+	connect   bool
+	timeout   time.Duration // connect timeout override; 0 uses each MCP's
+	lookPath  func(string) (string, error)
+	tokens    *auth.TokenStore
+	cache     *cache.Store
+	overrides map[overrides.Scope]map[string]overrides.OverrideEntry
+}
+
+func cmdMCPDoctor(args []string) {
+	opts, err := parseMCPDoctorArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		printMCPDoctorUsage()
+		os.Exit(1)
+	}
+	if opts.showHelp {
+		printMCPDoctorUsage()
+		return
+	}
+
+	cwd, err := currentWorkingDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.LoadProfiles(cwd, selectedProfiles(opts.profiles))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Fix the file named above; `slop-mcp mcp dump` shows each config file.")
+		os.Exit(1)
+	}
+
+	var mcps []config.MCPConfig
+	if len(opts.names) == 0 {
+		for _, name := range sortedMCPNames(cfg.MCPs) {
+			mcps = append(mcps, cfg.MCPs[name])
+		}
+	}
+	for _, name := range opts.names {
+		m, ok := cfg.MCPs[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: MCP '%s' not found in any config file\n", name)
+			os.Exit(1)
+		}
+		mcps = append(mcps, m)
+	}
+
+	env := doctorEnv{
+		connect:   !opts.noConnect,
+		timeout:   opts.timeout,
+		lookPath:  exec.LookPath,
+		tokens:    auth.NewTokenStore(),
+		cache:     cache.NewStore(),
+		overrides: loadDoctorOverrides(cwd),
+	}
+	report := runDoctor(context.Background(), env, mcps)
+
+	if opts.outputJSON {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printDoctorReport(report)
+	}
+	if report.Failures > 0 {
+		os.Exit(1)
+	}
+}
+
+type mcpDoctorOptions struct {
+	names      []string
+	outputJSON bool
+	noConnect  bool
+	timeout    time.Duration
+	profiles   []string
+	showHelp   bool
+}
+
+func parseMCPDoctorArgs(args []string) (mcpDoctorOptions, error) {
+	var opts mcpDoctorOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--json":
+			opts.outputJSON = true
+		case arg == "--no-connect":
+			opts.noConnect = true
+		case arg == "--timeout":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--timeout requires a value")
+			}
+			i++
+			timeout, err := parseTimeoutValue(args[i])
+			if err != nil {
+				return opts, err
+			}
+			opts.timeout = timeout
+		case strings.HasPrefix(arg, "--timeout="):
+			timeout, err := parseTimeoutValue(strings.TrimPrefix(arg, "--timeout="))
+			if err != nil {
+				return opts, err
+			}
+			opts.timeout = timeout
+		case arg == "--profile":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--profile requires a value")
+			}
+			i++
+			profiles, err := parseProfileValue(args[i])
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
+		case strings.HasPrefix(arg, "--profile="):
+			profiles, err := parseProfileValue(strings.TrimPrefix(arg, "--profile="))
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
+		case arg == "--help" || arg == "-h":
+			opts.showHelp = true
+		case strings.HasPrefix(arg, "-"):
+			return opts, fmt.Errorf("unknown doctor option %q", arg)
+		default:
+			opts.names = append(opts.names, arg)
+		}
+	}
+	return opts, nil
+}
+
+// loadDoctorOverrides reads the tool overrides of every scope. Project and
+// local scope are left out outside a repo; nil means none could be read.
+func loadDoctorOverrides(cwd string) map[overrides.Scope]map[string]overrides.OverrideEntry {
+	home, _ := os.UserHomeDir()
+	var opts overrides.StoreOptions
+	opts.UserRoot, _ = overrides.ScopeRoot(overrides.ScopeUser, home, cwd)
+	opts.ProjectRoot, _ = overrides.ScopeRoot(overrides.ScopeProject, home, cwd)
+	opts.LocalRoot, _ = overrides.ScopeRoot(overrides.ScopeLocal, home, cwd)
+	store, err := overrides.OpenStore(opts)
+	if err != nil {
+		return nil
+	}
+	defer store.Close()
+	return store.ListOverrides()
+}
+
+// runDoctor diagnoses each MCP, connecting to them in parallel.
+func runDoctor(ctx context.Context, env doctorEnv, mcps []config.MCPConfig) doctorReport {
+	reg := registry.NewWithLogger(logging.Nop())
+	defer reg.Close()
+
+	report := doctorReport{MCPs: make([]doctorMCP, len(mcps))}
+	var wg sync.WaitGroup
+	for i, m := range mcps {
+		wg.Add(1)
+		go func(i int, m config.MCPConfig) {
+			defer wg.Done()
+			report.MCPs[i] = diagnoseMCP(ctx, env, reg, m)
+		}(i, m)
+	}
+	wg.Wait()
+
+	for _, m := range report.MCPs {
+		for _, c := range m.Checks {
+			switch c.Status {
+			case doctorFail:
+				report.Failures++
+			case doctorWarn:
+				report.Warnings++
+			}
+		}
+	}
+	return report
+}
+
+// diagnoseMCP runs every check on one MCP. The connect check is skipped when
+// an earlier check already shows the connect cannot work.
+func diagnoseMCP(ctx context.Context, env doctorEnv, reg *registry.Registry, cfg config.MCPConfig) doctorMCP {
+	d := doctorMCP{
+		Name:    cfg.Name,
+		Type:    cfg.Type,
+		Source:  cfg.Source.String(),
+		File:    cfg.File,
+		Profile: cfg.Profile,
+	}
+	d.Checks = append(d.Checks, checkMCPFields(cfg))
+	switch {
+	case cfg.Type == "unix":
+		d.Checks = append(d.Checks, checkMCPSocket(cfg))
+	case isURLMCPTransport(cfg.Type):
+		d.Checks = append(d.Checks, checkMCPURL(cfg))
+	default:
+		d.Checks = append(d.Checks, checkMCPCommand(cfg, env.lookPath))
+	}
+	if cfg.TLS != nil {
+		d.Checks = append(d.Checks, checkMCPTLS(cfg))
+	}
+	d.Checks = append(d.Checks, checkMCPReferences(cfg))
+	if isURLMCPTransport(cfg.Type) || (cfg.Type == "unix" && cfg.URL != "") {
+		d.Checks = append(d.Checks, checkMCPAuth(cfg, env.tokens))
+	}
+
+	var entry *cache.CacheEntry
+	if env.cache != nil {
+		entry, _ = env.cache.GetEntry(cfg.Name)
+	}
+	if entry != nil {
+		n := len(allowedCachedTools(cfg, entry))
+		d.CachedToolCount = &n
+	}
+
+	var tools []registry.ToolInfo
+	switch {
+	case !env.connect:
+		d.Checks = append(d.Checks, doctorCheck{Name: "connect", Status: doctorSkip, Message: "skipped (--no-connect)"})
+	case worstStatus(d.Checks) == doctorFail:
+		d.Checks = append(d.Checks, doctorCheck{Name: "connect", Status: doctorSkip, Message: "skipped until the failures above are fixed"})
+	default:
+		check, elapsed, ok := checkMCPConnect(ctx, reg, cfg, env.timeout)
+		d.Checks = append(d.Checks, check)
+		d.ConnectMillis = elapsed.Milliseconds()
+		if ok {
+			tools = reg.SearchTools("", cfg.Name)
+			n := len(tools)
+			d.ToolCount = &n
+			d.Checks = append(d.Checks, checkMCPCache(cfg, entry, tools))
+			d.Checks = append(d.Checks, checkMCPOverrides(cfg.Name, tools, env.overrides))
+		}
+	}
+
+	d.Status = worstStatus(d.Checks)
+	return d
+}
+
+// checkMCPFields validates the transport and the duration settings, which
+// the registry would otherwise silently replace with defaults.
+func checkMCPFields(cfg config.MCPConfig) doctorCheck {
+	var problems, fixes []string
+	fail := false
+	switch {
+	case !isValidMCPTransport(cfg.Type):
+		fail = true
+		problems = append(problems, fmt.Sprintf("unknown type %q", cfg.Type))
+		fixes = append(fixes, `set type to "stdio", "sse", "http", "streamable", "websocket", or "unix"`)
+	case (isURLMCPTransport(cfg.Type) || cfg.Type == "unix") && cfg.Command != "":
+		problems = append(problems, fmt.Sprintf("command is ignored for type %s", cfg.Type))
+		fixes = append(fixes, "remove command, or remove url and type to run it as a stdio MCP")
+	case cfg.Type != "unix" && cfg.Socket != "":
+		problems = append(problems, fmt.Sprintf("socket is ignored for type %s", cfg.Type))
+		fixes = append(fixes, `set type "unix" to use the socket`)
+	case !isURLMCPTransport(cfg.Type) && cfg.Type != "unix" && cfg.URL != "":
+		problems = append(problems, "url is ignored for a stdio MCP")
+		fixes = append(fixes, `set type "streamable" (or "sse") to use the url`)
+	case !isURLMCPTransport(cfg.Type) && cfg.Type != "unix" && (cfg.TLS != nil || cfg.Proxy != ""):
+		problems = append(problems, "tls and proxy are ignored for a stdio MCP")
+		fixes = append(fixes, "remove the tls and proxy settings, or connect to the server over a url")
+	case !isURLMCPTransport(cfg.Type) && cfg.Type != "unix" && cfg.OAuth != nil:
+		problems = append(problems, "oauth is ignored for a stdio MCP")
+		fixes = append(fixes, "remove the oauth block, or connect to the server over a url")
+	}
+
+	durations := []struct{ field, value string }{
+		{"timeout", cfg.Timeout},
+		{"health_check_interval", cfg.HealthCheckInterval},
+		{"elicitation_timeout", cfg.ElicitationTimeout},
+		{"circuit_cooldown", cfg.CircuitCooldown},
+		{"queue_timeout", cfg.QueueTimeout},
+		{"idle_timeout", cfg.IdleTimeout},
+		{"tool_timeout", cfg.ToolTimeout},
+	}
+	for _, t := range cfg.Tools {
+		durations = append(durations,
+			struct{ field, value string }{fmt.Sprintf("tool %q timeout", t.Pattern), t.Timeout},
+			struct{ field, value string }{fmt.Sprintf("tool %q cache_ttl", t.Pattern), t.CacheTTL})
+	}
+	if cfg.Retry != nil {
+		durations = append(durations,
+			struct{ field, value string }{"retry backoff", cfg.Retry.Backoff},
+			struct{ field, value string }{"retry max_backoff", cfg.Retry.MaxBackoff})
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d.value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not a duration and is ignored", d.field, d.value))
+			fixes = append(fixes, fmt.Sprintf(`write %s as a Go duration such as "30s" or "5m"`, d.field))
+		}
+	}
+
+	if len(problems) == 0 {
+		return doctorCheck{Name: "config", Status: doctorOK, Message: "fields are valid"}
+	}
+	status := doctorWarn
+	if fail {
+		status = doctorFail
+	}
+	return doctorCheck{
+		Name:    "config",
+		Status:  status,
+		Message: strings.Join(problems, "; "),
+		Fix:     strings.Join(fixes, "; "),
+	}
+}
+
+// checkMCPCommand checks that a stdio MCP's command resolves on PATH.
+func checkMCPCommand(cfg config.MCPConfig, lookPath func(string) (string, error)) doctorCheck {
+	if cfg.Command == "" {
+		return doctorCheck{Name: "command", Status: doctorFail, Message: "no command configured",
+			Fix: fmt.Sprintf("add a command to the %q block, or a url and type for a remote MCP", cfg.Name)}
+	}
+	path, err := lookPath(cfg.Command)
+	if err != nil {
+		return doctorCheck{Name: "command", Status: doctorFail,
+			Message: fmt.Sprintf("%s not found: %v", cfg.Command, err),
+			Fix:     fmt.Sprintf("install %s or set command to its absolute path (PATH is searched for bare names)", cfg.Command)}
+	}
+	return doctorCheck{Name: "command", Status: doctorOK, Message: path}
+}
+
+// checkMCPURL checks that a remote MCP's URL is an absolute http(s) URL.
+func checkMCPURL(cfg config.MCPConfig) doctorCheck {
+	fix := "set url to the server's MCP endpoint, e.g. https://host/mcp"
+	if cfg.URL == "" {
+		return doctorCheck{Name: "url", Status: doctorFail, Message: "no url configured", Fix: fix}
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return doctorCheck{Name: "url", Status: doctorFail, Message: fmt.Sprintf("invalid url: %v", err), Fix: fix}
+	}
+	switch {
+	case cfg.Type == "websocket" && u.Scheme != "ws" && u.Scheme != "wss" && u.Scheme != "http" && u.Scheme != "https":
+		return doctorCheck{Name: "url", Status: doctorFail, Message: fmt.Sprintf("url scheme %q is not ws, wss, http, or https", u.Scheme),
+			Fix: "set url to the server's WebSocket endpoint, e.g. wss://host/mcp"}
+	case cfg.Type != "websocket" && u.Scheme != "http" && u.Scheme != "https":
+		return doctorCheck{Name: "url", Status: doctorFail, Message: fmt.Sprintf("url scheme %q is not http or https", u.Scheme), Fix: fix}
+	}
+	if u.Host == "" {
+		return doctorCheck{Name: "url", Status: doctorFail, Message: "url has no host", Fix: fix}
+	}
+	if (u.Scheme == "http" || u.Scheme == "ws") && !isLoopbackHost(u.Hostname()) {
+		return doctorCheck{Name: "url", Status: doctorWarn, Message: fmt.Sprintf("plain %s to a remote host sends headers and tokens unencrypted", u.Scheme),
+			Fix: fmt.Sprintf("use %ss", u.Scheme)}
+	}
+	return doctorCheck{Name: "url", Status: doctorOK, Message: cfg.URL}
+}
+
+// checkMCPSocket checks that a unix MCP's socket exists and, when the MCP
+// speaks HTTP over it, that its url is an http(s) URL.
+func checkMCPSocket(cfg config.MCPConfig) doctorCheck {
+	if cfg.Socket == "" {
+		return doctorCheck{Name: "socket", Status: doctorFail, Message: "no socket configured",
+			Fix: "set socket to the path the server listens on"}
+	}
+	info, err := os.Stat(cfg.Socket)
+	if err != nil {
+		return doctorCheck{Name: "socket", Status: doctorFail, Message: err.Error(),
+			Fix: "start the server, or set socket to the path it listens on"}
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return doctorCheck{Name: "socket", Status: doctorFail, Message: fmt.Sprintf("%s is not a socket", cfg.Socket),
+			Fix: "set socket to the path the server listens on"}
+	}
+	if cfg.URL != "" {
+		if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return doctorCheck{Name: "socket", Status: doctorFail, Message: fmt.Sprintf("url %q is not an http URL", cfg.URL),
+				Fix: "set url to the server's MCP endpoint on the socket, e.g. http://localhost/mcp, or remove it for newline-delimited JSON-RPC"}
+		}
+	}
+	return doctorCheck{Name: "socket", Status: doctorOK, Message: cfg.Socket}
+}
+
+// checkMCPTLS loads the MCP's tls files as a connect would, and warns about
+// a client certificate close to expiry and about disabled verification.
+func checkMCPTLS(cfg config.MCPConfig) doctorCheck {
+	tlsCfg, err := registry.BuildTLSConfig(cfg)
+	if err != nil {
+		return doctorCheck{Name: "tls", Status: doctorFail, Message: err.Error(),
+			Fix: "point ca, cert, and key at readable PEM files (relative paths are relative to the config file)"}
+	}
+	if len(tlsCfg.Certificates) > 0 && tlsCfg.Certificates[0].Leaf != nil {
+		if left := time.Until(tlsCfg.Certificates[0].Leaf.NotAfter); left < 14*24*time.Hour {
+			return doctorCheck{Name: "tls", Status: doctorWarn,
+				Message: fmt.Sprintf("client certificate expires in %s", left.Round(time.Hour)),
+				Fix:     "renew the client certificate"}
+		}
+	}
+	if cfg.TLS.InsecureSkipVerify {
+		return doctorCheck{Name: "tls", Status: doctorWarn, Message: "insecure_skip_verify accepts any server certificate",
+			Fix: "set ca to the server's CA bundle and remove insecure_skip_verify"}
+	}
+	var parts []string
+	if cfg.TLS.CA != "" {
+		parts = append(parts, "ca")
+	}
+	if cfg.TLS.Cert != "" {
+		parts = append(parts, "client certificate")
+	}
+	if cfg.TLS.ServerName != "" {
+		parts = append(parts, "server name "+cfg.TLS.ServerName)
+	}
+	if len(parts) == 0 {
+		return doctorCheck{Name: "tls", Status: doctorOK, Message: "empty tls block"}
+	}
+	return doctorCheck{Name: "tls", Status: doctorOK, Message: strings.Join(parts, ", ")}
+}
+
+func isLoopbackHost(host string) bool {
+	return host == "localhost" || host == "::1" || strings.HasPrefix(host, "127.")
+}
+
+// checkMCPReferences resolves the ${...} references in env, headers, and the
+// oauth client secret, reporting which one fails without printing any
+// resolved value.
+func checkMCPReferences(cfg config.MCPConfig) doctorCheck {
+	if _, err := secrets.ExpandMap(cfg.Env); err != nil {
+		return doctorCheck{Name: "references", Status: doctorFail, Message: fmt.Sprintf("env %v", err),
+			Fix: "set the variable, create the file, or store the secret the reference names"}
+	}
+	if _, err := secrets.ExpandMap(cfg.Headers); err != nil {
+		return doctorCheck{Name: "references", Status: doctorFail, Message: fmt.Sprintf("header %v", err),
+			Fix: "set the variable, create the file, or store the secret the reference names"}
+	}
+	if cfg.OAuth != nil {
+		if _, err := secrets.Expand(cfg.OAuth.ClientSecret); err != nil {
+			return doctorCheck{Name: "references", Status: doctorFail, Message: fmt.Sprintf("oauth client_secret %v", err),
+				Fix: "set the variable, create the file, or store the secret the reference names"}
+		}
+	}
+	return doctorCheck{Name: "references", Status: doctorOK, Message: "env and header references resolve"}
+}
+
+// checkMCPAuth reports the stored OAuth token of a remote MCP, if any. An
+// MCP with an oauth block needs no login: a missing or expired token is
+// replaced by the client_credentials grant on connect.
+func checkMCPAuth(cfg config.MCPConfig, store *auth.TokenStore) doctorCheck {
+	login := fmt.Sprintf("slop-mcp mcp auth login %s", cfg.Name)
+	if store == nil {
+		return doctorCheck{Name: "auth", Status: doctorSkip, Message: "token store unavailable"}
+	}
+	token, err := store.GetToken(cfg.Name)
+	if err != nil {
+		return doctorCheck{Name: "auth", Status: doctorWarn, Message: fmt.Sprintf("cannot read tokens: %v", err),
+			Fix: fmt.Sprintf("check %s, or run %s", store.Path(), login)}
+	}
+	if token == nil {
+		if cfg.OAuth != nil {
+			return doctorCheck{Name: "auth", Status: doctorOK,
+				Message: "no token stored; the client_credentials grant runs on connect"}
+		}
+		return doctorCheck{Name: "auth", Status: doctorSkip, Message: "no OAuth token stored"}
+	}
+	if token.ServerURL != "" && token.ServerURL != cfg.URL {
+		return doctorCheck{Name: "auth", Status: doctorWarn,
+			Message: fmt.Sprintf("token was issued for %s, but url is %s", token.ServerURL, cfg.URL),
+			Fix:     login}
+	}
+	if token.IsExpired() {
+		if cfg.OAuth != nil {
+			return doctorCheck{Name: "auth", Status: doctorOK,
+				Message: fmt.Sprintf("token expired at %s; the client_credentials grant runs again on connect", token.ExpiresAt.Format(time.RFC3339))}
+		}
+		if token.RefreshToken != "" && token.TokenEndpoint != "" {
+			return doctorCheck{Name: "auth", Status: doctorOK,
+				Message: fmt.Sprintf("token expired at %s; it is refreshed on connect", token.ExpiresAt.Format(time.RFC3339))}
+		}
+		return doctorCheck{Name: "auth", Status: doctorFail,
+			Message: fmt.Sprintf("token expired at %s and cannot be refreshed", token.ExpiresAt.Format(time.RFC3339)),
+			Fix:     login}
+	}
+	if token.ExpiresAt.IsZero() {
+		return doctorCheck{Name: "auth", Status: doctorOK, Message: "token valid (no expiry)"}
+	}
+	return doctorCheck{Name: "auth", Status: doctorOK,
+		Message: fmt.Sprintf("token valid until %s", token.ExpiresAt.Format(time.RFC3339))}
+}
+
+// checkMCPConnect connects to the MCP once, timing the handshake and tool
+// listing, and reports whether it succeeded.
+func checkMCPConnect(ctx context.Context, reg *registry.Registry, cfg config.MCPConfig, timeout time.Duration) (doctorCheck, time.Duration, bool) {
+	if timeout > 0 {
+		cfg.Timeout = timeout.String()
+	}
+	start := time.Now()
+	err := reg.Connect(ctx, cfg)
+	elapsed := time.Since(start)
+	if err == nil {
+		return doctorCheck{Name: "connect", Status: doctorOK,
+			Message: fmt.Sprintf("connected in %s", elapsed.Round(time.Millisecond))}, elapsed, true
+	}
+
+	check := doctorCheck{Name: "connect", Status: doctorFail, Message: err.Error()}
+	switch {
+	case reg.GetState(cfg.Name) == registry.StateNeedsAuth:
+		check.Fix = fmt.Sprintf("slop-mcp mcp auth login %s", cfg.Name)
+	case elapsed >= registry.GetConnectionTimeout(cfg):
+		check.Fix = fmt.Sprintf("the server did not answer within %s; raise timeout on the %q block if it is slow to start",
+			registry.GetConnectionTimeout(cfg), cfg.Name)
+	case cfg.Type == "unix":
+		check.Fix = fmt.Sprintf("check that the server is listening on %s and speaks MCP", cfg.Socket)
+	case isURLMCPTransport(cfg.Type):
+		check.Fix = fmt.Sprintf("check that %s is reachable and serves MCP over %s", cfg.URL, cfg.Type)
+	default:
+		check.Fix = fmt.Sprintf("run the command by hand to check that it starts and speaks MCP on stdio: %s", strings.Join(append([]string{cfg.Command}, cfg.Args...), " "))
+	}
+	return check, elapsed, false
+}
+
+// allowedCachedTools returns the names of the cached tools the MCP's
+// allow/deny policy exposes, matching what the live index holds.
+func allowedCachedTools(cfg config.MCPConfig, entry *cache.CacheEntry) []string {
+	var names []string
+	for _, t := range entry.Tools {
+		if cfg.ToolAllowed(t.Name) {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// checkMCPCache compares the live tool list with the tool cache entry that
+// serve uses at startup.
+func checkMCPCache(cfg config.MCPConfig, entry *cache.CacheEntry, tools []registry.ToolInfo) doctorCheck {
+	if entry == nil {
+		return doctorCheck{Name: "cache", Status: doctorOK,
+			Message: fmt.Sprintf("%d tools; no cache entry yet (serve writes one on its first connect)", len(tools))}
+	}
+	if entry.ConfigHash != cache.ConfigHash(cfg) {
+		return doctorCheck{Name: "cache", Status: doctorWarn,
+			Message: "cache entry was written for an earlier command, url, socket, tls, proxy, env, or headers; serve ignores it",
+			Fix:     "none needed: serve replaces the entry on its next connect"}
+	}
+
+	live := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		live[t.Name] = true
+	}
+	cached := make(map[string]bool)
+	var removed []string
+	for _, name := range allowedCachedTools(cfg, entry) {
+		cached[name] = true
+		if !live[name] {
+			removed = append(removed, name)
+		}
+	}
+	var added []string
+	for _, t := range tools {
+		if !cached[t.Name] {
+			added = append(added, t.Name)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return doctorCheck{Name: "cache", Status: doctorOK,
+			Message: fmt.Sprintf("%d tools, matching the cache entry from %s", len(tools), entry.CachedAt.Format(time.RFC3339))}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "new: "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "gone: "+strings.Join(removed, ", "))
+	}
+	return doctorCheck{Name: "cache", Status: doctorWarn,
+		Message: fmt.Sprintf("%d tools live, %d cached (%s)", len(tools), len(cached), strings.Join(parts, "; ")),
+		Fix:     fmt.Sprintf(`set dynamic true on the %q block if its tools change often; a running serve picks up the change on reconnect`, cfg.Name)}
+}
+
+// checkMCPOverrides flags customize_tools overrides of the MCP's tools whose
+// tool is gone or whose upstream description or parameters changed since
+// the override was written.
+func checkMCPOverrides(mcpName string, tools []registry.ToolInfo, all map[overrides.Scope]map[string]overrides.OverrideEntry) doctorCheck {
+	byName := make(map[string]registry.ToolInfo, len(tools))
+	for _, t := range tools {
+		byName[t.Name] = t
+	}
+	prefix := mcpName + "."
+	total := 0
+	var stale, missing []string
+	for _, scope := range overrides.AllScopes {
+		for key, e := range all[scope] {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			total++
+			label := fmt.Sprintf("%s (%s)", key, scope)
+			t, ok := byName[strings.TrimPrefix(key, prefix)]
+			if !ok {
+				missing = append(missing, label)
+				continue
+			}
+			if e.Stale(t.UpstreamDescription(), overrides.ParamDescriptions(t.InputSchema)) {
+				stale = append(stale, label)
+			}
+		}
+	}
+	if total == 0 {
+		return doctorCheck{Name: "overrides", Status: doctorOK, Message: "no tool overrides"}
+	}
+	if len(stale) == 0 && len(missing) == 0 {
+		return doctorCheck{Name: "overrides", Status: doctorOK, Message: fmt.Sprintf("%d overrides, all current", total)}
+	}
+	sort.Strings(stale)
+	sort.Strings(missing)
+	var parts, fixes []string
+	if len(stale) > 0 {
+		parts = append(parts, "stale: "+strings.Join(stale, ", "))
+		fixes = append(fixes, "review stale ones with customize_tools list_overrides stale_only and set them again")
+	}
+	if len(missing) > 0 {
+		parts = append(parts, "tool gone: "+strings.Join(missing, ", "))
+		fixes = append(fixes, "delete overrides of removed tools with customize_tools remove_override")
+	}
+	return doctorCheck{Name: "overrides", Status: doctorWarn,
+		Message: strings.Join(parts, "; "),
+		Fix:     strings.Join(fixes, "; ")}
+}
+
+// worstStatus returns the most severe status of checks.
+func worstStatus(checks []doctorCheck) string {
+	rank := map[string]int{doctorOK: 0, doctorSkip: 0, doctorWarn: 1, doctorFail: 2}
+	worst := doctorOK
+	for _, c := range checks {
+		if rank[c.Status] > rank[worst] {
+			worst = c.Status
+		}
+	}
+	return worst
+}
+
+func printDoctorReport(report doctorReport) {
+	if len(report.MCPs) == 0 {
+		fmt.Println("No MCPs configured. Add one with: slop-mcp mcp add <name> <command>")
+		return
+	}
+	for _, m := range report.MCPs {
+		fmt.Printf("%s (%s, %s) - %s\n", m.Name, m.Type, m.Source, strings.ToUpper(m.Status))
+		if m.File != "" {
+			fmt.Printf("  file: %s\n", m.File)
+		}
+		for _, c := range m.Checks {
+			fmt.Printf("  [%-4s] %-10s %s\n", c.Status, c.Name, c.Message)
+			if c.Fix != "" && (c.Status == doctorWarn || c.Status == doctorFail) {
+				fmt.Printf("         %-10s fix: %s\n", "", c.Fix)
+			}
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%d MCPs checked: %d failures, %d warnings\n", len(report.MCPs), report.Failures, report.Warnings)
+}
+
+func printMCPDoctorUsage() {
+	fmt.Print(`slop-mcp mcp doctor - Diagnose configured MCP servers
+
+Usage:
+  slop-mcp mcp doctor [name...] [options]
+
+Options:
+  --json             Output as JSON
+  --no-connect       Only check the config; do not start or contact any MCP
+  --timeout <value>  Connect timeout for every MCP: seconds or duration like
+                     "30s" (default: each MCP's timeout)
+  --profile NAME     Apply config profile NAME (default: $SLOP_MCP_PROFILE)
+  --help, -h         Show this help
+
+For each MCP in the merged config (or just the named ones), doctor:
+  - validates the type and duration fields
+  - checks that a stdio command resolves on PATH, or that a url parses
+  - resolves ${env:...}, ${file:...}, and ${secret:...} references
+  - checks the stored OAuth token and its expiry
+  - connects, timing the handshake, and lists the tools
+  - compares the tools with the tool cache entry
+  - flags customize_tools overrides that are stale or whose tool is gone
+
+Every warning and failure comes with a suggested fix. Doctor connects on its
+own and works without a running server. It exits with status 1 when any
+check fails.
+
+Examples:
+  slop-mcp mcp doctor
+  slop-mcp mcp doctor github --timeout 10s
+  slop-mcp mcp doctor --no-connect --json
+`)
+}
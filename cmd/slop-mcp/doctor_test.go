@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/auth"
+	"github.com/standardbeagle/slop-mcp/internal/cache"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/overrides"
+)
+
+// newDoctorTestServer serves an MCP with the given tools over streamable HTTP.
+func newDoctorTestServer(t *testing.T, tools ...string) string {
+	t.Helper()
+	srv := mcp.NewServer(&mcp.Implementation{Name: "doctor-test", Version: "1.0.0"}, nil)
+	for _, name := range tools {
+		srv.AddTool(&mcp.Tool{
+			Name:        name,
+			Description: "does " + name,
+			InputSchema: map[string]any{"type": "object"},
+		}, func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{}, nil
+		})
+	}
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil))
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+func findCheck(t *testing.T, d doctorMCP, name string) doctorCheck {
+	t.Helper()
+	for _, c := range d.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check in %#v", name, d.Checks)
+	return doctorCheck{}
+}
+
+func TestParseMCPDoctorArgs(t *testing.T) {
+	opts, err := parseMCPDoctorArgs([]string{"github", "--json", "--no-connect", "--timeout", "10s", "--profile=work", "fs"})
+	if err != nil {
+		t.Fatalf("parseMCPDoctorArgs: %v", err)
+	}
+	if !opts.outputJSON || !opts.noConnect || opts.timeout != 10*time.Second {
+		t.Fatalf("unexpected options: %#v", opts)
+	}
+	if strings.Join(opts.names, ",") != "github,fs" || strings.Join(opts.profiles, ",") != "work" {
+		t.Fatalf("names = %v, profiles = %v", opts.names, opts.profiles)
+	}
+	if _, err := parseMCPDoctorArgs([]string{"--timeout"}); err == nil {
+		t.Fatal("expected missing timeout error")
+	}
+	if _, err := parseMCPDoctorArgs([]string{"--bogus"}); err == nil {
+		t.Fatal("expected unknown option error")
+	}
+}
+
+func TestCheckMCPFields(t *testing.T) {
+	c := checkMCPFields(config.MCPConfig{Name: "ok", Type: "stdio", Command: "x", Timeout: "30s"})
+	if c.Status != doctorOK {
+		t.Fatalf("valid config: %#v", c)
+	}
+
+	c = checkMCPFields(config.MCPConfig{Name: "bad", Type: "grpc"})
+	if c.Status != doctorFail || c.Fix == "" {
+		t.Fatalf("unknown type should fail with a fix: %#v", c)
+	}
+
+	c = checkMCPFields(config.MCPConfig{
+		Name: "slow", Type: "stdio", Command: "x", IdleTimeout: "ten minutes",
+		Tools: []config.ToolConfig{{Pattern: "get_*", CacheTTL: "5"}},
+	})
+	if c.Status != doctorWarn || !strings.Contains(c.Message, "idle_timeout") || !strings.Contains(c.Message, `tool "get_*" cache_ttl`) {
+		t.Fatalf("bad durations should warn: %#v", c)
+	}
+
+	c = checkMCPFields(config.MCPConfig{Name: "daemon", Type: "unix", Socket: "/run/d.sock", URL: "http://localhost/mcp"})
+	if c.Status != doctorOK {
+		t.Fatalf("unix MCP with url: %#v", c)
+	}
+	c = checkMCPFields(config.MCPConfig{Name: "daemon", Type: "stdio", Command: "x", Socket: "/run/d.sock"})
+	if c.Status != doctorWarn || !strings.Contains(c.Message, "socket is ignored") {
+		t.Fatalf("socket on a stdio MCP should warn: %#v", c)
+	}
+}
+
+func TestCheckMCPTLS(t *testing.T) {
+	dir := t.TempDir()
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	ts.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "ca.pem"), ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "config.kdl")
+
+	c := checkMCPTLS(config.MCPConfig{Name: "corp", File: file, TLS: &config.TLSConfig{CA: "ca.pem", ServerName: "mcp.internal"}})
+	if c.Status != doctorOK || !strings.Contains(c.Message, "server name mcp.internal") {
+		t.Fatalf("ca relative to the config file: %#v", c)
+	}
+	c = checkMCPTLS(config.MCPConfig{Name: "corp", File: file, TLS: &config.TLSConfig{Cert: "client.pem", Key: "client.key"}})
+	if c.Status != doctorFail || !strings.Contains(c.Message, "client.pem") {
+		t.Fatalf("missing client certificate: %#v", c)
+	}
+	c = checkMCPTLS(config.MCPConfig{Name: "corp", File: file, TLS: &config.TLSConfig{InsecureSkipVerify: true}})
+	if c.Status != doctorWarn {
+		t.Fatalf("insecure_skip_verify: %#v", c)
+	}
+	c = checkMCPFields(config.MCPConfig{Name: "fs", Type: "stdio", Command: "x", Proxy: "none"})
+	if c.Status != doctorWarn || !strings.Contains(c.Message, "proxy") {
+		t.Fatalf("proxy on a stdio MCP should warn: %#v", c)
+	}
+}
+
+func TestCheckMCPSocket(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	if c := checkMCPSocket(config.MCPConfig{Name: "d", Type: "unix", Socket: path}); c.Status != doctorOK {
+		t.Fatalf("listening socket: %#v", c)
+	}
+	if c := checkMCPSocket(config.MCPConfig{Name: "d", Type: "unix", Socket: path, URL: "ftp://x"}); c.Status != doctorFail {
+		t.Fatalf("non-http url: %#v", c)
+	}
+	if c := checkMCPSocket(config.MCPConfig{Name: "d", Type: "unix", Socket: filepath.Join(dir, "missing.sock")}); c.Status != doctorFail {
+		t.Fatalf("missing socket: %#v", c)
+	}
+	if c := checkMCPSocket(config.MCPConfig{Name: "d", Type: "unix", Socket: dir}); c.Status != doctorFail || !strings.Contains(c.Message, "not a socket") {
+		t.Fatalf("directory: %#v", c)
+	}
+}
+
+func TestCheckMCPCommand(t *testing.T) {
+	found := func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	missing := func(name string) (string, error) { return "", errors.New("executable file not found in $PATH") }
+
+	if c := checkMCPCommand(config.MCPConfig{Name: "fs", Command: "mcp-fs"}, found); c.Status != doctorOK || c.Message != "/usr/bin/mcp-fs" {
+		t.Fatalf("resolved command: %#v", c)
+	}
+	if c := checkMCPCommand(config.MCPConfig{Name: "fs", Command: "mcp-fs"}, missing); c.Status != doctorFail || !strings.Contains(c.Fix, "install mcp-fs") {
+		t.Fatalf("missing command: %#v", c)
+	}
+	if c := checkMCPCommand(config.MCPConfig{Name: "fs"}, found); c.Status != doctorFail {
+		t.Fatalf("empty command: %#v", c)
+	}
+}
+
+func TestCheckMCPURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.example.com/mcp", doctorOK},
+		{"http://localhost:3000/mcp", doctorOK},
+		{"http://api.example.com/mcp", doctorWarn},
+		{"ftp://api.example.com", doctorFail},
+		{"https://", doctorFail},
+		{"", doctorFail},
+		{"https://bad host/", doctorFail},
+		{"wss://api.example.com/mcp", doctorFail},
+	}
+	for _, tt := range tests {
+		if c := checkMCPURL(config.MCPConfig{Name: "api", Type: "http", URL: tt.url}); c.Status != tt.want {
+			t.Errorf("url %q: status %s, want %s (%s)", tt.url, c.Status, tt.want, c.Message)
+		}
+	}
+
+	wsTests := []struct {
+		url  string
+		want string
+	}{
+		{"wss://api.example.com/mcp", doctorOK},
+		{"ws://localhost:3000/mcp", doctorOK},
+		{"ws://api.example.com/mcp", doctorWarn},
+		{"https://api.example.com/mcp", doctorOK},
+		{"ftp://api.example.com", doctorFail},
+	}
+	for _, tt := range wsTests {
+		if c := checkMCPURL(config.MCPConfig{Name: "api", Type: "websocket", URL: tt.url}); c.Status != tt.want {
+			t.Errorf("websocket url %q: status %s, want %s (%s)", tt.url, c.Status, tt.want, c.Message)
+		}
+	}
+}
+
+func TestCheckMCPReferences(t *testing.T) {
+	t.Setenv("DOCTOR_TEST_SET", "x")
+	c := checkMCPReferences(config.MCPConfig{Env: map[string]string{"A": "${env:DOCTOR_TEST_SET}"}})
+	if c.Status != doctorOK {
+		t.Fatalf("resolvable reference: %#v", c)
+	}
+	c = checkMCPReferences(config.MCPConfig{Headers: map[string]string{"Authorization": "Bearer ${env:DOCTOR_TEST_UNSET}"}})
+	if c.Status != doctorFail || !strings.Contains(c.Message, "DOCTOR_TEST_UNSET") {
+		t.Fatalf("unresolvable reference: %#v", c)
+	}
+}
+
+func TestCheckMCPAuth(t *testing.T) {
+	store := auth.NewTokenStoreWithPath(filepath.Join(t.TempDir(), "auth.json"))
+	cfg := config.MCPConfig{Name: "figma", Type: "http", URL: "https://figma.example.com/mcp"}
+
+	if c := checkMCPAuth(cfg, store); c.Status != doctorSkip {
+		t.Fatalf("no token: %#v", c)
+	}
+
+	token := &auth.MCPToken{ServerName: "figma", ServerURL: cfg.URL, AccessToken: "a", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := store.SetToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if c := checkMCPAuth(cfg, store); c.Status != doctorOK || !strings.Contains(c.Message, "valid until") {
+		t.Fatalf("valid token: %#v", c)
+	}
+
+	token.ExpiresAt = time.Now().Add(-time.Hour)
+	if err := store.SetToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if c := checkMCPAuth(cfg, store); c.Status != doctorFail || c.Fix != "slop-mcp mcp auth login figma" {
+		t.Fatalf("expired token without refresh: %#v", c)
+	}
+
+	token.RefreshToken, token.TokenEndpoint = "r", "https://figma.example.com/token"
+	if err := store.SetToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if c := checkMCPAuth(cfg, store); c.Status != doctorOK || !strings.Contains(c.Message, "refreshed") {
+		t.Fatalf("expired token with refresh: %#v", c)
+	}
+
+	cfg.URL = "https://other.example.com/mcp"
+	if c := checkMCPAuth(cfg, store); c.Status != doctorWarn {
+		t.Fatalf("token for another URL: %#v", c)
+	}
+}
+
+func TestCheckMCPAuthClientCredentials(t *testing.T) {
+	store := auth.NewTokenStoreWithPath(filepath.Join(t.TempDir(), "auth.json"))
+	cfg := config.MCPConfig{Name: "ci", Type: "http", URL: "https://api.example.com/mcp",
+		OAuth: &config.OAuthConfig{ClientID: "ci", TokenEndpoint: "https://auth.example.com/token"}}
+
+	if c := checkMCPAuth(cfg, store); c.Status != doctorOK || !strings.Contains(c.Message, "client_credentials grant runs on connect") {
+		t.Fatalf("no token: %#v", c)
+	}
+
+	token := &auth.MCPToken{ServerName: "ci", ServerURL: cfg.URL, AccessToken: "a",
+		GrantType: auth.GrantClientCredentials, ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := store.SetToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if c := checkMCPAuth(cfg, store); c.Status != doctorOK || !strings.Contains(c.Message, "runs again") {
+		t.Fatalf("expired token: %#v", c)
+	}
+
+	cfg.OAuth.ClientSecret = "${env:DOCTOR_TEST_UNSET}"
+	if c := checkMCPReferences(cfg); c.Status != doctorFail || !strings.Contains(c.Message, "oauth client_secret") {
+		t.Fatalf("unresolved client secret: %#v", c)
+	}
+}
+
+func TestRunDoctorConnectsAndComparesCache(t *testing.T) {
+	url := newDoctorTestServer(t, "get_file", "put_file")
+	cfg := config.MCPConfig{Name: "files", Type: "streamable", URL: url}
+
+	cacheStore := cache.NewStoreWithPath(filepath.Join(t.TempDir(), "tools.json"))
+	if err := cacheStore.SetEntry("files", &cache.CacheEntry{
+		ConfigHash: cache.ConfigHash(cfg),
+		Tools:      []cache.CachedToolInfo{{Name: "get_file"}, {Name: "old_tool"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	stale := overrides.OverrideEntry{Description: "custom", SourceHash: "outdated"}
+	current := overrides.OverrideEntry{Description: "custom", SourceHash: overrides.ComputeHash("does get_file", map[string]string{})}
+	env := doctorEnv{
+		connect:  true,
+		timeout:  10 * time.Second,
+		lookPath: func(string) (string, error) { return "", errors.New("unused") },
+		tokens:   auth.NewTokenStoreWithPath(filepath.Join(t.TempDir(), "auth.json")),
+		cache:    cacheStore,
+		overrides: map[overrides.Scope]map[string]overrides.OverrideEntry{
+			overrides.ScopeUser: {
+				"files.get_file":  current,
+				"files.put_file":  stale,
+				"files.gone_tool": stale,
+				"other.get_file":  stale,
+			},
+		},
+	}
+
+	report := runDoctor(context.Background(), env, []config.MCPConfig{cfg})
+	if len(report.MCPs) != 1 {
+		t.Fatalf("report: %#v", report)
+	}
+	d := report.MCPs[0]
+	if c := findCheck(t, d, "connect"); c.Status != doctorOK {
+		t.Fatalf("connect: %#v", c)
+	}
+	if d.ToolCount == nil || *d.ToolCount != 2 || d.CachedToolCount == nil || *d.CachedToolCount != 2 {
+		t.Fatalf("tool counts: live %v, cached %v", d.ToolCount, d.CachedToolCount)
+	}
+	c := findCheck(t, d, "cache")
+	if c.Status != doctorWarn || !strings.Contains(c.Message, "new: put_file") || !strings.Contains(c.Message, "gone: old_tool") {
+		t.Fatalf("cache: %#v", c)
+	}
+	c = findCheck(t, d, "overrides")
+	if c.Status != doctorWarn || !strings.Contains(c.Message, "stale: files.put_file (user)") || !strings.Contains(c.Message, "tool gone: files.gone_tool (user)") {
+		t.Fatalf("overrides: %#v", c)
+	}
+	if strings.Contains(c.Message, "files.get_file") || strings.Contains(c.Message, "other.") {
+		t.Fatalf("current or foreign overrides flagged: %#v", c)
+	}
+	if d.Status != doctorWarn || report.Failures != 0 || report.Warnings != 2 {
+		t.Fatalf("status %s, %d failures, %d warnings", d.Status, report.Failures, report.Warnings)
+	}
+}
+
+func TestRunDoctorSkipsConnectAfterFailure(t *testing.T) {
+	env := doctorEnv{
+		connect:  true,
+		lookPath: func(string) (string, error) { return "", errors.New("not found") },
+	}
+	report := runDoctor(context.Background(), env, []config.MCPConfig{
+		{Name: "fs", Type: "stdio", Command: "slop-doctor-missing"},
+	})
+	d := report.MCPs[0]
+	if c := findCheck(t, d, "connect"); c.Status != doctorSkip {
+		t.Fatalf("connect should be skipped: %#v", c)
+	}
+	if d.Status != doctorFail || report.Failures != 1 {
+		t.Fatalf("status %s, %d failures", d.Status, report.Failures)
+	}
+}
+
+func TestRunDoctorReportsConnectFailureWithFix(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(ts.Close)
+	env := doctorEnv{connect: true, timeout: 5 * time.Second}
+	report := runDoctor(context.Background(), env, []config.MCPConfig{
+		{Name: "api", Type: "streamable", URL: ts.URL},
+	})
+	c := findCheck(t, report.MCPs[0], "connect")
+	if c.Status != doctorFail || c.Fix == "" {
+		t.Fatalf("connect failure: %#v", c)
+	}
+}
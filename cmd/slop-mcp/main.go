@@ -23,6 +23,8 @@ func main() {
 		cmdMonitor(os.Args[2:])
 	case "message":
 		cmdMessage(os.Args[2:])
+	case "secret":
+		cmdSecret(os.Args[2:])
 	case "mcp":
 		if len(os.Args) < 3 {
 			printMCPUsage()
@@ -45,6 +47,10 @@ func main() {
 			cmdMCPList(os.Args[3:])
 		case "status":
 			cmdMCPStatus(os.Args[3:])
+		case "logs":
+			cmdMCPLogs(os.Args[3:])
+		case "doctor":
+			cmdMCPDoctor(os.Args[3:])
 		case "auth":
 			cmdMCPAuth(os.Args[3:])
 		case "paths":
@@ -89,10 +95,13 @@ Commands:
   mcp get                      Get details of an MCP server
   mcp list                     List registered MCP servers
   mcp status                   Show live MCP connection status
+  mcp logs                     Show recent stderr output of a stdio MCP
+  mcp doctor                   Diagnose configured MCPs and suggest fixes
   mcp auth                     Manage OAuth authentication
   mcp paths                    Show config file paths
   mcp dump                     Show config file contents
   mcp metadata                 Get full MCP metadata (tools, prompts, resources)
+  secret                       Manage secrets referenced from MCP config
   version                      Show version
   help                         Show this help
 
@@ -109,7 +118,7 @@ Usage:
 Subcommands:
   add <name> <command> [args...] [options]
       Register an MCP server (stdio transport)
-      Options: --local, --project, --user, --transport, --url, --env, --header
+      Options: --local, --project, --user, --transport, --url, --socket, --env, --header
 
   add-json <name> '<json>' [--local|--project|--user]
       Register an MCP server from JSON config
@@ -133,6 +142,12 @@ Subcommands:
   status [--port=<port>] [--json]
       Show live MCP connection status from a running server
 
+  logs <name> [--lines=<n>] [--port=<port>] [--json]
+      Show recent stderr output of a stdio MCP from a running server
+
+  doctor [names...] [--no-connect] [--timeout=<value>] [--json]
+      Check each MCP's config, command, URL, auth, and connection
+
   auth <action> [name]
       Manage OAuth authentication (login, logout, status, list)
 
@@ -151,8 +166,9 @@ Scope Options:
   --user       User config ($XDG_CONFIG_HOME/slop-mcp/config.kdl or ~/.config/slop-mcp/config.kdl)
 
 Transport Options:
-  --transport=<type>  stdio (default), sse, http, streamable
+  --transport=<type>  stdio (default), sse, http, streamable, websocket, unix
   --url=<url>         Server URL (required for http transports)
+  --socket=<path>     Unix socket path (required for the unix transport)
 
 Config Options:
   --env KEY=VALUE, -e KEY=VALUE      Set environment variable
@@ -184,6 +200,9 @@ Examples:
   # Check live connection status
   slop-mcp mcp status --port=8080
 
+  # Diagnose a failing MCP
+  slop-mcp mcp doctor github
+
   # Authenticate with an MCP
   slop-mcp mcp auth login figma
   slop-mcp mcp auth list
@@ -18,17 +18,22 @@ import (
 	"github.com/standardbeagle/slop-mcp/internal/auth"
 	"github.com/standardbeagle/slop-mcp/internal/config"
 	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
 )
 
 var getwd = os.Getwd
 
 // redactValue masks a secret env/header value for display so `mcp get`/`mcp
 // list` do not leak API keys or tokens into terminal scrollback while still
-// confirming the variable is set.
+// confirming the variable is set. A value that is just a ${secret:...}-style
+// reference names the secret without containing it, so it is shown as is.
 func redactValue(v string) string {
 	if v == "" {
 		return ""
 	}
+	if secrets.IsReference(v) {
+		return v
+	}
 	return "***redacted***"
 }
 
@@ -77,6 +82,7 @@ func cmdMCPAdd(args []string) {
 		Command: opts.command,
 		Args:    opts.cmdArgs,
 		URL:     opts.url,
+		Socket:  opts.socket,
 	}
 	if len(opts.env) > 0 {
 		mcpCfg.Env = opts.env
@@ -103,6 +109,7 @@ type mcpAddOptions struct {
 	env       map[string]string
 	headers   map[string]string
 	url       string
+	socket    string
 }
 
 func parseMCPAddArgs(args []string) (mcpAddOptions, bool, error) {
@@ -153,6 +160,14 @@ func parseMCPAddArgs(args []string) (mcpAddOptions, bool, error) {
 			i++
 		case strings.HasPrefix(args[i], "--url="):
 			opts.url = strings.TrimPrefix(args[i], "--url=")
+		case args[i] == "--socket":
+			if i+1 >= len(args) {
+				return opts, false, fmt.Errorf("--socket requires a path")
+			}
+			opts.socket = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--socket="):
+			opts.socket = strings.TrimPrefix(args[i], "--socket=")
 		case args[i] == "--env" || args[i] == "-e":
 			if i+1 >= len(args) {
 				return opts, false, fmt.Errorf("--env requires KEY=VALUE")
@@ -193,7 +208,24 @@ func parseMCPAddArgs(args []string) (mcpAddOptions, bool, error) {
 	}
 
 	if !isValidMCPTransport(opts.transport) {
-		return opts, false, fmt.Errorf("invalid transport %q (must be stdio, command, sse, http, or streamable)", opts.transport)
+		return opts, false, fmt.Errorf("invalid transport %q (must be stdio, command, sse, http, streamable, websocket, or unix)", opts.transport)
+	}
+
+	if opts.transport == "unix" {
+		if len(positional) != 1 {
+			if len(positional) == 0 {
+				return opts, false, fmt.Errorf("name is required")
+			}
+			return opts, false, fmt.Errorf("unexpected extra argument %q for unix transport", positional[1])
+		}
+		opts.name = positional[0]
+		if opts.socket == "" {
+			return opts, false, fmt.Errorf("--socket is required for the unix transport")
+		}
+		return opts, false, nil
+	}
+	if opts.socket != "" {
+		return opts, false, fmt.Errorf("--socket is only used with --transport=unix")
 	}
 
 	// For HTTP transports, we don't need a command
@@ -224,12 +256,12 @@ func parseMCPAddArgs(args []string) (mcpAddOptions, bool, error) {
 }
 
 func isURLMCPTransport(transport string) bool {
-	return transport == "sse" || transport == "http" || transport == "streamable"
+	return transport == "sse" || transport == "http" || transport == "streamable" || transport == "websocket"
 }
 
 func isValidMCPTransport(transport string) bool {
 	switch transport {
-	case "stdio", "command", "sse", "http", "streamable":
+	case "stdio", "command", "sse", "http", "streamable", "websocket", "unix":
 		return true
 	}
 	return false
@@ -261,6 +293,7 @@ func printMCPAddUsage() {
 Usage:
   slop-mcp mcp add <name> <command> [args...] [options]
   slop-mcp mcp add <name> --transport=sse --url=<url> [options]
+  slop-mcp mcp add <name> --transport=unix --socket=<path> [--url=<url>] [options]
 
 Arguments:
   <name>       Name for the MCP server (used to reference it)
@@ -274,8 +307,11 @@ Options:
   -s, --scope=<scope>  Set scope: local, project, or user
 
   --transport=<type>, -t <type>
-                Transport type: stdio (default), sse, http, streamable
-  --url=<url>   Server URL (required for http/sse/streamable transports)
+                Transport type: stdio (default), sse, http, streamable, websocket, unix
+  --url=<url>   Server URL (required for http/sse/streamable/websocket transports;
+                with unix, speak streamable HTTP to this URL over the socket)
+  --socket=<path>
+                Unix socket path (required for the unix transport)
 
   --env KEY=VALUE, -e KEY=VALUE
                 Set environment variable (can be repeated)
@@ -298,6 +334,13 @@ Examples:
   # HTTP transport with headers
   slop-mcp mcp add secure --transport=http --url=https://api.example.com/mcp \
     -H "Authorization: Bearer token"
+
+  # WebSocket transport
+  slop-mcp mcp add internal --transport=websocket --url=wss://mcp.internal.example.com/ws
+
+  # Unix socket: newline-delimited JSON-RPC, or streamable HTTP with --url
+  slop-mcp mcp add daemon --transport=unix --socket=/run/daemon/mcp.sock
+  slop-mcp mcp add daemon --transport=unix --socket=/run/daemon/http.sock --url=http://localhost/mcp
 `)
 }
 
@@ -1099,6 +1142,15 @@ func printMCPList(cfg *config.Config) {
 			sort.Strings(keys)
 			fmt.Printf("    env: %d variables (%s)\n", len(mcp.Env), strings.Join(keys, ", "))
 		}
+		if mcp.File != "" {
+			fmt.Printf("    file: %s\n", mcp.File)
+		}
+		if mcp.Profile != "" {
+			fmt.Printf("    profile: %s\n", mcp.Profile)
+		}
+	}
+	if len(cfg.Profiles) > 0 {
+		fmt.Printf("  (profiles defined: %s)\n", strings.Join(cfg.Profiles, ", "))
 	}
 }
 
@@ -1116,10 +1168,14 @@ Options:
   --json       Output as JSON
   --help, -h   Show this help
 
+Each MCP shows the file it was read from and, when it comes from a profile
+block, the profile. Profiles named in SLOP_MCP_PROFILE are applied.
+
 Examples:
   slop-mcp mcp list
   slop-mcp mcp list --project
   slop-mcp mcp list --json
+  SLOP_MCP_PROFILE=work slop-mcp mcp list
 `)
 }
 
@@ -1493,6 +1549,145 @@ Examples:
 `)
 }
 
+func cmdMCPLogs(args []string) {
+	opts, err := parseMCPLogsArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		printMCPLogsUsage()
+		os.Exit(1)
+	}
+	if opts.showHelp {
+		printMCPLogsUsage()
+		return
+	}
+
+	endpoint := fmt.Sprintf("http://localhost:%d/logs?name=%s", opts.port, url.QueryEscape(opts.name))
+	if opts.lines > 0 {
+		endpoint += "&lines=" + strconv.Itoa(opts.lines)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to server at %s: %v\n", endpoint, err)
+		fmt.Fprintf(os.Stderr, "Make sure slop-mcp is running with: slop-mcp serve --port %d\n", opts.port)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var output struct {
+		Logs  []string `json:"logs"`
+		Error string   `json:"error"`
+	}
+	if err := json.Unmarshal(body, &output); err != nil {
+		fmt.Fprintf(os.Stderr, "Server error: %s\n", strings.TrimSpace(string(body)))
+		os.Exit(1)
+	}
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "Server error: %s\n", output.Error)
+		os.Exit(1)
+	}
+
+	if opts.outputJSON {
+		pretty, _ := json.MarshalIndent(output.Logs, "", "  ")
+		fmt.Println(string(pretty))
+		return
+	}
+	for _, line := range output.Logs {
+		fmt.Println(line)
+	}
+}
+
+type mcpLogsOptions struct {
+	name       string
+	port       int
+	lines      int
+	outputJSON bool
+	showHelp   bool
+}
+
+func parseMCPLogsArgs(args []string) (mcpLogsOptions, error) {
+	opts := mcpLogsOptions{port: 8080}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--json":
+			opts.outputJSON = true
+		case strings.HasPrefix(args[i], "--port="):
+			port, err := parseMCPPort(strings.TrimPrefix(args[i], "--port="))
+			if err != nil {
+				return opts, err
+			}
+			opts.port = port
+		case args[i] == "--port":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--port requires a value")
+			}
+			port, err := parseMCPPort(args[i+1])
+			if err != nil {
+				return opts, err
+			}
+			opts.port = port
+			i++
+		case strings.HasPrefix(args[i], "--lines="), args[i] == "--lines" || args[i] == "-n":
+			raw := strings.TrimPrefix(args[i], "--lines=")
+			if raw == args[i] {
+				if i+1 >= len(args) {
+					return opts, fmt.Errorf("%s requires a value", args[i])
+				}
+				raw = args[i+1]
+				i++
+			}
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("invalid --lines value %q: expected a positive number", raw)
+			}
+			opts.lines = n
+		case args[i] == "--help" || args[i] == "-h":
+			opts.showHelp = true
+		case strings.HasPrefix(args[i], "-"):
+			return opts, fmt.Errorf("unknown logs option %q", args[i])
+		case opts.name == "":
+			opts.name = args[i]
+		default:
+			return opts, fmt.Errorf("unexpected argument %q", args[i])
+		}
+	}
+	if opts.name == "" && !opts.showHelp {
+		return opts, fmt.Errorf("MCP name is required")
+	}
+	return opts, nil
+}
+
+func printMCPLogsUsage() {
+	fmt.Print(`slop-mcp mcp logs - Show recent stderr output of a stdio MCP
+
+Usage:
+  slop-mcp mcp logs <name> [--lines=<n>] [--port=<port>] [--json]
+
+Options:
+  --lines=<n>, -n  Show only the last n lines (default: all buffered)
+  --port=<port>    Server port (default: 8080)
+  --json           Output as JSON
+  --help, -h       Show this help
+
+This command queries a running slop-mcp server for the stderr lines it
+captured from an MCP subprocess. The server keeps the last 200 lines per
+MCP (see stderr_lines in the KDL config); set stderr_log to also keep a
+full log file.
+
+Examples:
+  slop-mcp mcp logs filesystem             # All buffered lines
+  slop-mcp mcp logs filesystem -n 20       # Last 20 lines
+  slop-mcp mcp logs filesystem --json      # Output as JSON
+`)
+}
+
 func cmdMCPAuth(args []string) {
 	if len(args) < 1 {
 		printMCPAuthUsage()
@@ -1532,23 +1727,39 @@ func cmdMCPAuth(args []string) {
 			os.Exit(1)
 		}
 
-		flow := &auth.OAuthFlow{
-			ServerName: name,
-			ServerURL:  cfg.URL,
-			Store:      store,
-		}
-
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 		defer cancel()
 
-		fmt.Printf("Starting OAuth flow for %s...\n", name)
-		result, err := flow.DiscoverAndAuth(ctx)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: OAuth flow failed: %v\n", err)
-			os.Exit(1)
+		var result *auth.AuthResult
+		if cfg.OAuth != nil {
+			// An oauth block means the client_credentials grant: no browser.
+			flow, err := registry.NewClientCredentialsFlow(*cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			result, err = flow.Authenticate(ctx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: client_credentials grant failed: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Obtained a client_credentials token for %s\n", name)
+		} else {
+			flow := &auth.OAuthFlow{
+				ServerName: name,
+				ServerURL:  cfg.URL,
+				Store:      store,
+			}
+
+			fmt.Printf("Starting OAuth flow for %s...\n", name)
+			result, err = flow.DiscoverAndAuth(ctx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: OAuth flow failed: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully authenticated with %s\n", name)
 		}
 
-		fmt.Printf("Successfully authenticated with %s\n", name)
 		fmt.Printf("  Server URL: %s\n", result.Token.ServerURL)
 		if !result.Token.ExpiresAt.IsZero() {
 			fmt.Printf("  Expires at: %s\n", result.Token.ExpiresAt.Format(time.RFC3339))
@@ -1588,6 +1799,9 @@ func cmdMCPAuth(args []string) {
 		fmt.Printf("%s:\n", name)
 		fmt.Printf("  Authenticated: yes\n")
 		fmt.Printf("  Server URL: %s\n", token.ServerURL)
+		if token.GrantType != "" {
+			fmt.Printf("  Grant: %s\n", token.GrantType)
+		}
 		if !token.ExpiresAt.IsZero() {
 			fmt.Printf("  Expires at: %s\n", token.ExpiresAt.Format(time.RFC3339))
 			if token.IsExpired() {
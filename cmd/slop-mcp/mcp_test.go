@@ -191,6 +191,37 @@ func TestParseMCPStatusArgs(t *testing.T) {
 	}
 }
 
+func TestParseMCPLogsArgs(t *testing.T) {
+	opts, err := parseMCPLogsArgs([]string{"filesystem", "--port", "3000", "-n", "20", "--json"})
+	if err != nil {
+		t.Fatalf("parseMCPLogsArgs: %v", err)
+	}
+	if opts.name != "filesystem" || opts.port != 3000 || opts.lines != 20 || !opts.outputJSON {
+		t.Fatalf("unexpected options: %#v", opts)
+	}
+
+	opts, err = parseMCPLogsArgs([]string{"--lines=5", "github"})
+	if err != nil {
+		t.Fatalf("parseMCPLogsArgs equals: %v", err)
+	}
+	if opts.name != "github" || opts.lines != 5 || opts.port != 8080 {
+		t.Fatalf("unexpected options: %#v", opts)
+	}
+
+	if _, err := parseMCPLogsArgs(nil); err == nil {
+		t.Fatal("expected missing name error")
+	}
+	if _, err := parseMCPLogsArgs([]string{"a", "b"}); err == nil {
+		t.Fatal("expected extra argument error")
+	}
+	if _, err := parseMCPLogsArgs([]string{"a", "--lines", "0"}); err == nil {
+		t.Fatal("expected invalid lines error")
+	}
+	if _, err := parseMCPLogsArgs([]string{"a", "--bogus"}); err == nil {
+		t.Fatal("expected unknown option error")
+	}
+}
+
 func TestParseMCPMetadataArgs(t *testing.T) {
 	opts, err := parseMCPMetadataArgs([]string{"--port", "3000", "--output", "mcps.json", "--mcp=figma", "--json"})
 	if err != nil {
@@ -338,8 +369,19 @@ func TestParseMCPAddArgs(t *testing.T) {
 		t.Fatalf("unexpected http opts: %#v help=%v", opts, showHelp)
 	}
 
+	opts, _, err = parseMCPAddArgs([]string{"daemon", "-t", "unix", "--socket", "/run/daemon.sock", "--url=http://localhost/mcp"})
+	if err != nil {
+		t.Fatalf("parseMCPAddArgs unix: %v", err)
+	}
+	if opts.name != "daemon" || opts.socket != "/run/daemon.sock" || opts.url != "http://localhost/mcp" {
+		t.Fatalf("unexpected unix opts: %#v", opts)
+	}
+
 	for _, args := range [][]string{
 		{},
+		{"daemon", "--transport=unix"},
+		{"daemon", "extra", "--transport=unix", "--socket=/run/daemon.sock"},
+		{"daemon", "npx", "--socket=/run/daemon.sock"},
 		{"api", "extra", "--transport=http", "--url=https://example.test/mcp"},
 		{"api", "--transport=http"},
 		{"api", "--transport=ftp", "--url=https://example.test/mcp"},
@@ -73,7 +73,7 @@ func cmdMonitor(args []string) {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-	cfg, err := config.Load(cwd)
+	cfg, err := config.LoadProfiles(cwd, selectedProfiles(opts.profiles))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
@@ -98,20 +98,11 @@ func cmdMonitor(args []string) {
 
 	// Connect all configured MCPs
 	for _, mcpCfg := range cfg.MCPs {
-		transportType := mcpCfg.Type
-		if transportType == "stdio" {
-			transportType = "command"
+		slopCfg, err := slopMCPConfig(mcpCfg)
+		if err == nil {
+			err = rt.ConnectMCP(ctx, slopCfg)
 		}
-		slopCfg := slop.MCPConfig{
-			Name:    mcpCfg.Name,
-			Type:    transportType,
-			Command: mcpCfg.Command,
-			Args:    mcpCfg.Args,
-			Env:     mapToSlice(mcpCfg.Env),
-			URL:     mcpCfg.URL,
-			Headers: mcpCfg.Headers,
-		}
-		if err := rt.ConnectMCP(ctx, slopCfg); err != nil {
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to connect MCP %s: %v\n", mcpCfg.Name, err)
 		}
 	}
@@ -133,6 +124,7 @@ type monitorOptions struct {
 	inlineScript string
 	showHelp     bool
 	timeout      time.Duration
+	profiles     []string
 }
 
 func parseMonitorArgs(args []string) (monitorOptions, error) {
@@ -161,6 +153,22 @@ func parseMonitorArgs(args []string) (monitorOptions, error) {
 				return opts, err
 			}
 			opts.timeout = timeout
+		case args[i] == "--profile":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--profile requires a value")
+			}
+			profiles, err := parseProfileValue(args[i+1])
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
+			i++
+		case strings.HasPrefix(args[i], "--profile="):
+			profiles, err := parseProfileValue(strings.TrimPrefix(args[i], "--profile="))
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
 		case args[i] == "--help" || args[i] == "-h":
 			opts.showHelp = true
 		case strings.HasPrefix(args[i], "-"):
@@ -277,6 +285,8 @@ Options:
   -e '<script>'        Execute inline script
   --timeout=<value>    Stop after the given time: seconds or duration like
                        "30s", "5m" (default: no timeout)
+  --profile NAME       Apply config profile NAME (comma-separated for several;
+                       default: $SLOP_MCP_PROFILE)
   --help, -h           Show this help
 
 Built-in Functions (in addition to standard SLOP built-ins):
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -14,6 +15,7 @@ func TestParseMonitorArgs(t *testing.T) {
 		wantHelp    bool
 		wantTimeout time.Duration
 		wantErr     bool
+		wantProfs   []string
 	}{
 		{name: "watch messages only", args: nil},
 		{name: "script file", args: []string{"watch.slop"}, wantFile: "watch.slop"},
@@ -21,6 +23,9 @@ func TestParseMonitorArgs(t *testing.T) {
 		{name: "timeout equals", args: []string{"--timeout=30s"}, wantTimeout: 30 * time.Second},
 		{name: "timeout separate", args: []string{"--timeout", "2m"}, wantTimeout: 2 * time.Minute},
 		{name: "help", args: []string{"-h"}, wantHelp: true},
+		{name: "profile", args: []string{"--profile", "work"}, wantProfs: []string{"work"}},
+		{name: "profile equals", args: []string{"--profile=work,ci"}, wantProfs: []string{"work", "ci"}},
+		{name: "missing profile", args: []string{"--profile"}, wantErr: true},
 		{name: "missing inline", args: []string{"-e"}, wantErr: true},
 		{name: "missing timeout", args: []string{"--timeout"}, wantErr: true},
 		{name: "bad timeout", args: []string{"--timeout=0"}, wantErr: true},
@@ -53,6 +58,9 @@ func TestParseMonitorArgs(t *testing.T) {
 			if got.timeout != tt.wantTimeout {
 				t.Fatalf("timeout = %v, want %v", got.timeout, tt.wantTimeout)
 			}
+			if !reflect.DeepEqual(got.profiles, tt.wantProfs) {
+				t.Fatalf("profiles = %v, want %v", got.profiles, tt.wantProfs)
+			}
 		})
 	}
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/standardbeagle/slop-mcp/internal/builtins"
 	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
 	"github.com/standardbeagle/slop/pkg/slop"
 )
 
@@ -47,7 +48,7 @@ func cmdRun(args []string) {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-	cfg, err := config.Load(cwd)
+	cfg, err := config.LoadProfiles(cwd, selectedProfiles(opts.profiles))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
@@ -76,23 +77,11 @@ func cmdRun(args []string) {
 
 	// Connect all configured MCPs
 	for _, mcpCfg := range cfg.MCPs {
-		// Normalize type for SLOP runtime
-		transportType := mcpCfg.Type
-		if transportType == "stdio" {
-			transportType = "command"
+		slopCfg, err := slopMCPConfig(mcpCfg)
+		if err == nil {
+			err = rt.ConnectMCP(ctx, slopCfg)
 		}
-
-		slopCfg := slop.MCPConfig{
-			Name:    mcpCfg.Name,
-			Type:    transportType,
-			Command: mcpCfg.Command,
-			Args:    mcpCfg.Args,
-			Env:     mapToSlice(mcpCfg.Env),
-			URL:     mcpCfg.URL,
-			Headers: mcpCfg.Headers,
-		}
-
-		if err := rt.ConnectMCP(ctx, slopCfg); err != nil {
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to connect MCP %s: %v\n", mcpCfg.Name, err)
 		}
 	}
@@ -139,6 +128,7 @@ type runOptions struct {
 	inlineScript string
 	timeout      time.Duration
 	outputJSON   bool
+	profiles     []string
 	showHelp     bool
 }
 
@@ -174,6 +164,22 @@ func parseRunArgs(args []string) (runOptions, error) {
 				return opts, err
 			}
 			opts.timeout = timeout
+		case arg == "--profile":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--profile requires a value")
+			}
+			i++
+			profiles, err := parseProfileValue(args[i])
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
+		case strings.HasPrefix(arg, "--profile="):
+			profiles, err := parseProfileValue(strings.TrimPrefix(arg, "--profile="))
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
 		case strings.HasPrefix(arg, "-"):
 			return opts, fmt.Errorf("unknown option %q", arg)
 		default:
@@ -213,6 +219,73 @@ func parseTimeoutValue(s string) (time.Duration, error) {
 	return time.Duration(secs) * time.Second, nil
 }
 
+// parseProfileValue parses a --profile value: one profile name or a
+// comma-separated list.
+func parseProfileValue(s string) ([]string, error) {
+	profiles := config.ParseProfiles(s)
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("--profile requires a profile name")
+	}
+	return profiles, nil
+}
+
+// selectedProfiles returns the profiles given with --profile, or those in
+// SLOP_MCP_PROFILE when there were none.
+func selectedProfiles(flag []string) []string {
+	if len(flag) > 0 {
+		return flag
+	}
+	return config.ProfilesFromEnv()
+}
+
+// slopMCPConfig converts an MCP config for the SLOP runtime that run and
+// monitor connect through. The runtime speaks only stdio, SSE, and
+// streamable HTTP without TLS, proxy, or OAuth settings, so any other MCP is
+// refused here rather than connected without them.
+func slopMCPConfig(mcpCfg config.MCPConfig) (slop.MCPConfig, error) {
+	transportType := mcpCfg.Type
+	switch transportType {
+	case "stdio", "command", "":
+		transportType = "command"
+	case "http", "streamable":
+		transportType = "streamable"
+	case "sse":
+	default:
+		return slop.MCPConfig{}, fmt.Errorf("type %q is unsupported by run and monitor (use serve)", mcpCfg.Type)
+	}
+	var unsupported []string
+	if mcpCfg.TLS != nil {
+		unsupported = append(unsupported, "tls")
+	}
+	if mcpCfg.Proxy != "" {
+		unsupported = append(unsupported, "proxy")
+	}
+	if mcpCfg.OAuth != nil {
+		unsupported = append(unsupported, "oauth")
+	}
+	if len(unsupported) > 0 {
+		return slop.MCPConfig{}, fmt.Errorf("%s unsupported by run and monitor (use serve)", strings.Join(unsupported, ", "))
+	}
+
+	env, err := secrets.ExpandMap(mcpCfg.Env)
+	if err != nil {
+		return slop.MCPConfig{}, fmt.Errorf("env %w", err)
+	}
+	headers, err := secrets.ExpandMap(mcpCfg.Headers)
+	if err != nil {
+		return slop.MCPConfig{}, fmt.Errorf("header %w", err)
+	}
+	return slop.MCPConfig{
+		Name:    mcpCfg.Name,
+		Type:    transportType,
+		Command: mcpCfg.Command,
+		Args:    mcpCfg.Args,
+		Env:     mapToSlice(env),
+		URL:     mcpCfg.URL,
+		Headers: headers,
+	}, nil
+}
+
 func mapToSlice(m map[string]string) []string {
 	result := make([]string, 0, len(m))
 	for k, v := range m {
@@ -282,6 +355,8 @@ Options:
   --timeout <value>  Execution timeout: seconds or duration like "30s", "5m"
   --timeout=<value>  Same as --timeout <value>
                      (default: 300)
+  --profile NAME     Apply config profile NAME (comma-separated for several;
+                     default: $SLOP_MCP_PROFILE)
   --help, -h         Show this help
 
 This command executes SLOP scripts with access to all configured MCPs.
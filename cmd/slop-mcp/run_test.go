@@ -1,8 +1,11 @@
 package main
 
 import (
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
 )
 
 func TestParseRunArgs(t *testing.T) {
@@ -128,3 +131,57 @@ func TestParseRunArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestRunProfileOption(t *testing.T) {
+	got, err := parseRunArgs([]string{"-e", "emit 1", "--profile", "work", "--profile=ci"})
+	if err != nil {
+		t.Fatalf("parseRunArgs: %v", err)
+	}
+	if len(got.profiles) != 2 || got.profiles[0] != "work" || got.profiles[1] != "ci" {
+		t.Fatalf("profiles = %v, want [work ci]", got.profiles)
+	}
+	if _, err := parseRunArgs([]string{"-e", "emit 1", "--profile="}); err == nil {
+		t.Fatal("expected empty profile error")
+	}
+}
+
+func TestSelectedProfilesFallsBackToEnv(t *testing.T) {
+	t.Setenv(config.ProfileEnvVar, "ci, debug")
+	if got := selectedProfiles(nil); len(got) != 2 || got[0] != "ci" || got[1] != "debug" {
+		t.Fatalf("selectedProfiles(nil) = %v, want [ci debug]", got)
+	}
+	if got := selectedProfiles([]string{"work"}); len(got) != 1 || got[0] != "work" {
+		t.Fatalf("selectedProfiles([work]) = %v, want [work]", got)
+	}
+}
+
+func TestSlopMCPConfig(t *testing.T) {
+	for _, tc := range []struct {
+		cfg      config.MCPConfig
+		wantType string
+		wantErr  string
+	}{
+		{cfg: config.MCPConfig{Type: "stdio", Command: "x"}, wantType: "command"},
+		{cfg: config.MCPConfig{Type: "http", URL: "https://example.com/mcp"}, wantType: "streamable"},
+		{cfg: config.MCPConfig{Type: "sse", URL: "https://example.com/sse"}, wantType: "sse"},
+		{cfg: config.MCPConfig{Type: "websocket", URL: "wss://example.com"}, wantErr: `type "websocket" is unsupported`},
+		{cfg: config.MCPConfig{Type: "unix", Socket: "/tmp/mcp.sock"}, wantErr: `type "unix" is unsupported`},
+		{cfg: config.MCPConfig{Type: "http", URL: "https://example.com", TLS: &config.TLSConfig{}, Proxy: "http://proxy:3128"}, wantErr: "tls, proxy unsupported"},
+		{cfg: config.MCPConfig{Type: "streamable", URL: "https://example.com", OAuth: &config.OAuthConfig{ClientID: "ci"}}, wantErr: "oauth unsupported"},
+	} {
+		got, err := slopMCPConfig(tc.cfg)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("slopMCPConfig(%+v) error = %v, want %q", tc.cfg, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("slopMCPConfig(%+v): %v", tc.cfg, err)
+			continue
+		}
+		if got.Type != tc.wantType {
+			t.Errorf("slopMCPConfig(%+v).Type = %q, want %q", tc.cfg, got.Type, tc.wantType)
+		}
+	}
+}
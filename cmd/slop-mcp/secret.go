@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
+)
+
+func cmdSecret(args []string) {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" || args[0] == "help" {
+		printSecretUsage()
+		return
+	}
+	if err := runSecret(args, os.Stdin, os.Stdout, secrets.NewStore()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runSecret executes a secret subcommand against store. Values given to
+// "set" without an argument are read from stdin, which keeps them out of
+// shell history.
+func runSecret(args []string, stdin io.Reader, stdout io.Writer, store *secrets.Store) error {
+	sub, rest := args[0], args[1:]
+	switch sub {
+	case "set":
+		if len(rest) < 1 || len(rest) > 2 {
+			return fmt.Errorf("usage: slop-mcp secret set <name> [value]")
+		}
+		value := ""
+		if len(rest) == 2 {
+			value = rest[1]
+		} else {
+			line, err := bufio.NewReader(stdin).ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("reading value from stdin: %w", err)
+			}
+			value = strings.TrimRight(line, "\r\n")
+		}
+		if value == "" {
+			return fmt.Errorf("secret value is empty")
+		}
+		if err := store.Set(rest[0], value); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Stored secret %q; reference it as ${secret:%s}\n", rest[0], rest[0])
+		return nil
+
+	case "get":
+		if len(rest) != 1 {
+			return fmt.Errorf("usage: slop-mcp secret get <name>")
+		}
+		value, err := store.Get(rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, value)
+		return nil
+
+	case "list", "ls":
+		if len(rest) != 0 {
+			return fmt.Errorf("usage: slop-mcp secret list")
+		}
+		names, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(stdout, "No secrets stored")
+			return nil
+		}
+		for _, name := range names {
+			fmt.Fprintln(stdout, name)
+		}
+		return nil
+
+	case "delete", "rm":
+		if len(rest) != 1 {
+			return fmt.Errorf("usage: slop-mcp secret delete <name>")
+		}
+		found, err := store.Delete(rest[0])
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", secrets.ErrNotFound, rest[0])
+		}
+		fmt.Fprintf(stdout, "Deleted secret %q\n", rest[0])
+		return nil
+
+	default:
+		return fmt.Errorf("unknown secret subcommand %q (use set, get, list, or delete)", sub)
+	}
+}
+
+func printSecretUsage() {
+	fmt.Print(`slop-mcp secret - Manage secrets referenced from MCP config
+
+Usage:
+  slop-mcp secret <subcommand> [args]
+
+Subcommands:
+  set <name> [value]   Store a secret (reads the value from stdin if omitted)
+  get <name>           Print a secret
+  list                 List secret names
+  delete <name>        Remove a secret
+
+Secrets are stored encrypted in secrets.enc in the user config directory.
+The key is kept in secrets.key next to it, or derived from SLOP_MCP_SECRETS_KEY
+when that is set. Reference a secret from an env or headers value in KDL:
+
+  mcp "github" {
+      command "github-mcp"
+      env {
+          GITHUB_TOKEN "${secret:github_token}"
+      }
+  }
+
+Examples:
+  slop-mcp secret set github_token           # Paste the value, then Enter
+  echo "$TOKEN" | slop-mcp secret set github_token
+  slop-mcp secret list
+`)
+}
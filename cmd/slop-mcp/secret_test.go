@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
+)
+
+func TestRunSecret(t *testing.T) {
+	t.Setenv(secrets.KeyEnvVar, "")
+	dir := t.TempDir()
+	store := secrets.NewStoreWithPath(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "secrets.key"))
+
+	run := func(stdin string, args ...string) (string, error) {
+		var out bytes.Buffer
+		err := runSecret(args, strings.NewReader(stdin), &out, store)
+		return out.String(), err
+	}
+
+	if _, err := run("ghp_from_stdin\n", "set", "github_token"); err != nil {
+		t.Fatalf("set from stdin: %v", err)
+	}
+	if _, err := run("", "set", "api_key", "k-1"); err != nil {
+		t.Fatalf("set from arg: %v", err)
+	}
+	if _, err := run("", "set", "empty"); err == nil {
+		t.Fatal("expected empty value error")
+	}
+
+	out, err := run("", "get", "github_token")
+	if err != nil || out != "ghp_from_stdin\n" {
+		t.Fatalf("get = %q, %v", out, err)
+	}
+	out, err = run("", "list")
+	if err != nil || out != "api_key\ngithub_token\n" {
+		t.Fatalf("list = %q, %v", out, err)
+	}
+	if _, err := run("", "delete", "api_key"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := run("", "delete", "api_key"); err == nil {
+		t.Fatal("expected not found error on second delete")
+	}
+	if _, err := run("", "bogus"); err == nil {
+		t.Fatal("expected unknown subcommand error")
+	}
+}
+
+func TestRedactValueShowsReferences(t *testing.T) {
+	if got := redactValue("${secret:github_token}"); got != "${secret:github_token}" {
+		t.Fatalf("reference redacted: %q", got)
+	}
+	if got := redactValue("ghp_literal"); got != "***redacted***" {
+		t.Fatalf("literal not redacted: %q", got)
+	}
+	if got := redactValue("ghp_${env:X}"); got != "***redacted***" {
+		t.Fatalf("mixed value not redacted: %q", got)
+	}
+}
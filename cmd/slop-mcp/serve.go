@@ -34,7 +34,8 @@ func cmdServe(args []string) {
 	}
 
 	// Load and merge configs
-	cfg, err := config.Load(cwd)
+	profiles := selectedProfiles(opts.profiles)
+	cfg, err := config.LoadProfiles(cwd, profiles)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
@@ -56,6 +57,7 @@ func cmdServe(args []string) {
 		os.Exit(1)
 	}
 	defer srv.Close()
+	srv.EnableConfigReload(cwd, profiles)
 
 	// Set up context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -87,6 +89,7 @@ func cmdServe(args []string) {
 
 type serveOptions struct {
 	port     int
+	profiles []string
 	showHelp bool
 }
 
@@ -110,6 +113,22 @@ func parseServeArgs(args []string) (serveOptions, error) {
 				return opts, err
 			}
 			opts.port = port
+		case args[i] == "--profile":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--profile requires a value")
+			}
+			profiles, err := parseProfileValue(args[i+1])
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
+			i++
+		case strings.HasPrefix(args[i], "--profile="):
+			profiles, err := parseProfileValue(strings.TrimPrefix(args[i], "--profile="))
+			if err != nil {
+				return opts, err
+			}
+			opts.profiles = append(opts.profiles, profiles...)
 		case args[i] == "--help" || args[i] == "-h":
 			opts.showHelp = true
 		default:
@@ -134,18 +153,37 @@ Usage:
   slop-mcp serve [options]
 
 Options:
-  --port, -p PORT    Run with SSE/HTTP transport on PORT
-                     (default: stdio transport)
+  --port, -p PORT    Serve over HTTP on PORT: streamable HTTP at /mcp,
+                     SSE at / (default: stdio transport)
+  --profile NAME     Apply config profile NAME (comma-separated for several)
   --help, -h         Show this help
 
 Examples:
   slop-mcp serve                    # Run with stdio transport
-  slop-mcp serve --port 8080        # Run with HTTP/SSE on port 8080
+  slop-mcp serve --port 8080        # Run with HTTP on port 8080
+  slop-mcp serve --profile work     # Add the MCPs of profile "work"
+
+Environment:
+  SLOP_MCP_HTTP_TOKEN                Require "Authorization: Bearer <token>" in HTTP mode
+  SLOP_MCP_HTTP_TOKEN_FILE           KDL file of named tokens with per-token MCP/tool permissions
+  SLOP_MCP_HTTP_SESSION_TIMEOUT      Idle timeout for streamable HTTP sessions (default 30m, 0 = never)
+  SLOP_MCP_HTTP_ISOLATION            Isolate HTTP clients: session, token, or off (default);
+                                     token requires SLOP_MCP_HTTP_TOKEN_FILE
+  SLOP_MCP_RELOAD_INTERVAL           How often config files are checked for edits (default 2s, 0 = never)
+  SLOP_MCP_PROFILE                   Config profiles to apply when --profile is not given
 
 Configuration:
   The server loads MCP configurations from (later overrides earlier):
   1. User config: $XDG_CONFIG_HOME/slop-mcp/config.kdl or ~/.config/slop-mcp/config.kdl
   2. Project config: .slop-mcp.kdl (in current directory)
   3. Local config: .slop-mcp.local.kdl (gitignored, for secrets)
+
+  Each file may include other files and define named profile blocks; the
+  MCPs of the selected profiles are added on top of the unprofiled ones.
+
+  Edits to these files and to the CLI tool directories are applied while the
+  server runs: added MCPs connect, removed ones disconnect, and changed ones
+  reconnect. Connected clients are sent tools/list_changed when the pinned
+  tools change.
 `)
 }
@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestParseServeArgs(t *testing.T) {
 	tests := []struct {
@@ -9,12 +12,17 @@ func TestParseServeArgs(t *testing.T) {
 		wantPort int
 		wantHelp bool
 		wantErr  bool
+		wantProf []string
 	}{
 		{name: "default stdio", args: nil},
 		{name: "long port separate", args: []string{"--port", "8080"}, wantPort: 8080},
 		{name: "long port equals", args: []string{"--port=3000"}, wantPort: 3000},
 		{name: "short port", args: []string{"-p", "9000"}, wantPort: 9000},
 		{name: "help", args: []string{"--help"}, wantHelp: true},
+		{name: "profile separate", args: []string{"--profile", "work"}, wantProf: []string{"work"}},
+		{name: "profile list and repeat", args: []string{"--profile=work, ci", "--profile", "debug"}, wantProf: []string{"work", "ci", "debug"}},
+		{name: "missing profile", args: []string{"--profile"}, wantErr: true},
+		{name: "empty profile", args: []string{"--profile= ,"}, wantErr: true},
 		{name: "missing port", args: []string{"--port"}, wantErr: true},
 		{name: "empty equals port", args: []string{"--port="}, wantErr: true},
 		{name: "invalid port", args: []string{"--port", "abc"}, wantErr: true},
@@ -40,6 +48,9 @@ func TestParseServeArgs(t *testing.T) {
 			if got.showHelp != tt.wantHelp {
 				t.Fatalf("showHelp = %v, want %v", got.showHelp, tt.wantHelp)
 			}
+			if !reflect.DeepEqual(got.profiles, tt.wantProf) {
+				t.Fatalf("profiles = %v, want %v", got.profiles, tt.wantProf)
+			}
 		})
 	}
 }
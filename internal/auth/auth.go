@@ -452,6 +452,67 @@ func openBrowser(url string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
+// Authenticate runs the client_credentials grant (RFC 6749 section 4.4)
+// and saves the token to the flow's store. The token records the requested
+// scopes, so Matches notices when the configured scopes change.
+func (f *ClientCredentialsFlow) Authenticate(ctx context.Context) (*AuthResult, error) {
+	data := url.Values{"grant_type": {GrantClientCredentials}}
+	if len(f.Scopes) > 0 {
+		data.Set("scope", strings.Join(f.Scopes, " "))
+	}
+	if f.ServerURL != "" {
+		data.Set("resource", f.ServerURL)
+	}
+	if f.ClientSecret == "" {
+		// A public client identifies itself in the body instead.
+		data.Set("client_id", f.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", f.TokenEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if f.ClientSecret != "" {
+		// RFC 6749 section 2.3.1: form-encode both before the Basic scheme.
+		req.SetBasicAuth(url.QueryEscape(f.ClientID), url.QueryEscape(f.ClientSecret))
+	}
+
+	client := f.HTTPClient
+	if client == nil {
+		client = tokenHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	tokenResp, err := decodeTokenEndpointResponse(resp, "client_credentials grant")
+	if err != nil {
+		return nil, err
+	}
+
+	token := &MCPToken{
+		ServerName:    f.ServerName,
+		ServerURL:     f.ServerURL,
+		ClientID:      f.ClientID,
+		AccessToken:   tokenResp.AccessToken,
+		RefreshToken:  tokenResp.RefreshToken,
+		TokenType:     tokenResp.TokenType,
+		Scope:         strings.Join(f.Scopes, " "),
+		TokenEndpoint: f.TokenEndpoint,
+		GrantType:     GrantClientCredentials,
+	}
+	if tokenResp.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	if f.Store != nil {
+		if err := f.Store.SetToken(token); err != nil {
+			return nil, fmt.Errorf("failed to save token: %w", err)
+		}
+	}
+	return &AuthResult{Token: token}, nil
+}
+
 // RefreshToken refreshes an expired token.
 func RefreshToken(ctx context.Context, token *MCPToken, tokenEndpoint string) (*MCPToken, error) {
 	if token.RefreshToken == "" {
@@ -15,6 +15,11 @@ func (f *OAuthFlow) DiscoverAndAuth(ctx context.Context) (*AuthResult, error) {
 	return nil, fmt.Errorf("OAuth support not compiled in. Rebuild with: go build -tags mcp_go_client_oauth")
 }
 
+// Authenticate is a stub that returns an error when OAuth is not compiled in.
+func (f *ClientCredentialsFlow) Authenticate(ctx context.Context) (*AuthResult, error) {
+	return nil, fmt.Errorf("OAuth support not compiled in. Rebuild with: go build -tags mcp_go_client_oauth")
+}
+
 // RefreshToken is a stub that returns an error when OAuth is not compiled in.
 func RefreshToken(ctx context.Context, token *MCPToken, tokenEndpoint string) (*MCPToken, error) {
 	return nil, fmt.Errorf("OAuth support not compiled in. Rebuild with: go build -tags mcp_go_client_oauth")
@@ -191,6 +191,87 @@ func TestRefreshTokenRejectsMissingAccessToken(t *testing.T) {
 	assert.Contains(t, err.Error(), "missing access_token")
 }
 
+func TestClientCredentialsFlow_AuthenticateSavesToken(t *testing.T) {
+	var form map[string][]string
+	var user, pass string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		user, pass, _ = r.BasicAuth()
+		return jsonResponse(http.StatusOK, `{"access_token":"cc-token","token_type":"Bearer","expires_in":3600}`), nil
+	})}
+	store := NewTokenStoreWithPath(filepath.Join(t.TempDir(), "auth.json"))
+	flow := &ClientCredentialsFlow{
+		ServerName:    "api",
+		ServerURL:     "https://api.example/mcp",
+		TokenEndpoint: "https://auth.example/token",
+		ClientID:      "ci",
+		ClientSecret:  "s3cret",
+		Scopes:        []string{"tools:read", "tools:call"},
+		HTTPClient:    client,
+		Store:         store,
+	}
+
+	result, err := flow.Authenticate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "client_credentials", form["grant_type"][0])
+	assert.Equal(t, "tools:read tools:call", form["scope"][0])
+	assert.Equal(t, "https://api.example/mcp", form["resource"][0])
+	assert.NotContains(t, form, "client_id", "a confidential client authenticates with Basic auth")
+	assert.Equal(t, "ci", user)
+	assert.Equal(t, "s3cret", pass)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), result.Token.ExpiresAt, time.Minute)
+
+	stored, err := store.GetToken("api")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "cc-token", stored.AccessToken)
+	assert.Empty(t, stored.ClientSecret, "the secret stays in the config")
+	assert.True(t, flow.Matches(stored))
+
+	other := *flow
+	other.Scopes = []string{"tools:read"}
+	assert.False(t, other.Matches(stored), "changed scopes need a new token")
+	assert.False(t, flow.Matches(&MCPToken{ClientID: "ci", TokenEndpoint: flow.TokenEndpoint, Scope: "tools:read tools:call"}),
+		"a token from the interactive login is not reused")
+}
+
+func TestClientCredentialsFlow_FormEncodesBasicAuth(t *testing.T) {
+	var user, pass string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		user, pass, _ = r.BasicAuth()
+		return jsonResponse(http.StatusOK, `{"access_token":"cc-token","token_type":"Bearer"}`), nil
+	})}
+	flow := &ClientCredentialsFlow{
+		ServerName:    "api",
+		TokenEndpoint: "https://auth.example/token",
+		ClientID:      "ci:bot",
+		ClientSecret:  "p@ss word/+",
+		HTTPClient:    client,
+		Store:         NewTokenStoreWithPath(filepath.Join(t.TempDir(), "auth.json")),
+	}
+
+	_, err := flow.Authenticate(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ci%3Abot", user)
+	assert.Equal(t, "p%40ss+word%2F%2B", pass)
+}
+
+func TestClientCredentialsFlow_AuthenticateReportsOAuthError(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "public", r.PostForm.Get("client_id"), "a public client sends client_id in the body")
+		return jsonResponse(http.StatusUnauthorized, `{"error":"invalid_client"}`), nil
+	})}
+	flow := &ClientCredentialsFlow{ServerName: "api", TokenEndpoint: "https://auth.example/token", ClientID: "public", HTTPClient: client}
+
+	result, err := flow.Authenticate(context.Background())
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "client_credentials grant failed with status 401")
+	assert.Contains(t, err.Error(), "invalid_client")
+}
+
 func TestDecodeTokenEndpointResponseIncludesErrorBody(t *testing.T) {
 	resp := &http.Response{
 		StatusCode: http.StatusBadRequest,
@@ -5,8 +5,10 @@ package auth
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,8 +39,12 @@ type MCPToken struct {
 	ExpiresAt     time.Time `json:"expires_at,omitempty"`
 	Scope         string    `json:"scope,omitempty"`
 	TokenEndpoint string    `json:"token_endpoint,omitempty"` // For refresh token flow
+	GrantType     string    `json:"grant_type,omitempty"`     // GrantClientCredentials, or empty for the interactive login
 }
 
+// GrantClientCredentials marks tokens obtained by a ClientCredentialsFlow.
+const GrantClientCredentials = "client_credentials"
+
 // TokenFile is the structure of the auth token file.
 type TokenFile struct {
 	Version int                  `json:"version"`
@@ -196,6 +202,30 @@ type OAuthFlow struct {
 	Store      *TokenStore
 }
 
+// ClientCredentialsFlow obtains tokens for an MCP server with the OAuth
+// client_credentials grant, without a browser or any user interaction.
+type ClientCredentialsFlow struct {
+	ServerName    string
+	ServerURL     string // Sent as the RFC 8707 resource
+	TokenEndpoint string
+	ClientID      string
+	ClientSecret  string // Resolved; sent with HTTP Basic authentication
+	Scopes        []string
+	HTTPClient    *http.Client // For the token endpoint; nil uses a client with a 30s timeout
+	Store         *TokenStore  // Where the token is saved; nil skips saving
+}
+
+// Matches reports whether token was issued by a flow with the same client,
+// token endpoint, and scopes, so it can stand in for a new one until it
+// expires.
+func (f *ClientCredentialsFlow) Matches(token *MCPToken) bool {
+	return token != nil &&
+		token.GrantType == GrantClientCredentials &&
+		token.ClientID == f.ClientID &&
+		token.TokenEndpoint == f.TokenEndpoint &&
+		token.Scope == strings.Join(f.Scopes, " ")
+}
+
 // AuthResult contains the result of an OAuth flow.
 type AuthResult struct {
 	Token *MCPToken
@@ -13,6 +13,7 @@ import (
 
 	"github.com/standardbeagle/slop-mcp/internal/atomicfile"
 	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
 )
 
 // CacheSchemaVersion is the current cache file schema version.
@@ -72,8 +73,12 @@ func defaultCachePath() string {
 
 // ConfigHash computes a deterministic hash of the identity fields of an MCPConfig.
 // Only fields that affect which server we connect to are included:
-// Type, Command, Args, URL, Headers, Env.
+// Type, Command, Args, URL, Headers, Env, Socket, TLS, Proxy, OAuth.
 // Volatile fields (Name, Timeout, MaxRetries, etc.) are excluded.
+// Headers and Env are hashed as written: a ${secret:...} or ${env:...}
+// reference contributes its name, never the value it resolves to. The
+// hash is stored on disk, so an OAuth client secret contributes only when it
+// is such a reference; a literal secret is left out.
 func ConfigHash(cfg config.MCPConfig) string {
 	h := sha256.New()
 
@@ -107,6 +112,30 @@ func ConfigHash(cfg config.MCPConfig) string {
 	h.Write([]byte(sortedMapString(cfg.Env)))
 	h.Write([]byte("\n"))
 
+	// Socket, only when set so hashes of other MCPs stay as they were
+	if cfg.Socket != "" {
+		h.Write([]byte("socket:"))
+		h.Write([]byte(cfg.Socket))
+		h.Write([]byte("\n"))
+	}
+
+	// TLS and proxy, likewise only when set
+	if t := cfg.TLS; t != nil {
+		fmt.Fprintf(h, "tls:%s\x00%s\x00%s\x00%s\x00%t\n", t.CA, t.Cert, t.Key, t.ServerName, t.InsecureSkipVerify)
+	}
+	if cfg.Proxy != "" {
+		h.Write([]byte("proxy:"))
+		h.Write([]byte(cfg.Proxy))
+		h.Write([]byte("\n"))
+	}
+	if o := cfg.OAuth; o != nil {
+		secretRef := ""
+		if secrets.IsReference(o.ClientSecret) {
+			secretRef = o.ClientSecret
+		}
+		fmt.Fprintf(h, "oauth:%s\x00%s\x00%s\x00%s\n", o.ClientID, secretRef, strings.Join(o.Scopes, " "), o.TokenEndpoint)
+	}
+
 	return fmt.Sprintf("%x", h.Sum(nil))[:16]
 }
 
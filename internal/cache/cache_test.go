@@ -79,6 +79,22 @@ func TestConfigHash_IdentityFieldsChange(t *testing.T) {
 			name: "different headers",
 			cfg:  config.MCPConfig{Type: "stdio", Command: "server", Headers: map[string]string{"Auth": "Bearer x"}},
 		},
+		{
+			name: "different socket",
+			cfg:  config.MCPConfig{Type: "stdio", Command: "server", Socket: "/run/mcp.sock"},
+		},
+		{
+			name: "different tls",
+			cfg:  config.MCPConfig{Type: "stdio", Command: "server", TLS: &config.TLSConfig{CA: "ca.pem"}},
+		},
+		{
+			name: "different proxy",
+			cfg:  config.MCPConfig{Type: "stdio", Command: "server", Proxy: "http://proxy:3128"},
+		},
+		{
+			name: "different oauth",
+			cfg:  config.MCPConfig{Type: "stdio", Command: "server", OAuth: &config.OAuthConfig{ClientID: "ci", TokenEndpoint: "https://auth/token"}},
+		},
 	}
 
 	baseHash := ConfigHash(base)
@@ -90,6 +106,17 @@ func TestConfigHash_IdentityFieldsChange(t *testing.T) {
 	}
 }
 
+func TestConfigHash_OAuthSecretNotHashed(t *testing.T) {
+	oauth := func(secret string) config.MCPConfig {
+		return config.MCPConfig{Type: "http", URL: "https://api/mcp", OAuth: &config.OAuthConfig{
+			ClientID: "ci", ClientSecret: secret, TokenEndpoint: "https://auth/token",
+		}}
+	}
+	assert.Equal(t, ConfigHash(oauth("")), ConfigHash(oauth("s3cret")), "a literal secret is left out")
+	assert.Equal(t, ConfigHash(oauth("s3cret")), ConfigHash(oauth("other")))
+	assert.NotEqual(t, ConfigHash(oauth("${env:A}")), ConfigHash(oauth("${env:B}")), "a changed reference counts")
+}
+
 func TestConfigHash_MapOrderIndependent(t *testing.T) {
 	cfg1 := config.MCPConfig{
 		Type:    "stdio",
@@ -106,6 +133,22 @@ func TestConfigHash_MapOrderIndependent(t *testing.T) {
 		"map order should not affect hash")
 }
 
+func TestConfigHash_HashesReferenceNotValue(t *testing.T) {
+	cfg := config.MCPConfig{
+		Type:    "http",
+		URL:     "https://api.example.com/mcp",
+		Headers: map[string]string{"Authorization": "Bearer ${env:API_TOKEN}"},
+	}
+
+	t.Setenv("API_TOKEN", "first")
+	before := ConfigHash(cfg)
+	t.Setenv("API_TOKEN", "rotated")
+	assert.Equal(t, before, ConfigHash(cfg), "rotating the secret keeps the cache entry")
+
+	cfg.Headers = map[string]string{"Authorization": "Bearer ${env:OTHER_TOKEN}"}
+	assert.NotEqual(t, before, ConfigHash(cfg), "pointing at another secret changes the hash")
+}
+
 func TestStore_RoundTrip(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "cache", "tools.json")
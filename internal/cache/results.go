@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/atomicfile"
+	"github.com/standardbeagle/slop-mcp/internal/filelock"
+)
+
+// ResultSchemaVersion is the current result cache file schema version.
+const ResultSchemaVersion = 1
+
+// CachedResult is a memoized tool result persisted across restarts.
+type CachedResult struct {
+	MCPName    string          `json:"mcp_name"`
+	ToolName   string          `json:"tool_name"`
+	ArgsHash   string          `json:"args_hash"`   // canonical hash of the call arguments
+	ConfigHash string          `json:"config_hash"` // ConfigHash of the MCP when the result was stored
+	Result     json.RawMessage `json:"result"`      // the CallToolResult as JSON
+	ExpiresAt  time.Time       `json:"expires_at"`
+}
+
+// ResultFile is the on-disk structure for the result cache.
+type ResultFile struct {
+	Version int                      `json:"version"`
+	Entries map[string]*CachedResult `json:"entries"` // Keyed by MCP, tool, and args hash
+}
+
+// ResultStore provides thread-safe access to the disk-based result cache.
+type ResultStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Results returns the result cache stored next to s (results.json in the
+// same directory).
+func (s *Store) Results() *ResultStore {
+	if s.path == "" {
+		return &ResultStore{}
+	}
+	return &ResultStore{path: filepath.Join(filepath.Dir(s.path), "results.json")}
+}
+
+// Load reads the persisted results, leaving out expired ones. Returns an
+// empty map if the file doesn't exist or is unreadable.
+func (s *ResultStore) Load() (map[string]*CachedResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return make(map[string]*CachedResult), nil
+	}
+	entries, _, err := s.readLocked()
+	return entries, err
+}
+
+// Update applies fn to the persisted results and writes them back. The file
+// is locked across the read-modify-write, so results saved meanwhile by
+// another slop-mcp process are kept rather than overwritten. A store with no
+// path (no home directory) keeps nothing.
+func (s *ResultStore) Update(fn func(entries map[string]*CachedResult)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	unlock, err := filelock.Lock(s.path)
+	if err != nil {
+		return fmt.Errorf("lock result cache: %w", err)
+	}
+	defer func() { _ = unlock() }()
+
+	entries, exists, err := s.readLocked()
+	if err != nil {
+		return err
+	}
+	fn(entries)
+	if !exists && len(entries) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(ResultFile{Version: ResultSchemaVersion, Entries: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal result cache: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("write result cache: %w", err)
+	}
+	return nil
+}
+
+// readLocked reads the unexpired results on disk and reports whether the
+// file exists. A corrupt or outdated file reads as empty. The caller must
+// hold s.mu.
+func (s *ResultStore) readLocked() (map[string]*CachedResult, bool, error) {
+	entries := make(map[string]*CachedResult)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return entries, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read result cache: %w", err)
+	}
+
+	var rf ResultFile
+	if err := json.Unmarshal(data, &rf); err != nil || rf.Version != ResultSchemaVersion {
+		// Corrupt or outdated cache: start empty
+		return entries, true, nil
+	}
+	now := time.Now()
+	for key, e := range rf.Entries {
+		if e != nil && now.Before(e.ExpiresAt) {
+			entries[key] = e
+		}
+	}
+	return entries, true, nil
+}
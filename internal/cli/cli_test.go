@@ -166,6 +166,32 @@ func TestRegistry_ListAndGetToolInfosAreSorted(t *testing.T) {
 	}
 }
 
+func TestRegistry_Replace(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(&ToolConfig{Name: "old"})
+	reg.Register(&ToolConfig{Name: "kept", Description: "before"})
+
+	fresh := NewRegistry()
+	fresh.Register(&ToolConfig{Name: "kept", Description: "after"})
+	fresh.Register(&ToolConfig{Name: "new"})
+	reg.Replace(fresh)
+
+	if reg.Get("old") != nil {
+		t.Fatal("old tool should be gone after Replace")
+	}
+	if got := reg.Get("kept"); got == nil || got.Description != "after" {
+		t.Fatalf("kept = %+v, want the replacement definition", got)
+	}
+	if reg.Count() != 2 {
+		t.Fatalf("Count = %d, want 2", reg.Count())
+	}
+
+	fresh.Unregister("new")
+	if reg.Get("new") == nil {
+		t.Fatal("Replace must copy, not share, the source tool map")
+	}
+}
+
 func TestExecutor_Execute(t *testing.T) {
 	tool := &ToolConfig{
 		Name:        "echo",
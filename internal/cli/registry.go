@@ -116,6 +116,21 @@ func (r *Registry) Unregister(name string) {
 	delete(r.tools, name)
 }
 
+// Replace swaps the whole tool set for the tools of src in one step, so a
+// reload never exposes a half-loaded registry.
+func (r *Registry) Replace(src *Registry) {
+	src.mu.RLock()
+	tools := make(map[string]*ToolConfig, len(src.tools))
+	for name, tool := range src.tools {
+		tools[name] = tool
+	}
+	src.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools = tools
+}
+
 // Get retrieves a CLI tool by name.
 func (r *Registry) Get(name string) *ToolConfig {
 	r.mu.RLock()
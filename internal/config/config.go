@@ -1,28 +1,280 @@
 package config
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
 
 // Config represents the merged configuration from user and project sources.
 type Config struct {
 	MCPs map[string]MCPConfig
+	// Profiles lists the profiles defined by the loaded files, sorted.
+	Profiles []string
+	// Files lists every config file that was read, including included ones.
+	Files []string
+	// Pins lists the upstream tools exposed as top-level tools, in file order.
+	Pins []PinConfig
+	// layout keeps the include and profile blocks of a single parsed file.
+	layout *fileLayout
 }
 
 // MCPConfig represents a single MCP server configuration.
 type MCPConfig struct {
 	Name                string            `json:"name,omitempty"`
-	Type                string            `json:"type"` // "stdio", "sse", "http", "streamable"
+	Type                string            `json:"type"` // "stdio", "sse", "http", "streamable", "websocket", "unix"
 	Command             string            `json:"command,omitempty"`
 	Args                []string          `json:"args,omitempty"`
 	Env                 map[string]string `json:"env,omitempty"`
 	URL                 string            `json:"url,omitempty"`
 	Headers             map[string]string `json:"headers,omitempty"`
+	Socket              string            `json:"socket,omitempty"`                // Unix socket path for a "unix" MCP; with URL set, streamable HTTP is spoken over it
+	TLS                 *TLSConfig        `json:"tls,omitempty"`                   // Client certificate, CA bundle, and server name for HTTP(S) and WebSocket MCPs
+	Proxy               string            `json:"proxy,omitempty"`                 // Proxy URL (http, https, or socks5) for HTTP transports; ProxyNone ignores HTTP(S)_PROXY
+	OAuth               *OAuthConfig      `json:"oauth,omitempty"`                 // OAuth client_credentials grant, used instead of the interactive login
 	Timeout             string            `json:"timeout,omitempty"`               // Connection timeout (e.g., "30s", "1m")
 	MaxRetries          int               `json:"max_retries,omitempty"`           // Max auto-reconnect retries (0 = use default 5, negative = disabled)
 	HealthCheckInterval string            `json:"health_check_interval,omitempty"` // Background health check interval (e.g., "30s", "1m"); 0 = disabled
 	Dynamic             bool              `json:"dynamic,omitempty"`               // If true, always re-fetch tool list (never use cache)
+	Sampling            string            `json:"sampling,omitempty"`              // "allow" (default) or "deny": relay sampling/createMessage to the downstream client
+	ElicitationTimeout  string            `json:"elicitation_timeout,omitempty"`   // How long a relayed elicitation waits for the user (e.g., "5m")
+	FailureThreshold    int               `json:"failure_threshold,omitempty"`     // Consecutive upstream failures that open the circuit (0 = default 5, negative = disabled)
+	CircuitCooldown     string            `json:"circuit_cooldown,omitempty"`      // How long an open circuit fails calls fast (e.g., "1m"; default 30s)
+	MaxConcurrent       int               `json:"max_concurrent,omitempty"`        // Cap on calls in flight to this MCP; extra calls queue (0 = unlimited)
+	QueueTimeout        string            `json:"queue_timeout,omitempty"`         // How long a queued call waits for a slot (e.g., "10s"; default 30s, "0" = don't queue)
+	IdleTimeout         string            `json:"idle_timeout,omitempty"`          // Shut down an unused stdio MCP after this long (e.g., "10m"); "0" = never
+	StderrLines         int               `json:"stderr_lines,omitempty"`          // Stderr lines kept in memory for a stdio MCP (0 = default 200)
+	StderrLog           string            `json:"stderr_log,omitempty"`            // File a stdio MCP's stderr is also appended to
+	Allow               []string          `json:"allow,omitempty"`                 // Tool name globs to expose; empty exposes every tool
+	Deny                []string          `json:"deny,omitempty"`                  // Tool name globs to hide and block; wins over Allow
+	ToolTimeout         string            `json:"tool_timeout,omitempty"`          // Per-call timeout for this MCP's tools (e.g., "2m"); replaces SLOP_MCP_EXECUTE_TIMEOUT
+	Tools               []ToolConfig      `json:"tools,omitempty"`                 // Per-tool settings by name glob; the first match applies
+	Retry               *RetryConfig      `json:"retry,omitempty"`                 // Retry policy for tools marked idempotent; nil = never retry
+	File                string            `json:"file,omitempty"`                  // Config file the MCP block was read from
+	Profile             string            `json:"profile,omitempty"`               // Profile block that defined the MCP; empty for top-level blocks
 	Source              Source            `json:"-"`
 }
 
+// Sampling policies for MCPConfig.Sampling. An empty value means SamplingAllow.
+const (
+	SamplingAllow = "allow"
+	SamplingDeny  = "deny"
+)
+
+// ToolAllowed reports whether the allow/deny policy of the MCP exposes
+// toolName. Patterns use path.Match syntax; deny wins over allow.
+func (c MCPConfig) ToolAllowed(toolName string) bool {
+	if len(c.Allow) > 0 && !matchAny(c.Allow, toolName) {
+		return false
+	}
+	return !matchAny(c.Deny, toolName)
+}
+
+// TLSConfig tunes the TLS client of an MCP reached over HTTPS or WSS. File
+// paths are resolved with MCPConfig.ResolvePath.
+type TLSConfig struct {
+	CA                 string `json:"ca,omitempty"`                   // PEM bundle of CAs trusted in addition to the system roots
+	Cert               string `json:"cert,omitempty"`                 // PEM client certificate for mutual TLS; needs Key
+	Key                string `json:"key,omitempty"`                  // PEM private key of Cert
+	ServerName         string `json:"server_name,omitempty"`          // Name verified in the server certificate and sent as SNI
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // Accept any server certificate; for testing only
+}
+
+// validate checks that a client certificate comes with its key.
+func (t *TLSConfig) validate() error {
+	if (t.Cert == "") != (t.Key == "") {
+		return fmt.Errorf("tls cert and key must be set together")
+	}
+	return nil
+}
+
+// OAuthConfig has an MCP get its tokens with the OAuth client_credentials
+// grant, without user interaction.
+type OAuthConfig struct {
+	ClientID      string   `json:"client_id"`               // Client registered with the authorization server
+	ClientSecret  string   `json:"client_secret,omitempty"` // May be a ${env:...}, ${file:...}, or ${secret:...} reference
+	Scopes        []string `json:"scopes,omitempty"`        // Scopes to request; none lets the server pick its default
+	TokenEndpoint string   `json:"token_endpoint"`          // Authorization server's token endpoint URL
+}
+
+// validate checks that the grant has a client and an http(s) token endpoint.
+func (o *OAuthConfig) validate() error {
+	if o.ClientID == "" {
+		return fmt.Errorf("oauth client_id is required")
+	}
+	if o.TokenEndpoint == "" {
+		return fmt.Errorf("oauth token_endpoint is required")
+	}
+	u, err := url.Parse(o.TokenEndpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("oauth token_endpoint %q must be an http or https URL", o.TokenEndpoint)
+	}
+	return nil
+}
+
+// ProxyNone as MCPConfig.Proxy connects directly, ignoring HTTP_PROXY,
+// HTTPS_PROXY, and NO_PROXY.
+const ProxyNone = "none"
+
+// validateProxy checks an MCPConfig.Proxy value.
+func validateProxy(p string) error {
+	if p == "" || p == ProxyNone {
+		return nil
+	}
+	u, err := url.Parse(p)
+	if err != nil {
+		return fmt.Errorf("proxy %q: %w", p, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("proxy %q: scheme must be http, https, or socks5 (or use %q)", p, ProxyNone)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("proxy %q: missing host", p)
+	}
+	return nil
+}
+
+// ResolvePath makes a file path from the MCP's config absolute: ~ is the
+// home directory and a relative path is relative to the config file that
+// defined the MCP (or the working directory, for an MCP without one).
+func (c MCPConfig) ResolvePath(p string) (string, error) {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expanding %s: %w", p, err)
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	if !filepath.IsAbs(p) && c.File != "" {
+		p = filepath.Join(filepath.Dir(c.File), p)
+	}
+	return filepath.Clean(p), nil
+}
+
+// ToolConfig tunes calls to the tools of an MCP whose names match Pattern.
+type ToolConfig struct {
+	Pattern      string `json:"pattern"`                 // Tool name glob (path.Match syntax)
+	Timeout      string `json:"timeout,omitempty"`       // Per-call timeout; overrides the MCP's ToolTimeout
+	Idempotent   bool   `json:"idempotent,omitempty"`    // Safe to call again, so the MCP's Retry policy applies
+	CacheTTL     string `json:"cache_ttl,omitempty"`     // Memoize successful results for this long (e.g., "5m")
+	CachePersist bool   `json:"cache_persist,omitempty"` // Keep memoized results on disk across restarts
+}
+
+// RetryConfig is an MCP's opt-in retry policy. It only ever applies to tools
+// a ToolConfig marks idempotent.
+type RetryConfig struct {
+	MaxAttempts int      `json:"max_attempts,omitempty"` // Total attempts, the first included (default 3)
+	Backoff     string   `json:"backoff,omitempty"`      // Delay before the first retry, doubled after each (default "500ms")
+	MaxBackoff  string   `json:"max_backoff,omitempty"`  // Cap on a single delay, Retry-After included (default "10s")
+	On          []string `json:"on,omitempty"`           // Error classes to retry: RetryOnConnection, RetryOnRateLimit (default both)
+}
+
+// Retry-on classes for RetryConfig.On.
+const (
+	RetryOnConnection = "connection" // transport failures: dropped socket, crashed subprocess, refused connect
+	RetryOnRateLimit  = "rate_limit" // HTTP 429 Too Many Requests, honoring Retry-After
+)
+
+// ToolSettings returns the first ToolConfig whose pattern matches toolName.
+func (c MCPConfig) ToolSettings(toolName string) (ToolConfig, bool) {
+	for _, t := range c.Tools {
+		if ok, _ := path.Match(t.Pattern, toolName); ok {
+			return t, true
+		}
+	}
+	return ToolConfig{}, false
+}
+
+// PinConfig exposes the upstream tools matching Pattern as top-level tools
+// of the slop-mcp server, next to search_tools and execute_tool.
+type PinConfig struct {
+	Pattern string `json:"pattern"`         // "mcp.tool", with path.Match globs in either part; split at the first dot
+	Alias   string `json:"alias,omitempty"` // Name to expose the tool under; only for a pattern without globs
+}
+
+// Split returns the MCP and tool parts of the pin pattern.
+func (p PinConfig) Split() (mcpPattern, toolPattern string) {
+	mcpPattern, toolPattern, _ = strings.Cut(p.Pattern, ".")
+	return mcpPattern, toolPattern
+}
+
+// Match reports whether the pin covers toolName of mcpName.
+func (p PinConfig) Match(mcpName, toolName string) bool {
+	mcpPattern, toolPattern := p.Split()
+	if ok, _ := path.Match(mcpPattern, mcpName); !ok {
+		return false
+	}
+	ok, _ := path.Match(toolPattern, toolName)
+	return ok
+}
+
+// validate checks the pattern and alias of a pin.
+func (p PinConfig) validate() error {
+	mcpPattern, toolPattern, ok := strings.Cut(p.Pattern, ".")
+	if !ok || mcpPattern == "" || toolPattern == "" {
+		return fmt.Errorf("pin %q: pattern must be \"mcp.tool\"", p.Pattern)
+	}
+	for _, part := range []string{mcpPattern, toolPattern} {
+		if _, err := path.Match(part, ""); err != nil {
+			return fmt.Errorf("pin %q: invalid pattern: %w", p.Pattern, err)
+		}
+	}
+	if p.Alias == "" {
+		return nil
+	}
+	if strings.ContainsAny(p.Pattern, "*?[\\") {
+		return fmt.Errorf("pin %q: alias needs a pattern that names a single tool", p.Pattern)
+	}
+	if !validToolName(p.Alias) {
+		return fmt.Errorf("pin %q: alias %q may only use letters, digits, '_', '-', and '.'", p.Pattern, p.Alias)
+	}
+	return nil
+}
+
+// validToolName reports whether name is a non-empty tool name of letters,
+// digits, '_', '-', and '.', the characters MCP clients accept.
+func validToolName(name string) bool {
+	if name == "" || len(name) > 128 {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// mergePins returns a followed by b, with a pin of b replacing the pin of a
+// with the same pattern in place.
+func mergePins(a, b []PinConfig) []PinConfig {
+	if len(b) == 0 {
+		return a
+	}
+	out := append([]PinConfig{}, a...)
+	for _, pin := range b {
+		replaced := false
+		for i := range out {
+			if out[i].Pattern == pin.Pattern {
+				out[i], replaced = pin, true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, pin)
+		}
+	}
+	return out
+}
+
 // Scope indicates where the config should be stored.
 type Scope int
 
@@ -117,7 +369,7 @@ func ParseJSONConfig(data string) (*MCPConfig, error) {
 		return nil, err
 	}
 
-	mcpType := inferMCPType(cfg.Type, cfg.Command, cfg.URL)
+	mcpType := inferMCPType(cfg.Type, cfg.Command, cfg.URL, "")
 	return &MCPConfig{
 		Type:                mcpType,
 		Command:             cfg.Command,
@@ -132,13 +384,19 @@ func ParseJSONConfig(data string) (*MCPConfig, error) {
 	}, nil
 }
 
-func inferMCPType(mcpType, command, url string) string {
+func inferMCPType(mcpType, command, url, socket string) string {
 	if mcpType != "" {
 		return mcpType
 	}
 	if command != "" {
 		return "stdio"
 	}
+	if socket != "" {
+		return "unix"
+	}
+	if strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://") {
+		return "websocket"
+	}
 	if url != "" {
 		return "http"
 	}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProfileEnvVar names the environment variable that selects config profiles
+// (comma-separated) when no --profile flag is given.
+const ProfileEnvVar = "SLOP_MCP_PROFILE"
+
+// ParseProfiles splits a comma-separated profile list, dropping blanks.
+func ParseProfiles(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ProfilesFromEnv returns the profiles selected by SLOP_MCP_PROFILE.
+func ProfilesFromEnv() []string {
+	return ParseProfiles(os.Getenv(ProfileEnvVar))
+}
+
+// fileLayout is the include and profile structure of one config file.
+type fileLayout struct {
+	includes []string
+	profiles []profileBlock
+}
+
+// profileBlock is one profile node of a config file.
+type profileBlock struct {
+	name     string
+	includes []string
+	pins     []PinConfig
+	mcps     []MCPConfig // in file order
+}
+
+// fileLoader reads a config file and, recursively, the files it includes,
+// applying the profile blocks that are selected.
+type fileLoader struct {
+	source   Source
+	selected map[string]bool
+	stack    []string        // include chain being read, for cycle detection
+	files    map[string]bool // every file read
+	defined  map[string]bool // every profile seen
+}
+
+func newFileLoader(source Source, profiles []string) *fileLoader {
+	l := &fileLoader{
+		source:   source,
+		selected: make(map[string]bool),
+		files:    make(map[string]bool),
+		defined:  make(map[string]bool),
+	}
+	for _, p := range profiles {
+		l.selected[p] = true
+	}
+	return l
+}
+
+// load reads path with the MCPs it defines attributed to profile (empty at
+// the top level). Included files come first, so the including file's own
+// blocks override them, and selected profile blocks come last, so they
+// override both. A missing file is an empty config when optional is set.
+func (l *fileLoader) load(path, profile string, optional bool) (*Config, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range l.stack {
+		if p == abs {
+			chain := append(append([]string{}, l.stack[i:]...), abs)
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	data, err := os.ReadFile(abs)
+	if os.IsNotExist(err) && optional {
+		return NewConfig(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.files[abs] = true
+	parsed, err := ParseKDLConfig(string(data), l.source)
+	if err != nil {
+		return nil, err
+	}
+	layout := parsed.layout
+	if layout == nil {
+		layout = &fileLayout{}
+	}
+
+	l.stack = append(l.stack, abs)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	out := NewConfig()
+	if err := l.includeAll(out, abs, layout.includes, profile); err != nil {
+		return nil, err
+	}
+	for name, m := range parsed.MCPs {
+		m.File, m.Profile = abs, profile
+		out.MCPs[name] = m
+	}
+	out.Pins = mergePins(out.Pins, parsed.Pins)
+	for _, block := range layout.profiles {
+		l.defined[block.name] = true
+		if profile != "" {
+			return nil, fmt.Errorf("%s: profile %q is included from profile %q; profiles cannot be nested", abs, block.name, profile)
+		}
+		if !l.selected[block.name] {
+			continue
+		}
+		if err := l.includeAll(out, abs, block.includes, block.name); err != nil {
+			return nil, err
+		}
+		for _, m := range block.mcps {
+			m.File, m.Profile = abs, block.name
+			out.MCPs[m.Name] = m
+		}
+		out.Pins = mergePins(out.Pins, block.pins)
+	}
+	return out, nil
+}
+
+// includeAll loads each include of from into out, in order.
+func (l *fileLoader) includeAll(out *Config, from string, includes []string, profile string) error {
+	for _, inc := range includes {
+		path, err := resolveIncludePath(filepath.Dir(from), inc)
+		if err != nil {
+			return err
+		}
+		sub, err := l.load(path, profile, false)
+		if err != nil {
+			return fmt.Errorf("include %q in %s: %w", inc, from, err)
+		}
+		for name, m := range sub.MCPs {
+			out.MCPs[name] = m
+		}
+		out.Pins = mergePins(out.Pins, sub.Pins)
+	}
+	return nil
+}
+
+// resolveIncludePath makes an include path absolute: ~ is the home
+// directory and relative paths are relative to the including file.
+func resolveIncludePath(dir, p string) (string, error) {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expanding %s: %w", p, err)
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Clean(p), nil
+}
+
+// loadConfigFile loads the config file at path with its includes and the
+// selected profiles applied. A missing file is an empty config.
+func loadConfigFile(path string, source Source, profiles []string) (*Config, error) {
+	l := newFileLoader(source, profiles)
+	cfg, err := l.load(path, "", true)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Files = sortedSet(l.files)
+	cfg.Profiles = sortedSet(l.defined)
+	return cfg, nil
+}
+
+// loadFileForEdit parses the config file at path without following includes
+// or applying profiles, for a rewrite by WriteConfigFile. A missing file is
+// an empty config.
+func loadFileForEdit(path string, source Source) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewConfig(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseKDLConfig(string(data), source)
+}
+
+// checkProfiles returns an error naming the first of profiles that no
+// loaded file defines.
+func checkProfiles(cfg *Config, profiles []string) error {
+	for _, p := range profiles {
+		if !containsString(cfg.Profiles, p) {
+			if len(cfg.Profiles) == 0 {
+				return fmt.Errorf("profile %q is not defined in any config file", p)
+			}
+			return fmt.Errorf("profile %q is not defined in any config file (defined: %s)", p, strings.Join(cfg.Profiles, ", "))
+		}
+	}
+	return nil
+}
+
+func sortedSet(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// mergeStrings returns the sorted union of a and b.
+func mergeStrings(a, b []string) []string {
+	set := make(map[string]bool, len(a)+len(b))
+	for _, s := range a {
+		set[s] = true
+	}
+	for _, s := range b {
+		set[s] = true
+	}
+	return sortedSet(set)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
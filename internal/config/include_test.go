@@ -0,0 +1,255 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKDL(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestParseProfiles(t *testing.T) {
+	assert.Nil(t, ParseProfiles(""))
+	assert.Nil(t, ParseProfiles(" , "))
+	assert.Equal(t, []string{"work", "ci"}, ParseProfiles("work, ci,"))
+}
+
+func TestLoadConfigFile_IncludesRelativeToIncludingFile(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root.kdl")
+	shared := filepath.Join(dir, "shared", "mcps.kdl")
+	writeKDL(t, shared, `
+include "more.kdl"
+mcp "shared" {
+    command "shared-server"
+}
+mcp "override" {
+    command "from-include"
+}
+`)
+	writeKDL(t, filepath.Join(dir, "shared", "more.kdl"), `
+mcp "more" {
+    command "more-server"
+}
+`)
+	writeKDL(t, root, `
+include "shared/mcps.kdl"
+mcp "override" {
+    command "from-root"
+}
+`)
+
+	cfg, err := loadConfigFile(root, SourceProject, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "shared-server", cfg.MCPs["shared"].Command)
+	assert.Equal(t, "more-server", cfg.MCPs["more"].Command)
+	assert.Equal(t, "from-root", cfg.MCPs["override"].Command, "the including file wins over its includes")
+	assert.Equal(t, shared, cfg.MCPs["shared"].File)
+	assert.Equal(t, root, cfg.MCPs["override"].File)
+	assert.Equal(t, SourceProject, cfg.MCPs["more"].Source)
+	assert.Len(t, cfg.Files, 3)
+}
+
+func TestLoadConfigFile_Profiles(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root.kdl")
+	writeKDL(t, filepath.Join(dir, "ci.kdl"), `
+mcp "ci-only" {
+    command "ci-server"
+}
+`)
+	writeKDL(t, root, `
+mcp "base" {
+    command "base-server"
+}
+profile "work" {
+    mcp "base" {
+        command "work-base"
+    }
+    mcp "jira" {
+        command "jira-server"
+    }
+}
+profile "ci" {
+    include "ci.kdl"
+}
+`)
+
+	cfg, err := loadConfigFile(root, SourceProject, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "base-server", cfg.MCPs["base"].Command)
+	assert.NotContains(t, cfg.MCPs, "jira", "unselected profiles are ignored")
+	assert.Equal(t, []string{"ci", "work"}, cfg.Profiles)
+
+	cfg, err = loadConfigFile(root, SourceProject, []string{"work", "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, "work-base", cfg.MCPs["base"].Command, "profile blocks override unprofiled MCPs")
+	assert.Equal(t, "work", cfg.MCPs["base"].Profile)
+	assert.Equal(t, "work", cfg.MCPs["jira"].Profile)
+	assert.Equal(t, "ci", cfg.MCPs["ci-only"].Profile, "files included from a profile belong to it")
+	assert.Equal(t, filepath.Join(dir, "ci.kdl"), cfg.MCPs["ci-only"].File)
+}
+
+func TestLoadConfigFile_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeKDL(t, filepath.Join(dir, "a.kdl"), `include "b.kdl"`)
+	writeKDL(t, filepath.Join(dir, "b.kdl"), `include "a.kdl"`)
+
+	_, err := loadConfigFile(filepath.Join(dir, "a.kdl"), SourceProject, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle")
+}
+
+func TestLoadConfigFile_MissingIncludeIsAnError(t *testing.T) {
+	dir := t.TempDir()
+	writeKDL(t, filepath.Join(dir, "root.kdl"), `include "gone.kdl"`)
+
+	_, err := loadConfigFile(filepath.Join(dir, "root.kdl"), SourceProject, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gone.kdl")
+}
+
+func TestLoadConfigFile_NestedProfileIsAnError(t *testing.T) {
+	dir := t.TempDir()
+	writeKDL(t, filepath.Join(dir, "inner.kdl"), `
+profile "inner" {
+    mcp "x" {
+        command "x"
+    }
+}
+`)
+	writeKDL(t, filepath.Join(dir, "root.kdl"), `
+profile "outer" {
+    include "inner.kdl"
+}
+`)
+
+	_, err := loadConfigFile(filepath.Join(dir, "root.kdl"), SourceProject, []string{"outer"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be nested")
+}
+
+func TestParseKDLConfig_ProfileErrors(t *testing.T) {
+	_, err := ParseKDLConfig(`profile "a" {}
+profile "a" {}`, SourceProject)
+	assert.Error(t, err, "duplicate profile")
+
+	_, err = ParseKDLConfig(`profile "a" {
+    mcp "x" {
+        sampling "maybe"
+    }
+}`, SourceProject)
+	require.Error(t, err, "MCPs inside a profile are validated")
+	assert.Contains(t, err.Error(), `profile "a"`)
+}
+
+func TestLoadProfiles_UndefinedProfile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeKDL(t, ProjectConfigPath(dir), `
+profile "work" {
+    mcp "jira" {
+        command "jira-server"
+    }
+}
+`)
+
+	cfg, err := LoadProfiles(dir, []string{"work"})
+	require.NoError(t, err)
+	assert.Equal(t, "work", cfg.MCPs["jira"].Profile)
+
+	_, err = LoadProfiles(dir, []string{"play"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `profile "play" is not defined`)
+	assert.Contains(t, err.Error(), "work")
+}
+
+func TestLoad_UsesProfileEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(ProfileEnvVar, "work")
+	dir := t.TempDir()
+	writeKDL(t, ProjectConfigPath(dir), `
+profile "work" {
+    mcp "jira" {
+        command "jira-server"
+    }
+}
+`)
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.Contains(t, cfg.MCPs, "jira")
+}
+
+func TestAddAndRemoveMCPPreserveIncludesAndProfiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.kdl")
+	writeKDL(t, filepath.Join(dir, "shared.kdl"), `
+mcp "shared" {
+    command "shared-server"
+}
+`)
+	writeKDL(t, path, `
+include "shared.kdl"
+profile "work" {
+    mcp "jira" {
+        command "jira-server"
+    }
+    mcp "gone" {
+        command "gone-server"
+    }
+}
+`)
+
+	require.NoError(t, AddMCPConfigToFile(path, MCPConfig{Name: "fresh", Command: "fresh-server"}))
+	require.NoError(t, RemoveMCPFromFile(path, "gone"))
+
+	cfg, err := loadConfigFile(path, SourceProject, []string{"work"})
+	require.NoError(t, err)
+	assert.Contains(t, cfg.MCPs, "shared", "the include survives the rewrite")
+	assert.Contains(t, cfg.MCPs, "fresh")
+	assert.Equal(t, "work", cfg.MCPs["jira"].Profile, "the profile block survives the rewrite")
+	assert.NotContains(t, cfg.MCPs, "gone", "removal reaches into profile blocks")
+}
+
+func TestLoadConfigFile_PinsFromIncludesAndProfiles(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root.kdl")
+	writeKDL(t, filepath.Join(dir, "shared.kdl"), `
+pin "fs.read_file"
+pin "fs.write_file"
+`)
+	writeKDL(t, root, `
+include "shared.kdl"
+pin "fs.read_file" {
+    alias "read"
+}
+profile "work" {
+    pin "jira.*"
+}
+`)
+
+	cfg, err := loadConfigFile(root, SourceProject, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []PinConfig{
+		{Pattern: "fs.read_file", Alias: "read"},
+		{Pattern: "fs.write_file"},
+	}, cfg.Pins, "the including file's pin replaces the included one in place")
+
+	cfg, err = loadConfigFile(root, SourceProject, []string{"work"})
+	require.NoError(t, err)
+	assert.Len(t, cfg.Pins, 3)
+	assert.Equal(t, "jira.*", cfg.Pins[2].Pattern)
+
+	require.NoError(t, AddMCPConfigToFile(root, MCPConfig{Name: "fresh", Command: "fresh-server"}))
+	cfg, err = loadConfigFile(root, SourceProject, []string{"work"})
+	require.NoError(t, err)
+	assert.Len(t, cfg.Pins, 3, "pins survive a rewrite")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -23,7 +24,32 @@ const (
 
 // KDLConfig is the raw KDL structure for unmarshaling.
 type KDLConfig struct {
-	MCPs []KDLMCPConfig `kdl:"mcp,multiple"`
+	MCPs     []KDLMCPConfig `kdl:"mcp,multiple"`
+	Includes []KDLInclude   `kdl:"include,multiple"`
+	Profiles []KDLProfile   `kdl:"profile,multiple"`
+	Pins     []KDLPin       `kdl:"pin,multiple"`
+}
+
+// KDLInclude represents an include node naming another config file,
+// relative to the directory of the file that includes it.
+type KDLInclude struct {
+	Path string `kdl:",arg"`
+}
+
+// KDLProfile represents a profile node: MCPs and includes that apply only
+// when the profile is selected.
+type KDLProfile struct {
+	Name     string         `kdl:",arg"`
+	MCPs     []KDLMCPConfig `kdl:"mcp,multiple"`
+	Includes []KDLInclude   `kdl:"include,multiple"`
+	Pins     []KDLPin       `kdl:"pin,multiple"`
+}
+
+// KDLPin represents a pin node: "mcp.tool" tools to expose as top-level
+// tools, optionally under an alias.
+type KDLPin struct {
+	Pattern string `kdl:",arg"`
+	Alias   string `kdl:"alias"`
 }
 
 // KDLMCPConfig represents an MCP node in KDL.
@@ -35,10 +61,62 @@ type KDLMCPConfig struct {
 	Env                 map[string]string `kdl:"env"`
 	URL                 string            `kdl:"url"`
 	Headers             map[string]string `kdl:"headers"`
+	Socket              string            `kdl:"socket"`
+	TLS                 *KDLTLSConfig     `kdl:"tls"`
+	Proxy               string            `kdl:"proxy"`
+	OAuth               *KDLOAuthConfig   `kdl:"oauth"`
 	Timeout             string            `kdl:"timeout"`
 	MaxRetries          int               `kdl:"max_retries"`
 	HealthCheckInterval string            `kdl:"health_check_interval"`
 	Dynamic             bool              `kdl:"dynamic"`
+	Sampling            string            `kdl:"sampling"`
+	ElicitationTimeout  string            `kdl:"elicitation_timeout"`
+	FailureThreshold    int               `kdl:"failure_threshold"`
+	CircuitCooldown     string            `kdl:"circuit_cooldown"`
+	MaxConcurrent       int               `kdl:"max_concurrent"`
+	QueueTimeout        string            `kdl:"queue_timeout"`
+	IdleTimeout         string            `kdl:"idle_timeout"`
+	StderrLines         int               `kdl:"stderr_lines"`
+	StderrLog           string            `kdl:"stderr_log"`
+	Allow               []string          `kdl:"allow"`
+	Deny                []string          `kdl:"deny"`
+	ToolTimeout         string            `kdl:"tool_timeout"`
+	Tools               []KDLToolConfig   `kdl:"tool,multiple"`
+	Retry               *KDLRetryConfig   `kdl:"retry"`
+}
+
+// KDLToolConfig represents a tool node inside an MCP node.
+type KDLToolConfig struct {
+	Pattern      string `kdl:",arg"`
+	Timeout      string `kdl:"timeout"`
+	Idempotent   bool   `kdl:"idempotent"`
+	CacheTTL     string `kdl:"cache_ttl"`
+	CachePersist bool   `kdl:"cache_persist"`
+}
+
+// KDLTLSConfig represents the tls node inside an MCP node.
+type KDLTLSConfig struct {
+	CA                 string `kdl:"ca"`
+	Cert               string `kdl:"cert"`
+	Key                string `kdl:"key"`
+	ServerName         string `kdl:"server_name"`
+	InsecureSkipVerify bool   `kdl:"insecure_skip_verify"`
+}
+
+// KDLOAuthConfig represents the oauth node inside an MCP node.
+type KDLOAuthConfig struct {
+	ClientID      string   `kdl:"client_id"`
+	ClientSecret  string   `kdl:"client_secret"`
+	Scopes        []string `kdl:"scopes"`
+	TokenEndpoint string   `kdl:"token_endpoint"`
+}
+
+// KDLRetryConfig represents the retry node inside an MCP node.
+type KDLRetryConfig struct {
+	MaxAttempts int      `kdl:"max_attempts"`
+	Backoff     string   `kdl:"backoff"`
+	MaxBackoff  string   `kdl:"max_backoff"`
+	On          []string `kdl:"on"`
 }
 
 // UserConfigDirPath returns the path to slop-mcp's user config directory.
@@ -184,7 +262,7 @@ func loadClaudeCodeMainConfig(path string, cfg *Config) error {
 	}
 
 	for name, mcp := range settings.MCPServers {
-		mcpType := inferMCPType(mcp.Type, mcp.Command, mcp.URL)
+		mcpType := inferMCPType(mcp.Type, mcp.Command, mcp.URL, "")
 		cfg.MCPs[name] = MCPConfig{
 			Name:                name,
 			Type:                mcpType,
@@ -254,7 +332,7 @@ func loadClaudeCodePluginMCPs(pluginsPath string, cfg *Config) error {
 					continue
 				}
 
-				mcpType := inferMCPType(mcp.Type, mcp.Command, mcp.URL)
+				mcpType := inferMCPType(mcp.Type, mcp.Command, mcp.URL, "")
 				cfg.MCPs[name] = MCPConfig{
 					Name:                name,
 					Type:                mcpType,
@@ -297,7 +375,7 @@ func LoadClaudeDesktopConfig() (*Config, error) {
 
 	cfg := NewConfig()
 	for name, mcp := range jsonCfg.MCPServers {
-		mcpType := inferMCPType(mcp.Type, mcp.Command, mcp.URL)
+		mcpType := inferMCPType(mcp.Type, mcp.Command, mcp.URL, "")
 		cfg.MCPs[name] = MCPConfig{
 			Name:                name,
 			Type:                mcpType,
@@ -316,25 +394,38 @@ func LoadClaudeDesktopConfig() (*Config, error) {
 	return cfg, nil
 }
 
-// LoadUserConfig loads configuration from the user config file.
+// LoadUserConfig loads configuration from the user config file, with the
+// profiles selected by SLOP_MCP_PROFILE.
 func LoadUserConfig() (*Config, error) {
+	return loadUserConfig(ProfilesFromEnv())
+}
+
+// LoadProjectConfig loads configuration from the project config file, with
+// the profiles selected by SLOP_MCP_PROFILE.
+func LoadProjectConfig(dir string) (*Config, error) {
+	return loadProjectConfig(dir, ProfilesFromEnv())
+}
+
+// LoadLocalConfig loads configuration from the local config file, with the
+// profiles selected by SLOP_MCP_PROFILE.
+func LoadLocalConfig(dir string) (*Config, error) {
+	return loadLocalConfig(dir, ProfilesFromEnv())
+}
+
+func loadUserConfig(profiles []string) (*Config, error) {
 	path := UserConfigPath()
 	if path == "" {
 		return NewConfig(), nil
 	}
-	return loadConfigFile(path, SourceUser)
+	return loadConfigFile(path, SourceUser, profiles)
 }
 
-// LoadProjectConfig loads configuration from the project config file.
-func LoadProjectConfig(dir string) (*Config, error) {
-	path := filepath.Join(dir, ProjectConfigFile)
-	return loadConfigFile(path, SourceProject)
+func loadProjectConfig(dir string, profiles []string) (*Config, error) {
+	return loadConfigFile(filepath.Join(dir, ProjectConfigFile), SourceProject, profiles)
 }
 
-// LoadLocalConfig loads configuration from the local config file.
-func LoadLocalConfig(dir string) (*Config, error) {
-	path := filepath.Join(dir, LocalConfigFile)
-	return loadConfigFile(path, SourceLocal)
+func loadLocalConfig(dir string, profiles []string) (*Config, error) {
+	return loadConfigFile(filepath.Join(dir, LocalConfigFile), SourceLocal, profiles)
 }
 
 // GetMCP returns a specific MCP config from a file.
@@ -351,8 +442,12 @@ func GetMCP(path, name string) (*MCPConfig, error) {
 	}
 
 	// Try parsing as KDL first
-	cfg, kdlErr := ParseKDLConfig(string(data), SourceProject)
+	_, kdlErr := ParseKDLConfig(string(data), SourceProject)
 	if kdlErr == nil {
+		cfg, err := loadConfigFile(path, SourceProject, ProfilesFromEnv())
+		if err != nil {
+			return nil, err
+		}
 		if mcp, ok := cfg.MCPs[name]; ok {
 			mcp.Name = name
 			return &mcp, nil
@@ -367,7 +462,7 @@ func GetMCP(path, name string) (*MCPConfig, error) {
 	}
 
 	if jmcp, ok := jsonCfg.MCPServers[name]; ok {
-		mcpType := inferMCPType(jmcp.Type, jmcp.Command, jmcp.URL)
+		mcpType := inferMCPType(jmcp.Type, jmcp.Command, jmcp.URL, "")
 		return &MCPConfig{
 			Name:                name,
 			Type:                mcpType,
@@ -398,35 +493,161 @@ func ConfigPaths(projectDir string) map[string]string {
 	}
 }
 
-func loadConfigFile(path string, source Source) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
-		return NewConfig(), nil
+// ParseKDLConfig parses one KDL config document. Only its top-level mcp
+// blocks become MCPs: include nodes are not followed and profile blocks are
+// not applied (loading a file by path does both). The include and profile
+// blocks are kept so WriteConfigFile can write them back unchanged.
+func ParseKDLConfig(data string, source Source) (*Config, error) {
+	var kdlCfg KDLConfig
+	if err := kdl.Unmarshal([]byte(data), &kdlCfg); err != nil {
+		return nil, err
 	}
+
+	mcps, err := parseKDLMCPs(kdlCfg.MCPs, source)
 	if err != nil {
 		return nil, err
 	}
-
-	return ParseKDLConfig(string(data), source)
+	cfg := NewConfig()
+	layout := &fileLayout{}
+	for _, m := range mcps {
+		cfg.MCPs[m.Name] = m
+	}
+	if cfg.Pins, err = parseKDLPins(kdlCfg.Pins); err != nil {
+		return nil, err
+	}
+	for _, inc := range kdlCfg.Includes {
+		if inc.Path == "" {
+			return nil, fmt.Errorf("include is missing a path")
+		}
+		layout.includes = append(layout.includes, inc.Path)
+	}
+	seen := make(map[string]bool)
+	for _, p := range kdlCfg.Profiles {
+		if p.Name == "" {
+			return nil, fmt.Errorf("profile block is missing a name")
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("duplicate profile block %q: each profile name must be unique within a config file", p.Name)
+		}
+		seen[p.Name] = true
+		pmcps, err := parseKDLMCPs(p.MCPs, source)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		pins, err := parseKDLPins(p.Pins)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		block := profileBlock{name: p.Name, mcps: pmcps, pins: pins}
+		for _, inc := range p.Includes {
+			if inc.Path == "" {
+				return nil, fmt.Errorf("profile %q: include is missing a path", p.Name)
+			}
+			block.includes = append(block.includes, inc.Path)
+		}
+		layout.profiles = append(layout.profiles, block)
+		cfg.Profiles = append(cfg.Profiles, p.Name)
+	}
+	sort.Strings(cfg.Profiles)
+	if len(layout.includes) > 0 || len(layout.profiles) > 0 {
+		cfg.layout = layout
+	}
+	return cfg, nil
 }
 
-// ParseKDLConfig parses KDL configuration data.
-func ParseKDLConfig(data string, source Source) (*Config, error) {
-	var kdlCfg KDLConfig
-	if err := kdl.Unmarshal([]byte(data), &kdlCfg); err != nil {
-		return nil, err
+// parseKDLPins validates and converts pin nodes, keeping their order.
+func parseKDLPins(nodes []KDLPin) ([]PinConfig, error) {
+	var out []PinConfig
+	for _, n := range nodes {
+		pin := PinConfig{Pattern: n.Pattern, Alias: n.Alias}
+		if err := pin.validate(); err != nil {
+			return nil, err
+		}
+		out = append(out, pin)
 	}
+	return out, nil
+}
 
-	cfg := NewConfig()
-	for _, m := range kdlCfg.MCPs {
+// parseKDLMCPs validates and converts mcp blocks, keeping their order. MCP
+// names must be unique within the list.
+func parseKDLMCPs(blocks []KDLMCPConfig, source Source) ([]MCPConfig, error) {
+	var out []MCPConfig
+	seen := make(map[string]bool)
+	for _, m := range blocks {
 		if m.Name == "" {
 			return nil, fmt.Errorf("mcp block is missing a name")
 		}
-		if _, dup := cfg.MCPs[m.Name]; dup {
+		if seen[m.Name] {
 			return nil, fmt.Errorf("duplicate mcp block %q: each MCP name must be unique within a config file", m.Name)
 		}
-		mcpType := inferMCPType(m.Type, m.Command, m.URL)
-		cfg.MCPs[m.Name] = MCPConfig{
+		seen[m.Name] = true
+		switch m.Sampling {
+		case "", SamplingAllow, SamplingDeny:
+		default:
+			return nil, fmt.Errorf("mcp %q: sampling must be %q or %q, got %q", m.Name, SamplingAllow, SamplingDeny, m.Sampling)
+		}
+		for _, p := range append(append([]string{}, m.Allow...), m.Deny...) {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("mcp %q: invalid tool pattern %q: %w", m.Name, p, err)
+			}
+		}
+		var tools []ToolConfig
+		for _, t := range m.Tools {
+			if _, err := path.Match(t.Pattern, ""); err != nil || t.Pattern == "" {
+				return nil, fmt.Errorf("mcp %q: invalid tool pattern %q", m.Name, t.Pattern)
+			}
+			tools = append(tools, ToolConfig{
+				Pattern:      t.Pattern,
+				Timeout:      t.Timeout,
+				Idempotent:   t.Idempotent,
+				CacheTTL:     t.CacheTTL,
+				CachePersist: t.CachePersist,
+			})
+		}
+		var retry *RetryConfig
+		if m.Retry != nil {
+			for _, class := range m.Retry.On {
+				if class != RetryOnConnection && class != RetryOnRateLimit {
+					return nil, fmt.Errorf("mcp %q: retry on must be %q or %q, got %q", m.Name, RetryOnConnection, RetryOnRateLimit, class)
+				}
+			}
+			retry = &RetryConfig{
+				MaxAttempts: m.Retry.MaxAttempts,
+				Backoff:     m.Retry.Backoff,
+				MaxBackoff:  m.Retry.MaxBackoff,
+				On:          m.Retry.On,
+			}
+		}
+		var tlsCfg *TLSConfig
+		if m.TLS != nil {
+			tlsCfg = &TLSConfig{
+				CA:                 m.TLS.CA,
+				Cert:               m.TLS.Cert,
+				Key:                m.TLS.Key,
+				ServerName:         m.TLS.ServerName,
+				InsecureSkipVerify: m.TLS.InsecureSkipVerify,
+			}
+			if err := tlsCfg.validate(); err != nil {
+				return nil, fmt.Errorf("mcp %q: %w", m.Name, err)
+			}
+		}
+		if err := validateProxy(m.Proxy); err != nil {
+			return nil, fmt.Errorf("mcp %q: %w", m.Name, err)
+		}
+		var oauth *OAuthConfig
+		if m.OAuth != nil {
+			oauth = &OAuthConfig{
+				ClientID:      m.OAuth.ClientID,
+				ClientSecret:  m.OAuth.ClientSecret,
+				Scopes:        m.OAuth.Scopes,
+				TokenEndpoint: m.OAuth.TokenEndpoint,
+			}
+			if err := oauth.validate(); err != nil {
+				return nil, fmt.Errorf("mcp %q: %w", m.Name, err)
+			}
+		}
+		mcpType := inferMCPType(m.Type, m.Command, m.URL, m.Socket)
+		out = append(out, MCPConfig{
 			Name:                m.Name,
 			Type:                mcpType,
 			Command:             m.Command,
@@ -434,15 +655,33 @@ func ParseKDLConfig(data string, source Source) (*Config, error) {
 			Env:                 m.Env,
 			URL:                 m.URL,
 			Headers:             m.Headers,
+			Socket:              m.Socket,
+			TLS:                 tlsCfg,
+			Proxy:               m.Proxy,
+			OAuth:               oauth,
 			Timeout:             m.Timeout,
 			MaxRetries:          m.MaxRetries,
 			HealthCheckInterval: m.HealthCheckInterval,
 			Dynamic:             m.Dynamic,
+			Sampling:            m.Sampling,
+			ElicitationTimeout:  m.ElicitationTimeout,
+			FailureThreshold:    m.FailureThreshold,
+			CircuitCooldown:     m.CircuitCooldown,
+			MaxConcurrent:       m.MaxConcurrent,
+			QueueTimeout:        m.QueueTimeout,
+			IdleTimeout:         m.IdleTimeout,
+			StderrLines:         m.StderrLines,
+			StderrLog:           m.StderrLog,
+			Allow:               m.Allow,
+			Deny:                m.Deny,
+			ToolTimeout:         m.ToolTimeout,
+			Tools:               tools,
+			Retry:               retry,
 			Source:              source,
-		}
+		})
 	}
 
-	return cfg, nil
+	return out, nil
 }
 
 // AddMCPToFile adds an MCP configuration to a KDL file.
@@ -458,12 +697,12 @@ func AddMCPToFile(path, name, command string, args []string) error {
 // AddMCPConfigToFile adds a full MCP configuration to a KDL file.
 func AddMCPConfigToFile(path string, mcp MCPConfig) error {
 	// Load existing config or create new
-	cfg, err := loadConfigFile(path, SourceProject)
+	cfg, err := loadFileForEdit(path, SourceProject)
 	if err != nil {
 		return err
 	}
 
-	mcp.Type = inferMCPType(mcp.Type, mcp.Command, mcp.URL)
+	mcp.Type = inferMCPType(mcp.Type, mcp.Command, mcp.URL, mcp.Socket)
 
 	// Add the new MCP
 	cfg.MCPs[mcp.Name] = mcp
@@ -475,14 +714,14 @@ func AddMCPConfigToFile(path string, mcp MCPConfig) error {
 // AddMCPConfigsToFile adds multiple MCP configurations to a KDL file with one
 // atomic write. Each MCP name is taken from the map key.
 func AddMCPConfigsToFile(path string, mcps map[string]MCPConfig) error {
-	cfg, err := loadConfigFile(path, SourceProject)
+	cfg, err := loadFileForEdit(path, SourceProject)
 	if err != nil {
 		return err
 	}
 
 	for name, mcp := range mcps {
 		mcp.Name = name
-		mcp.Type = inferMCPType(mcp.Type, mcp.Command, mcp.URL)
+		mcp.Type = inferMCPType(mcp.Type, mcp.Command, mcp.URL, mcp.Socket)
 		cfg.MCPs[name] = mcp
 	}
 
@@ -491,21 +730,33 @@ func AddMCPConfigsToFile(path string, mcps map[string]MCPConfig) error {
 
 // RemoveMCPFromFile removes an MCP configuration from a KDL file.
 func RemoveMCPFromFile(path, name string) error {
-	cfg, err := loadConfigFile(path, SourceProject)
+	cfg, err := loadFileForEdit(path, SourceProject)
 	if err != nil {
 		return err
 	}
 
-	if _, ok := cfg.MCPs[name]; !ok {
-		return fmt.Errorf("MCP %q not found in %s", name, path)
+	if _, ok := cfg.MCPs[name]; ok {
+		delete(cfg.MCPs, name)
+		return WriteConfigFile(path, cfg)
 	}
-
-	delete(cfg.MCPs, name)
-	return WriteConfigFile(path, cfg)
+	if cfg.layout != nil {
+		for i := range cfg.layout.profiles {
+			block := &cfg.layout.profiles[i]
+			for j, m := range block.mcps {
+				if m.Name == name {
+					block.mcps = append(block.mcps[:j], block.mcps[j+1:]...)
+					return WriteConfigFile(path, cfg)
+				}
+			}
+		}
+	}
+	return fmt.Errorf("MCP %q not found in %s", name, path)
 }
 
 // WriteConfigFile writes a config to a KDL file atomically, with MCP blocks
-// sorted by name for deterministic output.
+// sorted by name for deterministic output. Pins come first, in order, and the
+// include and profile blocks of a config read by ParseKDLConfig are written
+// back around the MCP blocks.
 //
 // Known limitation: the file is regenerated from the parsed config, so
 // comments and unrecognized nodes in an existing file are not preserved.
@@ -524,9 +775,22 @@ func WriteConfigFile(path string, cfg *Config) error {
 
 	// Build KDL content
 	var content string
+	if cfg.layout != nil {
+		for _, inc := range cfg.layout.includes {
+			content += "include " + kdlQuote(inc) + "\n"
+		}
+	}
+	for _, pin := range cfg.Pins {
+		content += formatPin(pin)
+	}
 	for _, name := range names {
 		content += formatMCPBlock(cfg.MCPs[name])
 	}
+	if cfg.layout != nil {
+		for _, block := range cfg.layout.profiles {
+			content += formatProfileBlock(block)
+		}
+	}
 
 	// 0600: MCP config blocks routinely embed secrets (env API keys,
 	// Authorization headers), so the file must not be world-readable.
@@ -548,6 +812,38 @@ func kdlQuote(s string) string {
 	return "\"" + kdlEscaper.Replace(s) + "\""
 }
 
+// formatPin renders a pin node, with its alias as a child node.
+func formatPin(pin PinConfig) string {
+	if pin.Alias == "" {
+		return "pin " + kdlQuote(pin.Pattern) + "\n"
+	}
+	return "pin " + kdlQuote(pin.Pattern) + " {\n    alias " + kdlQuote(pin.Alias) + "\n}\n"
+}
+
+// formatProfileBlock renders a profile node with its includes, pins, and MCP
+// blocks indented one level.
+func formatProfileBlock(block profileBlock) string {
+	result := "profile " + kdlQuote(block.name) + " {\n"
+	for _, inc := range block.includes {
+		result += "    include " + kdlQuote(inc) + "\n"
+	}
+	nodes := make([]string, 0, len(block.pins)+len(block.mcps))
+	for _, pin := range block.pins {
+		nodes = append(nodes, formatPin(pin))
+	}
+	for _, m := range block.mcps {
+		nodes = append(nodes, formatMCPBlock(m))
+	}
+	for _, node := range nodes {
+		for _, line := range strings.SplitAfter(node, "\n") {
+			if line != "" {
+				result += "    " + line
+			}
+		}
+	}
+	return result + "}\n"
+}
+
 func formatMCPBlock(mcp MCPConfig) string {
 	result := "mcp " + kdlQuote(mcp.Name) + " {\n"
 
@@ -573,6 +869,51 @@ func formatMCPBlock(mcp MCPConfig) string {
 		result += "    url " + kdlQuote(mcp.URL) + "\n"
 	}
 
+	if mcp.Socket != "" {
+		result += "    socket " + kdlQuote(mcp.Socket) + "\n"
+	}
+
+	if mcp.Proxy != "" {
+		result += "    proxy " + kdlQuote(mcp.Proxy) + "\n"
+	}
+
+	if tc := mcp.TLS; tc != nil {
+		result += "    tls {\n"
+		if tc.CA != "" {
+			result += "        ca " + kdlQuote(tc.CA) + "\n"
+		}
+		if tc.Cert != "" {
+			result += "        cert " + kdlQuote(tc.Cert) + "\n"
+		}
+		if tc.Key != "" {
+			result += "        key " + kdlQuote(tc.Key) + "\n"
+		}
+		if tc.ServerName != "" {
+			result += "        server_name " + kdlQuote(tc.ServerName) + "\n"
+		}
+		if tc.InsecureSkipVerify {
+			result += "        insecure_skip_verify true\n"
+		}
+		result += "    }\n"
+	}
+
+	if oc := mcp.OAuth; oc != nil {
+		result += "    oauth {\n"
+		result += "        client_id " + kdlQuote(oc.ClientID) + "\n"
+		if oc.ClientSecret != "" {
+			result += "        client_secret " + kdlQuote(oc.ClientSecret) + "\n"
+		}
+		if len(oc.Scopes) > 0 {
+			result += "        scopes"
+			for _, scope := range oc.Scopes {
+				result += " " + kdlQuote(scope)
+			}
+			result += "\n"
+		}
+		result += "        token_endpoint " + kdlQuote(oc.TokenEndpoint) + "\n"
+		result += "    }\n"
+	}
+
 	if mcp.Timeout != "" {
 		result += "    timeout " + kdlQuote(mcp.Timeout) + "\n"
 	}
@@ -589,6 +930,100 @@ func formatMCPBlock(mcp MCPConfig) string {
 		result += "    dynamic true\n"
 	}
 
+	if mcp.Sampling != "" {
+		result += "    sampling " + kdlQuote(mcp.Sampling) + "\n"
+	}
+
+	if mcp.ElicitationTimeout != "" {
+		result += "    elicitation_timeout " + kdlQuote(mcp.ElicitationTimeout) + "\n"
+	}
+
+	if mcp.FailureThreshold != 0 {
+		result += fmt.Sprintf("    failure_threshold %d\n", mcp.FailureThreshold)
+	}
+
+	if mcp.CircuitCooldown != "" {
+		result += "    circuit_cooldown " + kdlQuote(mcp.CircuitCooldown) + "\n"
+	}
+
+	if mcp.MaxConcurrent != 0 {
+		result += fmt.Sprintf("    max_concurrent %d\n", mcp.MaxConcurrent)
+	}
+
+	if mcp.QueueTimeout != "" {
+		result += "    queue_timeout " + kdlQuote(mcp.QueueTimeout) + "\n"
+	}
+
+	if mcp.IdleTimeout != "" {
+		result += "    idle_timeout " + kdlQuote(mcp.IdleTimeout) + "\n"
+	}
+
+	if mcp.StderrLines != 0 {
+		result += fmt.Sprintf("    stderr_lines %d\n", mcp.StderrLines)
+	}
+
+	if mcp.StderrLog != "" {
+		result += "    stderr_log " + kdlQuote(mcp.StderrLog) + "\n"
+	}
+
+	if len(mcp.Allow) > 0 {
+		result += "    allow"
+		for _, p := range mcp.Allow {
+			result += " " + kdlQuote(p)
+		}
+		result += "\n"
+	}
+
+	if len(mcp.Deny) > 0 {
+		result += "    deny"
+		for _, p := range mcp.Deny {
+			result += " " + kdlQuote(p)
+		}
+		result += "\n"
+	}
+
+	if mcp.ToolTimeout != "" {
+		result += "    tool_timeout " + kdlQuote(mcp.ToolTimeout) + "\n"
+	}
+
+	for _, t := range mcp.Tools {
+		result += "    tool " + kdlQuote(t.Pattern) + " {\n"
+		if t.Timeout != "" {
+			result += "        timeout " + kdlQuote(t.Timeout) + "\n"
+		}
+		if t.Idempotent {
+			result += "        idempotent true\n"
+		}
+		if t.CacheTTL != "" {
+			result += "        cache_ttl " + kdlQuote(t.CacheTTL) + "\n"
+		}
+		if t.CachePersist {
+			result += "        cache_persist true\n"
+		}
+		result += "    }\n"
+	}
+
+	if rc := mcp.Retry; rc != nil {
+		result += "    retry {\n"
+		if rc.MaxAttempts != 0 {
+			result += fmt.Sprintf("        max_attempts %d\n", rc.MaxAttempts)
+		}
+		if rc.Backoff != "" {
+			result += "        backoff " + kdlQuote(rc.Backoff) + "\n"
+		}
+		if rc.MaxBackoff != "" {
+			result += "        max_backoff " + kdlQuote(rc.MaxBackoff) + "\n"
+		}
+		if len(rc.On) > 0 {
+			result += "        on"
+			for _, class := range rc.On {
+				result += " " + kdlQuote(class)
+			}
+			result += "\n"
+		}
+		result += "    }\n"
+	}
+
 	if len(mcp.Env) > 0 {
 		result += "    env {\n"
 		for _, k := range sortedKeys(mcp.Env) {
@@ -133,7 +133,7 @@ func TestAddMCPConfigsToFile_WritesBatchWithDefaults(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	cfg, err := loadConfigFile(configPath, SourceProject)
+	cfg, err := loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, err)
 	require.Len(t, cfg.MCPs, 2)
 
@@ -1106,3 +1106,256 @@ func TestFormatMCPBlock_EscapedNameRoundTrip(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, name, parsed.Name)
 }
+
+func TestParseKDLConfig_Sampling(t *testing.T) {
+	kdl := `mcp "writer" {
+    command "writer-mcp"
+    sampling "deny"
+}
+mcp "helper" {
+    command "helper-mcp"
+}`
+
+	cfg, err := ParseKDLConfig(kdl, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, SamplingDeny, cfg.MCPs["writer"].Sampling)
+	assert.Equal(t, "", cfg.MCPs["helper"].Sampling)
+
+	_, err = ParseKDLConfig(`mcp "bad" {
+    command "x"
+    sampling "maybe"
+}`, SourceProject)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `sampling must be "allow" or "deny"`)
+}
+
+func TestFormatMCPBlock_RoundTripWithSampling(t *testing.T) {
+	original := MCPConfig{
+		Name:               "writer",
+		Type:               "stdio",
+		Command:            "writer-mcp",
+		Sampling:           SamplingDeny,
+		ElicitationTimeout: "5m",
+	}
+
+	formatted := formatMCPBlock(original)
+	assert.Contains(t, formatted, `sampling "deny"`)
+	assert.Contains(t, formatted, `elicitation_timeout "5m"`)
+
+	cfg, err := ParseKDLConfig(formatted, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, SamplingDeny, cfg.MCPs["writer"].Sampling)
+	assert.Equal(t, "5m", cfg.MCPs["writer"].ElicitationTimeout)
+}
+
+func TestParseKDLConfig_ToolPolicy(t *testing.T) {
+	kdl := `mcp "github" {
+    command "github-mcp"
+    allow "list_*" "get_*" "delete_branch"
+    deny "delete_*"
+}`
+
+	cfg, err := ParseKDLConfig(kdl, SourceProject)
+	require.NoError(t, err)
+	gh := cfg.MCPs["github"]
+	assert.Equal(t, []string{"list_*", "get_*", "delete_branch"}, gh.Allow)
+	assert.Equal(t, []string{"delete_*"}, gh.Deny)
+
+	assert.True(t, gh.ToolAllowed("list_repos"))
+	assert.False(t, gh.ToolAllowed("create_issue"), "not in allow")
+	assert.False(t, gh.ToolAllowed("delete_branch"), "deny wins over allow")
+	assert.True(t, MCPConfig{}.ToolAllowed("anything"), "no policy exposes every tool")
+	assert.False(t, MCPConfig{Deny: []string{"delete_repo"}}.ToolAllowed("delete_repo"))
+
+	_, err = ParseKDLConfig(`mcp "bad" {
+    command "x"
+    deny "[oops"
+}`, SourceProject)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid tool pattern")
+}
+
+func TestFormatMCPBlock_RoundTripWithToolPolicy(t *testing.T) {
+	original := MCPConfig{
+		Name:    "github",
+		Type:    "stdio",
+		Command: "github-mcp",
+		Allow:   []string{"list_*", "get_*"},
+		Deny:    []string{"delete_repo"},
+	}
+
+	formatted := formatMCPBlock(original)
+	assert.Contains(t, formatted, `allow "list_*" "get_*"`)
+	assert.Contains(t, formatted, `deny "delete_repo"`)
+
+	cfg, err := ParseKDLConfig(formatted, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, original.Allow, cfg.MCPs["github"].Allow)
+	assert.Equal(t, original.Deny, cfg.MCPs["github"].Deny)
+}
+
+func TestFormatMCPBlock_RoundTripWithCircuitBreaker(t *testing.T) {
+	original := MCPConfig{
+		Name:             "flaky",
+		Type:             "stdio",
+		Command:          "flaky-mcp",
+		FailureThreshold: 3,
+		CircuitCooldown:  "1m",
+	}
+
+	formatted := formatMCPBlock(original)
+	assert.Contains(t, formatted, "failure_threshold 3")
+	assert.Contains(t, formatted, `circuit_cooldown "1m"`)
+
+	cfg, err := ParseKDLConfig(formatted, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cfg.MCPs["flaky"].FailureThreshold)
+	assert.Equal(t, "1m", cfg.MCPs["flaky"].CircuitCooldown)
+}
+
+func TestFormatMCPBlock_RoundTripWithConcurrencyLimit(t *testing.T) {
+	original := MCPConfig{
+		Name:          "fragile",
+		Type:          "stdio",
+		Command:       "fragile-mcp",
+		MaxConcurrent: 2,
+		QueueTimeout:  "10s",
+	}
+
+	formatted := formatMCPBlock(original)
+	assert.Contains(t, formatted, "max_concurrent 2")
+	assert.Contains(t, formatted, `queue_timeout "10s"`)
+
+	cfg, err := ParseKDLConfig(formatted, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.MCPs["fragile"].MaxConcurrent)
+	assert.Equal(t, "10s", cfg.MCPs["fragile"].QueueTimeout)
+}
+
+func TestParseKDLConfig_ToolTimeoutsAndRetry(t *testing.T) {
+	kdl := `mcp "api" {
+    url "https://api.example.com/mcp"
+    tool_timeout "1m"
+    tool "get_*" {
+        idempotent true
+    }
+    tool "export_report" {
+        timeout "15m"
+    }
+    retry {
+        max_attempts 4
+        backoff "250ms"
+        max_backoff "5s"
+        on "rate_limit"
+    }
+}`
+
+	cfg, err := ParseKDLConfig(kdl, SourceProject)
+	require.NoError(t, err)
+	api := cfg.MCPs["api"]
+	assert.Equal(t, "1m", api.ToolTimeout)
+	require.Len(t, api.Tools, 2)
+	assert.Equal(t, ToolConfig{Pattern: "get_*", Idempotent: true}, api.Tools[0])
+	assert.Equal(t, ToolConfig{Pattern: "export_report", Timeout: "15m"}, api.Tools[1])
+	assert.Equal(t, &RetryConfig{MaxAttempts: 4, Backoff: "250ms", MaxBackoff: "5s", On: []string{RetryOnRateLimit}}, api.Retry)
+
+	settings, ok := api.ToolSettings("get_user")
+	assert.True(t, ok)
+	assert.True(t, settings.Idempotent)
+	_, ok = api.ToolSettings("delete_user")
+	assert.False(t, ok)
+
+	formatted := formatMCPBlock(api)
+	roundTrip, err := ParseKDLConfig(formatted, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, api.Tools, roundTrip.MCPs["api"].Tools)
+	assert.Equal(t, api.Retry, roundTrip.MCPs["api"].Retry)
+	assert.Equal(t, api.ToolTimeout, roundTrip.MCPs["api"].ToolTimeout)
+
+	_, err = ParseKDLConfig(`mcp "bad" {
+    command "x"
+    retry {
+        on "timeout"
+    }
+}`, SourceProject)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry on must be")
+}
+
+func TestFormatMCPBlock_RoundTripWithResultCache(t *testing.T) {
+	original := MCPConfig{
+		Name:    "github",
+		Type:    "stdio",
+		Command: "github-mcp",
+		Tools:   []ToolConfig{{Pattern: "get_schema", CacheTTL: "1h", CachePersist: true}},
+	}
+
+	formatted := formatMCPBlock(original)
+	assert.Contains(t, formatted, `cache_ttl "1h"`)
+	assert.Contains(t, formatted, "cache_persist true")
+
+	cfg, err := ParseKDLConfig(formatted, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, original.Tools, cfg.MCPs["github"].Tools)
+}
+
+func TestFormatMCPBlock_RoundTripWithStderrLog(t *testing.T) {
+	original := MCPConfig{
+		Name:        "noisy",
+		Type:        "stdio",
+		Command:     "noisy-mcp",
+		StderrLines: 500,
+		StderrLog:   "/var/log/slop-mcp/noisy.log",
+	}
+
+	formatted := formatMCPBlock(original)
+	assert.Contains(t, formatted, "stderr_lines 500")
+	assert.Contains(t, formatted, `stderr_log "/var/log/slop-mcp/noisy.log"`)
+
+	cfg, err := ParseKDLConfig(formatted, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, 500, cfg.MCPs["noisy"].StderrLines)
+	assert.Equal(t, "/var/log/slop-mcp/noisy.log", cfg.MCPs["noisy"].StderrLog)
+}
+
+func TestParseKDLConfig_Pins(t *testing.T) {
+	cfg, err := ParseKDLConfig(`
+pin "github.search_*"
+pin "fs.read_file" {
+    alias "read"
+}
+mcp "fs" {
+    command "fs-mcp"
+}`, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, []PinConfig{{Pattern: "github.search_*"}, {Pattern: "fs.read_file", Alias: "read"}}, cfg.Pins)
+
+	pin := cfg.Pins[0]
+	assert.True(t, pin.Match("github", "search_code"))
+	assert.False(t, pin.Match("github", "get_issue"))
+	assert.False(t, pin.Match("gitlab", "search_code"))
+
+	for _, bad := range []string{
+		`pin "github"`,
+		`pin ".tool"`,
+		`pin "github.[oops"`,
+		"pin \"github.*\" {\n    alias \"all\"\n}",
+		"pin \"fs.read_file\" {\n    alias \"read file\"\n}",
+	} {
+		_, err := ParseKDLConfig(bad, SourceProject)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestWriteConfigFile_RoundTripWithPins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.kdl")
+	cfg := NewConfig()
+	cfg.Pins = []PinConfig{{Pattern: "fs.read_file", Alias: "read"}, {Pattern: "github.*"}}
+	cfg.MCPs["fs"] = MCPConfig{Name: "fs", Type: "stdio", Command: "fs-mcp"}
+	require.NoError(t, WriteConfigFile(path, cfg))
+
+	got, err := loadFileForEdit(path, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Pins, got.Pins)
+	assert.Contains(t, got.MCPs, "fs")
+}
@@ -1,7 +1,8 @@
 package config
 
 // Merge combines user and project configs.
-// Project config takes precedence over user config for the same MCP name.
+// Project config takes precedence over user config for the same MCP name,
+// and for the same pin pattern.
 func Merge(user, project *Config) *Config {
 	merged := NewConfig()
 
@@ -19,26 +20,46 @@ func Merge(user, project *Config) *Config {
 		}
 	}
 
+	for _, c := range []*Config{user, project} {
+		if c != nil {
+			merged.Profiles = mergeStrings(merged.Profiles, c.Profiles)
+			merged.Files = mergeStrings(merged.Files, c.Files)
+			merged.Pins = mergePins(merged.Pins, c.Pins)
+		}
+	}
+
 	return merged
 }
 
 // Load loads and merges the three-tier configs in precedence order:
-// local (.slop-mcp.local.kdl) > project (.slop-mcp.kdl) > user config.
+// local (.slop-mcp.local.kdl) > project (.slop-mcp.kdl) > user config,
+// with the profiles selected by SLOP_MCP_PROFILE.
 func Load(projectDir string) (*Config, error) {
-	user, err := LoadUserConfig()
+	return LoadProfiles(projectDir, ProfilesFromEnv())
+}
+
+// LoadProfiles is Load with an explicit profile selection. Selecting a
+// profile that no file defines is an error, so a typo does not silently
+// load the base config alone.
+func LoadProfiles(projectDir string, profiles []string) (*Config, error) {
+	user, err := loadUserConfig(profiles)
 	if err != nil {
 		return nil, err
 	}
 
-	project, err := LoadProjectConfig(projectDir)
+	project, err := loadProjectConfig(projectDir, profiles)
 	if err != nil {
 		return nil, err
 	}
 
-	local, err := LoadLocalConfig(projectDir)
+	local, err := loadLocalConfig(projectDir, profiles)
 	if err != nil {
 		return nil, err
 	}
 
-	return Merge(Merge(user, project), local), nil
+	merged := Merge(Merge(user, project), local)
+	if err := checkProfiles(merged, profiles); err != nil {
+		return nil, err
+	}
+	return merged, nil
 }
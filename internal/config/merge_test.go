@@ -870,7 +870,7 @@ func TestAddMCPToFile(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify file contents
-	cfg, err := loadConfigFile(configPath, SourceProject)
+	cfg, err := loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, err)
 	assert.Len(t, cfg.MCPs, 1)
 	assert.Equal(t, "first-cmd", cfg.MCPs["first"].Command)
@@ -880,7 +880,7 @@ func TestAddMCPToFile(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify both MCPs exist
-	cfg, err = loadConfigFile(configPath, SourceProject)
+	cfg, err = loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, err)
 	assert.Len(t, cfg.MCPs, 2)
 	assert.Equal(t, "first-cmd", cfg.MCPs["first"].Command)
@@ -903,7 +903,7 @@ func TestAddMCPConfigToFile(t *testing.T) {
 	err := AddMCPConfigToFile(configPath, mcp)
 	require.NoError(t, err)
 
-	cfg, err := loadConfigFile(configPath, SourceProject)
+	cfg, err := loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, err)
 	assert.Len(t, cfg.MCPs, 1)
 
@@ -923,13 +923,135 @@ func TestAddMCPConfigToFileInfersHTTPForURLOnlyConfig(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	cfg, err := loadConfigFile(configPath, SourceProject)
+	cfg, err := loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, err)
 	require.Contains(t, cfg.MCPs, "url-only")
 	assert.Equal(t, "http", cfg.MCPs["url-only"].Type)
 	assert.Equal(t, "https://example.com/mcp", cfg.MCPs["url-only"].URL)
 }
 
+func TestParseKDLConfig_InfersWebSocketFromURLScheme(t *testing.T) {
+	cfg, err := ParseKDLConfig(`mcp "internal" {
+    url "wss://mcp.example.com/ws"
+}`, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, "websocket", cfg.MCPs["internal"].Type)
+}
+
+func TestUnixSocketMCP_ParsesAndRoundTrips(t *testing.T) {
+	cfg, err := ParseKDLConfig(`mcp "daemon" {
+    socket "/run/daemon/mcp.sock"
+}
+mcp "daemon-http" {
+    type "unix"
+    socket "/run/daemon/http.sock"
+    url "http://localhost/mcp"
+}`, SourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, "unix", cfg.MCPs["daemon"].Type, "a socket without a type implies unix")
+	assert.Equal(t, "/run/daemon/mcp.sock", cfg.MCPs["daemon"].Socket)
+	assert.Equal(t, "http://localhost/mcp", cfg.MCPs["daemon-http"].URL)
+
+	configPath := filepath.Join(t.TempDir(), "config.kdl")
+	require.NoError(t, WriteConfigFile(configPath, cfg))
+	reloaded, err := loadConfigFile(configPath, SourceProject, nil)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.MCPs["daemon-http"].Socket, reloaded.MCPs["daemon-http"].Socket)
+	assert.Equal(t, "unix", reloaded.MCPs["daemon"].Type)
+}
+
+func TestTLSAndProxy_ParseValidateAndRoundTrip(t *testing.T) {
+	cfg, err := ParseKDLConfig(`mcp "corp" {
+    url "https://mcp.corp.example/mcp"
+    proxy "http://proxy.corp.example:3128"
+    tls {
+        ca "certs/corp-ca.pem"
+        cert "certs/client.pem"
+        key "certs/client.key"
+        server_name "mcp.internal"
+    }
+}`, SourceProject)
+	require.NoError(t, err)
+	corp := cfg.MCPs["corp"]
+	require.NotNil(t, corp.TLS)
+	assert.Equal(t, TLSConfig{CA: "certs/corp-ca.pem", Cert: "certs/client.pem", Key: "certs/client.key", ServerName: "mcp.internal"}, *corp.TLS)
+	assert.Equal(t, "http://proxy.corp.example:3128", corp.Proxy)
+
+	configPath := filepath.Join(t.TempDir(), "config.kdl")
+	require.NoError(t, WriteConfigFile(configPath, cfg))
+	reloaded, err := loadConfigFile(configPath, SourceProject, nil)
+	require.NoError(t, err)
+	assert.Equal(t, corp.TLS, reloaded.MCPs["corp"].TLS)
+	assert.Equal(t, corp.Proxy, reloaded.MCPs["corp"].Proxy)
+
+	for bad, want := range map[string]string{
+		`mcp "x" { url "https://x"; tls { cert "c.pem"; }; }`: "cert and key must be set together",
+		`mcp "x" { url "https://x"; proxy "ftp://proxy"; }`:   "scheme must be http, https, or socks5",
+		`mcp "x" { url "https://x"; proxy "http://"; }`:       "missing host",
+	} {
+		_, err := ParseKDLConfig(bad, SourceProject)
+		require.Error(t, err, bad)
+		assert.Contains(t, err.Error(), want)
+	}
+	_, err = ParseKDLConfig(`mcp "x" { url "https://x"; proxy "none"; }`, SourceProject)
+	assert.NoError(t, err)
+}
+
+func TestOAuthClientCredentials_ParseValidateAndRoundTrip(t *testing.T) {
+	cfg, err := ParseKDLConfig(`mcp "api" {
+    url "https://api.example.com/mcp"
+    oauth {
+        client_id "ci-runner"
+        client_secret "${env:API_CLIENT_SECRET}"
+        scopes "tools:read" "tools:call"
+        token_endpoint "https://auth.example.com/oauth/token"
+    }
+}`, SourceProject)
+	require.NoError(t, err)
+	want := &OAuthConfig{
+		ClientID:      "ci-runner",
+		ClientSecret:  "${env:API_CLIENT_SECRET}",
+		Scopes:        []string{"tools:read", "tools:call"},
+		TokenEndpoint: "https://auth.example.com/oauth/token",
+	}
+	assert.Equal(t, want, cfg.MCPs["api"].OAuth)
+
+	configPath := filepath.Join(t.TempDir(), "config.kdl")
+	require.NoError(t, WriteConfigFile(configPath, cfg))
+	reloaded, err := loadConfigFile(configPath, SourceProject, nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, reloaded.MCPs["api"].OAuth)
+
+	for bad, msg := range map[string]string{
+		`mcp "x" { url "https://x"; oauth { token_endpoint "https://auth/token"; }; }`:    "client_id is required",
+		`mcp "x" { url "https://x"; oauth { client_id "c"; }; }`:                          "token_endpoint is required",
+		`mcp "x" { url "https://x"; oauth { client_id "c"; token_endpoint "/token"; }; }`: "must be an http or https URL",
+	} {
+		_, err := ParseKDLConfig(bad, SourceProject)
+		require.Error(t, err, bad)
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestMCPConfig_ResolvePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	cfg := MCPConfig{File: "/etc/slop-mcp/config.kdl"}
+	for in, want := range map[string]string{
+		"certs/ca.pem":    "/etc/slop-mcp/certs/ca.pem",
+		"/abs/ca.pem":     "/abs/ca.pem",
+		"~/certs/ca.pem":  filepath.Join(home, "certs/ca.pem"),
+		"../other/ca.pem": "/etc/other/ca.pem",
+	} {
+		got, err := cfg.ResolvePath(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, in)
+	}
+	got, err := MCPConfig{}.ResolvePath("ca.pem")
+	require.NoError(t, err)
+	assert.Equal(t, "ca.pem", got, "without a config file the path stays relative to the working directory")
+}
+
 // TestRemoveMCPFromFile tests removing an MCP from a config file.
 func TestRemoveMCPFromFile(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -946,7 +1068,7 @@ func TestRemoveMCPFromFile(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify only one remains
-	cfg, err = loadConfigFile(configPath, SourceProject)
+	cfg, err = loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, err)
 	assert.Len(t, cfg.MCPs, 1)
 	assert.Contains(t, cfg.MCPs, "keep")
@@ -965,7 +1087,7 @@ func TestRemoveMCPFromFileReturnsErrorWhenMissing(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "missing")
 
-	loaded, loadErr := loadConfigFile(configPath, SourceProject)
+	loaded, loadErr := loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, loadErr)
 	assert.Len(t, loaded.MCPs, 1)
 	assert.Contains(t, loaded.MCPs, "keep")
@@ -989,7 +1111,7 @@ func TestWriteConfigFile(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify file exists and is readable
-	loaded, err := loadConfigFile(configPath, SourceProject)
+	loaded, err := loadConfigFile(configPath, SourceProject, nil)
 	require.NoError(t, err)
 	assert.Len(t, loaded.MCPs, 1)
 	assert.Equal(t, "test-cmd", loaded.MCPs["test"].Command)
@@ -1020,3 +1142,17 @@ func TestNewConfig(t *testing.T) {
 func configWithMCPs(mcps map[string]MCPConfig) *Config {
 	return &Config{MCPs: mcps}
 }
+
+func TestMerge_Pins(t *testing.T) {
+	user := NewConfig()
+	user.Pins = []PinConfig{{Pattern: "fs.read_file"}, {Pattern: "github.*"}}
+	project := NewConfig()
+	project.Pins = []PinConfig{{Pattern: "fs.read_file", Alias: "read"}, {Pattern: "jira.*"}}
+
+	merged := Merge(user, project)
+	assert.Equal(t, []PinConfig{
+		{Pattern: "fs.read_file", Alias: "read"},
+		{Pattern: "github.*"},
+		{Pattern: "jira.*"},
+	}, merged.Pins)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	kdl "github.com/sblinch/kdl-go"
+)
+
+// HTTPToken is a named bearer token from an HTTP token file, with the MCPs
+// and tools its holder may use.
+type HTTPToken struct {
+	Name   string
+	Secret string
+	// MCPs and Tools are glob patterns (path.Match syntax); empty allows all.
+	// A tool pattern containing "." matches "mcp.tool", otherwise the bare
+	// tool name.
+	MCPs  []string
+	Tools []string
+	// ReadOnly forbids the meta-tools that change server state: manage_mcps,
+	// auth_mcp, and customize_tools.
+	ReadOnly bool
+}
+
+// KDLTokenFile is the raw KDL structure of an HTTP token file.
+type KDLTokenFile struct {
+	Tokens []KDLToken `kdl:"token,multiple"`
+}
+
+// KDLToken represents a token node in KDL.
+type KDLToken struct {
+	Name     string   `kdl:",arg"`
+	Secret   string   `kdl:"secret"`
+	MCPs     []string `kdl:"mcps"`
+	Tools    []string `kdl:"tools"`
+	ReadOnly bool     `kdl:"read_only"`
+}
+
+// LoadTokenFile reads and parses an HTTP token file.
+func LoadTokenFile(filePath string) ([]HTTPToken, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := ParseTokenFile(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return tokens, nil
+}
+
+// ParseTokenFile parses HTTP token file data. Every token needs a unique name
+// and a unique, non-empty secret, and all patterns must be valid globs.
+func ParseTokenFile(data string) ([]HTTPToken, error) {
+	var raw KDLTokenFile
+	if err := kdl.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+	if len(raw.Tokens) == 0 {
+		return nil, fmt.Errorf("no token blocks")
+	}
+
+	names := make(map[string]bool, len(raw.Tokens))
+	secrets := make(map[string]string, len(raw.Tokens))
+	tokens := make([]HTTPToken, 0, len(raw.Tokens))
+	for _, t := range raw.Tokens {
+		if t.Name == "" {
+			return nil, fmt.Errorf("token block is missing a name")
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("duplicate token block %q: each token name must be unique", t.Name)
+		}
+		names[t.Name] = true
+		if t.Secret == "" {
+			return nil, fmt.Errorf("token %q: secret is required", t.Name)
+		}
+		if other, dup := secrets[t.Secret]; dup {
+			return nil, fmt.Errorf("token %q: secret is the same as token %q", t.Name, other)
+		}
+		secrets[t.Secret] = t.Name
+		for _, p := range append(append([]string{}, t.MCPs...), t.Tools...) {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("token %q: invalid pattern %q: %w", t.Name, p, err)
+			}
+		}
+		tokens = append(tokens, HTTPToken{
+			Name:     t.Name,
+			Secret:   t.Secret,
+			MCPs:     t.MCPs,
+			Tools:    t.Tools,
+			ReadOnly: t.ReadOnly,
+		})
+	}
+	return tokens, nil
+}
+
+// AllowsMCP reports whether the token may use mcpName.
+func (t *HTTPToken) AllowsMCP(mcpName string) bool {
+	return len(t.MCPs) == 0 || matchAny(t.MCPs, mcpName)
+}
+
+// AllowsTool reports whether the token may call toolName on mcpName.
+func (t *HTTPToken) AllowsTool(mcpName, toolName string) bool {
+	if !t.AllowsMCP(mcpName) {
+		return false
+	}
+	if len(t.Tools) == 0 {
+		return true
+	}
+	for _, p := range t.Tools {
+		subject := toolName
+		if strings.Contains(p, ".") {
+			subject = mcpName + "." + toolName
+		}
+		if ok, _ := path.Match(p, subject); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
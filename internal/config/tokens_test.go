@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTokenFile(t *testing.T) {
+	kdl := `token "ci" {
+    secret "ci-secret"
+    mcps "github" "docs-*"
+    tools "get_*" "github.list_*"
+    read_only true
+}
+token "admin" {
+    secret "admin-secret"
+}`
+
+	tokens, err := ParseTokenFile(kdl)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	ci := tokens[0]
+	assert.Equal(t, "ci", ci.Name)
+	assert.Equal(t, "ci-secret", ci.Secret)
+	assert.Equal(t, []string{"github", "docs-*"}, ci.MCPs)
+	assert.Equal(t, []string{"get_*", "github.list_*"}, ci.Tools)
+	assert.True(t, ci.ReadOnly)
+
+	admin := tokens[1]
+	assert.Empty(t, admin.MCPs)
+	assert.False(t, admin.ReadOnly)
+}
+
+func TestParseTokenFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		kdl  string
+		want string
+	}{
+		{"empty", ``, "no token blocks"},
+		{"missing secret", `token "a"`, `token "a": secret is required`},
+		{"duplicate name", `token "a" {
+    secret "x"
+}
+token "a" {
+    secret "y"
+}`, `duplicate token block "a"`},
+		{"shared secret", `token "a" {
+    secret "x"
+}
+token "b" {
+    secret "x"
+}`, `token "b": secret is the same as token "a"`},
+		{"bad glob", `token "a" {
+    secret "x"
+    tools "[get"
+}`, `invalid pattern "[get"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTokenFile(tt.kdl)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestLoadTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.kdl")
+	require.NoError(t, os.WriteFile(path, []byte(`token "a" {
+    secret "x"
+}`), 0600))
+
+	tokens, err := LoadTokenFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a", tokens[0].Name)
+
+	_, err = LoadTokenFile(filepath.Join(t.TempDir(), "missing.kdl"))
+	assert.Error(t, err)
+}
+
+func TestHTTPToken_Allows(t *testing.T) {
+	tok := HTTPToken{
+		MCPs:  []string{"github", "docs-*"},
+		Tools: []string{"get_*", "github.list_*"},
+	}
+
+	assert.True(t, tok.AllowsMCP("github"))
+	assert.True(t, tok.AllowsMCP("docs-internal"))
+	assert.False(t, tok.AllowsMCP("filesystem"))
+
+	assert.True(t, tok.AllowsTool("github", "get_issue"))
+	assert.True(t, tok.AllowsTool("docs-internal", "get_page"), "bare patterns match any allowed MCP")
+	assert.True(t, tok.AllowsTool("github", "list_repos"))
+	assert.False(t, tok.AllowsTool("docs-internal", "list_repos"), "qualified patterns match one MCP")
+	assert.False(t, tok.AllowsTool("github", "delete_repo"))
+	assert.False(t, tok.AllowsTool("filesystem", "get_file"), "the MCP allow-list applies first")
+
+	open := HTTPToken{}
+	assert.True(t, open.AllowsMCP("anything"))
+	assert.True(t, open.AllowsTool("anything", "at_all"))
+}
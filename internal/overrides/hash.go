@@ -92,3 +92,33 @@ func ComputeHash(description string, params map[string]string) string {
 	h.Write(pb)
 	return hex.EncodeToString(h.Sum(nil))[:16]
 }
+
+// ParamDescriptions returns the parameter descriptions of a tool's input
+// schema by parameter name: the params half of ComputeHash's input.
+func ParamDescriptions(inputSchema map[string]any) map[string]string {
+	props, ok := inputSchema["properties"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(props))
+	for name, raw := range props {
+		if prop, ok := raw.(map[string]any); ok {
+			if desc, ok := prop["description"].(string); ok {
+				out[name] = desc
+			}
+		}
+	}
+	return out
+}
+
+// CanonicalHash returns the hex SHA-256 of v's canonical JSON, so values that
+// differ only in map key order hash the same. It keys memoized tool results
+// by their arguments.
+func CanonicalHash(v any) (string, error) {
+	b, err := canonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
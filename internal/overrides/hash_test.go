@@ -49,3 +49,46 @@ func TestComputeHash_Sensitive(t *testing.T) {
 		t.Error("hash must differ on description or params change")
 	}
 }
+
+func TestCanonicalHash_KeyOrderIndependent(t *testing.T) {
+	h1, err := CanonicalHash(map[string]any{"org": "acme", "filter": map[string]any{"b": 1, "a": 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := CanonicalHash(map[string]any{"filter": map[string]any{"a": 2, "b": 1}, "org": "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h1 != h2 {
+		t.Errorf("hash should ignore map key order: %s vs %s", h1, h2)
+	}
+	if h3, _ := CanonicalHash(map[string]any{"org": "other"}); h3 == h1 {
+		t.Error("hash must differ when values differ")
+	}
+}
+
+func TestParamDescriptions(t *testing.T) {
+	got := ParamDescriptions(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path":  map[string]any{"type": "string", "description": "File path"},
+			"depth": map[string]any{"type": "integer"},
+		},
+	})
+	if len(got) != 1 || got["path"] != "File path" {
+		t.Errorf("ParamDescriptions = %v, want only path", got)
+	}
+	if got := ParamDescriptions(nil); got != nil {
+		t.Errorf("ParamDescriptions(nil) = %v, want nil", got)
+	}
+}
+
+func TestOverrideEntry_Stale(t *testing.T) {
+	e := OverrideEntry{SourceHash: ComputeHash("desc", map[string]string{"a": "b"})}
+	if e.Stale("desc", map[string]string{"a": "b"}) {
+		t.Error("override against the current upstream reported stale")
+	}
+	if !e.Stale("desc", map[string]string{"a": "c"}) {
+		t.Error("override against changed params not reported stale")
+	}
+}
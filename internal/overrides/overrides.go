@@ -52,6 +52,12 @@ type OverrideEntry struct {
 	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
 }
 
+// Stale reports whether the override was recorded against a different
+// upstream description or parameter set than the given one.
+func (e OverrideEntry) Stale(upstreamDesc string, upstreamParams map[string]string) bool {
+	return e.SourceHash != ComputeHash(upstreamDesc, upstreamParams)
+}
+
 // Dependency is a hash-pinned reference to a native tool used by a custom tool.
 type Dependency struct {
 	MCP  string `json:"mcp"`
@@ -54,6 +54,11 @@ func OpenStore(opts StoreOptions) (*Store, error) {
 	return s, nil
 }
 
+// Options returns the roots the store was opened with.
+func (s *Store) Options() StoreOptions {
+	return s.opts
+}
+
 func (s *Store) rootFor(scope Scope) string {
 	switch scope {
 	case ScopeUser:
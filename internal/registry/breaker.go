@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// Circuit breaker defaults.
+const (
+	DefaultFailureThreshold = 5                // consecutive upstream failures that open the circuit
+	DefaultCircuitCooldown  = 30 * time.Second // how long an open circuit fails calls fast
+)
+
+// CircuitState is the state of an MCP's circuit breaker.
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // calls go through
+	CircuitOpen     CircuitState = "open"      // calls fail fast until the cool-down ends
+	CircuitHalfOpen CircuitState = "half-open" // one trial call decides whether to close or reopen
+)
+
+// circuitBreaker counts consecutive upstream failures (failed connects and
+// lost connections) of one MCP. It lives beside mcpState, which connect and
+// disconnect replace wholesale, so it survives those transitions.
+type circuitBreaker struct {
+	state     CircuitState
+	failures  int
+	lastErr   error
+	openUntil time.Time
+	probing   bool // a half-open trial call is in flight
+}
+
+// CircuitOpenError is returned, without contacting the MCP, while its
+// circuit is open.
+type CircuitOpenError struct {
+	MCPName  string
+	Until    time.Time
+	Failures int
+	LastErr  error
+	Probing  bool // half-open, with the one trial call already in flight
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.Probing {
+		return fmt.Sprintf("MCP %s circuit is half-open and a trial call is in flight; retry shortly", e.MCPName)
+	}
+	msg := fmt.Sprintf("MCP %s circuit open until %s after %d consecutive failures",
+		e.MCPName, e.Until.Format(time.RFC3339), e.Failures)
+	if e.LastErr != nil {
+		msg += fmt.Sprintf(" (last error: %v)", e.LastErr)
+	}
+	return msg
+}
+
+func (e *CircuitOpenError) Unwrap() error { return e.LastErr }
+
+// GetFailureThreshold returns how many consecutive failures open cfg's
+// circuit; 0 means the breaker is disabled (failure_threshold < 0).
+func GetFailureThreshold(cfg config.MCPConfig) int {
+	switch {
+	case cfg.FailureThreshold > 0:
+		return cfg.FailureThreshold
+	case cfg.FailureThreshold < 0:
+		return 0
+	default:
+		return DefaultFailureThreshold
+	}
+}
+
+// GetCircuitCooldown returns how long cfg's circuit stays open.
+func GetCircuitCooldown(cfg config.MCPConfig) time.Duration {
+	if cfg.CircuitCooldown != "" {
+		if d, err := time.ParseDuration(cfg.CircuitCooldown); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultCircuitCooldown
+}
+
+// breakerLocked returns mcpName's breaker, creating a closed one on first
+// use. The caller must hold r.mu for writing.
+func (r *Registry) breakerLocked(mcpName string) *circuitBreaker {
+	if r.breakers == nil {
+		r.breakers = make(map[string]*circuitBreaker)
+	}
+	b, ok := r.breakers[mcpName]
+	if !ok {
+		b = &circuitBreaker{state: CircuitClosed}
+		r.breakers[mcpName] = b
+	}
+	return b
+}
+
+// breakerAllow admits a call to mcpName or returns a *CircuitOpenError. Once
+// the cool-down ends the circuit turns half-open and admits exactly one trial
+// call, reported as probe; the caller must breakerRelease a probe when the
+// call finishes.
+func (r *Registry) breakerAllow(mcpName string) (probe bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[mcpName]
+	if !ok || b.state == CircuitClosed {
+		return false, nil
+	}
+	if b.state == CircuitOpen {
+		if time.Now().Before(b.openUntil) {
+			return false, &CircuitOpenError{MCPName: mcpName, Until: b.openUntil, Failures: b.failures, LastErr: b.lastErr}
+		}
+		b.state = CircuitHalfOpen
+	}
+	if b.probing {
+		return false, &CircuitOpenError{MCPName: mcpName, Failures: b.failures, LastErr: b.lastErr, Probing: true}
+	}
+	b.probing = true
+	return true, nil
+}
+
+// breakerRelease ends a half-open trial call. If the call neither failed nor
+// succeeded (e.g. the caller cancelled), the next call may try again.
+func (r *Registry) breakerRelease(mcpName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b, ok := r.breakers[mcpName]; ok {
+		b.probing = false
+	}
+}
+
+// breakerSuccess closes mcpName's circuit after a call the MCP answered.
+func (r *Registry) breakerSuccess(mcpName string) {
+	r.mu.RLock()
+	b, ok := r.breakers[mcpName]
+	healthy := !ok || (b.state == CircuitClosed && b.failures == 0)
+	r.mu.RUnlock()
+	if healthy {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.breakerSuccessLocked(mcpName)
+}
+
+// breakerSuccessLocked closes mcpName's circuit. The caller must hold r.mu.
+func (r *Registry) breakerSuccessLocked(mcpName string) {
+	b, ok := r.breakers[mcpName]
+	if !ok {
+		return
+	}
+	if b.state != CircuitClosed {
+		r.logger.Info("MCP circuit closed", "mcp_name", mcpName)
+	}
+	b.state, b.failures, b.lastErr, b.probing = CircuitClosed, 0, nil, false
+}
+
+// breakerFailureLocked records an upstream failure of cfg's MCP, opening the
+// circuit at the threshold; a failed half-open trial reopens it at once. The
+// caller must hold r.mu.
+func (r *Registry) breakerFailureLocked(cfg config.MCPConfig, cause error) {
+	threshold := GetFailureThreshold(cfg)
+	if threshold == 0 {
+		return
+	}
+	b := r.breakerLocked(cfg.Name)
+	b.failures++
+	b.lastErr = cause
+	if b.state == CircuitHalfOpen || b.failures >= threshold {
+		cooldown := GetCircuitCooldown(cfg)
+		b.state = CircuitOpen
+		b.openUntil = time.Now().Add(cooldown)
+		b.probing = false
+		r.logger.Warn("MCP circuit opened", "mcp_name", cfg.Name, "failures", b.failures, "cooldown", cooldown, "error", cause)
+	}
+}
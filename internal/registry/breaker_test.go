@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{
+		Name:             "flaky",
+		Type:             "stdio",
+		Command:          "/nonexistent/slop-mcp-test-binary",
+		FailureThreshold: 2,
+		CircuitCooldown:  "1h",
+	}
+	r.SetConfigured(cfg)
+	ctx := context.Background()
+
+	require.Error(t, r.Connect(ctx, cfg))
+	_, err := r.ExecuteToolRaw(ctx, "flaky", "anything", nil)
+	var open *CircuitOpenError
+	require.False(t, errors.As(err, &open), "one failure is under the threshold")
+
+	require.Error(t, r.Connect(ctx, cfg))
+	_, err = r.ExecuteToolRaw(ctx, "flaky", "anything", nil)
+	require.ErrorAs(t, err, &open)
+	assert.Equal(t, 2, open.Failures)
+	assert.Contains(t, err.Error(), "circuit open until")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), open.Until, time.Minute)
+
+	status := r.Status()
+	require.Len(t, status, 1)
+	assert.Equal(t, CircuitOpen, status[0].Circuit)
+	assert.Equal(t, 2, status[0].CircuitFailures)
+	assert.NotEmpty(t, status[0].CircuitOpenUntil)
+}
+
+func TestCircuitBreaker_HalfOpenTrialCloses(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "ci", Type: "stdio", Command: "x", FailureThreshold: 1}
+	r.mu.Lock()
+	r.breakerFailureLocked(cfg, errors.New("boom"))
+	r.breakers["ci"].openUntil = time.Now().Add(-time.Second)
+	r.mu.Unlock()
+
+	installInMemorySession(t, r, cfg, newProgressServer(nil, nil))
+	_, err := r.ExecuteToolRaw(context.Background(), "ci", "build", nil)
+	require.NoError(t, err, "the cool-down has passed, so a trial call goes through")
+
+	status := r.Status()
+	require.Len(t, status, 1)
+	assert.Empty(t, status[0].Circuit, "a successful trial closes the circuit")
+}
+
+func TestCircuitBreaker_HalfOpenAdmitsOneTrial(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "ci", FailureThreshold: 1, CircuitCooldown: "1m"}
+	r.mu.Lock()
+	r.breakerFailureLocked(cfg, errors.New("boom"))
+	r.breakers["ci"].openUntil = time.Now().Add(-time.Second)
+	r.mu.Unlock()
+
+	probe, err := r.breakerAllow("ci")
+	require.NoError(t, err)
+	assert.True(t, probe)
+
+	_, err = r.breakerAllow("ci")
+	var open *CircuitOpenError
+	require.ErrorAs(t, err, &open)
+	assert.True(t, open.Probing)
+
+	r.mu.Lock()
+	r.breakerFailureLocked(cfg, errors.New("still down"))
+	r.mu.Unlock()
+	_, err = r.breakerAllow("ci")
+	require.ErrorAs(t, err, &open)
+	assert.False(t, open.Probing, "a failed trial reopens the circuit")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), open.Until, 5*time.Second)
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "ci", FailureThreshold: -1}
+	r.mu.Lock()
+	for i := 0; i < 10; i++ {
+		r.breakerFailureLocked(cfg, errors.New("boom"))
+	}
+	r.mu.Unlock()
+	probe, err := r.breakerAllow("ci")
+	assert.NoError(t, err)
+	assert.False(t, probe)
+}
+
+func TestGetCircuitSettings(t *testing.T) {
+	assert.Equal(t, DefaultFailureThreshold, GetFailureThreshold(config.MCPConfig{}))
+	assert.Equal(t, 3, GetFailureThreshold(config.MCPConfig{FailureThreshold: 3}))
+	assert.Zero(t, GetFailureThreshold(config.MCPConfig{FailureThreshold: -1}))
+	assert.Equal(t, DefaultCircuitCooldown, GetCircuitCooldown(config.MCPConfig{CircuitCooldown: "bogus"}))
+	assert.Equal(t, 2*time.Minute, GetCircuitCooldown(config.MCPConfig{CircuitCooldown: "2m"}))
+}
@@ -0,0 +1,271 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// Caller identifies the downstream client session an upstream call is made
+// on behalf of. Requests the upstream MCP sends back while the call is in
+// flight (sampling/createMessage, elicitation/create) and its progress
+// notifications are relayed to this session.
+type Caller struct {
+	Session *mcp.ServerSession
+	// Interactive is false when no human is answering on Session's behalf
+	// mid-call (run_slop scripts); elicitation is refused for such callers.
+	Interactive bool
+	// ProgressToken is the downstream request's progress token; nil when the
+	// client asked for no progress. Upstream progress is relayed under it.
+	ProgressToken any
+}
+
+// inflightCall is one upstream tool call a Caller is waiting on.
+type inflightCall struct {
+	caller        *Caller
+	tool          string
+	progressToken string // token sent upstream; empty when not relaying progress
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context carrying c. callRaw registers the caller as in
+// flight on the target MCP for the duration of the upstream call.
+func WithCaller(ctx context.Context, c *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// callerFrom returns the Caller attached to ctx, or nil.
+func callerFrom(ctx context.Context) *Caller {
+	c, _ := ctx.Value(callerKey{}).(*Caller)
+	return c
+}
+
+// SamplingUnsupportedError is returned to an upstream MCP when the downstream
+// client it would be relayed to did not advertise the sampling capability.
+type SamplingUnsupportedError struct {
+	MCPName string
+}
+
+func (e *SamplingUnsupportedError) Error() string {
+	return fmt.Sprintf("MCP %s requested sampling/createMessage, but the downstream client does not support sampling", e.MCPName)
+}
+
+// ElicitationUnavailableError is returned to an upstream MCP whose
+// elicitation/create request cannot be put in front of a user.
+type ElicitationUnavailableError struct {
+	MCPName  string
+	ToolName string // empty when no call to the MCP is in flight
+	Reason   string
+}
+
+func (e *ElicitationUnavailableError) Error() string {
+	if e.ToolName == "" {
+		return fmt.Sprintf("MCP %s requested user input (elicitation), but %s", e.MCPName, e.Reason)
+	}
+	return fmt.Sprintf("MCP %s requested user input (elicitation) during '%s', but %s", e.MCPName, e.ToolName, e.Reason)
+}
+
+// DefaultElicitationTimeout bounds how long an upstream elicitation waits for
+// the user to answer when the MCP config sets no elicitation_timeout.
+const DefaultElicitationTimeout = 2 * time.Minute
+
+// GetElicitationTimeout returns how long an elicitation relayed for cfg may
+// wait for the user: the per-MCP elicitation_timeout, else the default.
+func GetElicitationTimeout(cfg config.MCPConfig) time.Duration {
+	if cfg.ElicitationTimeout != "" {
+		if d, err := time.ParseDuration(cfg.ElicitationTimeout); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultElicitationTimeout
+}
+
+// progressRouteGrace is how long an upstream progress token stays routable
+// after its call returned.
+const progressRouteGrace = time.Second
+
+// trackCall registers a call to toolName on mcpName, made for c, as in
+// flight until the returned func is called. When c wants progress, the call
+// gets a registry-unique upstream progress token: downstream tokens are only
+// unique per client session, so they cannot be forwarded as-is.
+func (r *Registry) trackCall(mcpName, toolName string, c *Caller) (*inflightCall, func()) {
+	call := &inflightCall{caller: c, tool: toolName}
+	if c.Session != nil && c.ProgressToken != nil {
+		call.progressToken = fmt.Sprintf("slop-mcp-%d", r.progressSeq.Add(1))
+	}
+	r.callersMu.Lock()
+	r.callers[mcpName] = append(r.callers[mcpName], call)
+	if call.progressToken != "" {
+		r.progress[call.progressToken] = call
+	}
+	r.callersMu.Unlock()
+
+	return call, func() {
+		if call.progressToken != "" {
+			// The SDK retires a call as soon as its response is read but
+			// queues earlier notifications for a separate handler goroutine,
+			// so progress sent just before the result can arrive after this
+			// release. Keep the route open briefly so it is not dropped.
+			time.AfterFunc(progressRouteGrace, func() {
+				r.callersMu.Lock()
+				delete(r.progress, call.progressToken)
+				r.callersMu.Unlock()
+			})
+		}
+		r.callersMu.Lock()
+		defer r.callersMu.Unlock()
+		list := r.callers[mcpName]
+		for i, x := range list {
+			if x == call {
+				list = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(r.callers, mcpName)
+		} else {
+			r.callers[mcpName] = list
+		}
+	}
+}
+
+// activeCall returns the in-flight call on mcpName that an upstream request
+// belongs to. Upstream requests carry no reference to the tool call that
+// triggered them, and an upstream is shared by every client session, so a
+// request can only be attributed when all calls in flight on the MCP were
+// made for one session; ambiguous reports that they were not, and the
+// request must then be refused rather than shown to a session it may not
+// belong to. Among one session's calls, the newest names the tool.
+func (r *Registry) activeCall(mcpName string) (call *inflightCall, ambiguous bool) {
+	r.callersMu.Lock()
+	defer r.callersMu.Unlock()
+	list := r.callers[mcpName]
+	if len(list) == 0 {
+		return nil, false
+	}
+	call = list[len(list)-1]
+	for _, other := range list {
+		if other.caller.Session != call.caller.Session {
+			return nil, true
+		}
+	}
+	return call, false
+}
+
+// ambiguousCallReason explains why an upstream request was not relayed while
+// several client sessions had calls in flight on the MCP.
+const ambiguousCallReason = "calls from more than one client session are in flight on it, and the request does not say which call it belongs to"
+
+// samplingAllowed reports whether an MCP may request sampling through
+// slop-mcp. Sampling is allowed unless the config says "deny".
+func samplingAllowed(cfg config.MCPConfig) bool {
+	return cfg.Sampling != config.SamplingDeny
+}
+
+// relayCreateMessage forwards an upstream sampling request to the downstream
+// client session that is waiting on a call to mcpName. The MCP's current
+// config is checked too, so a session opened before sampling was denied
+// cannot keep using it.
+func (r *Registry) relayCreateMessage(ctx context.Context, mcpName string, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	r.mu.RLock()
+	cfg := r.policyFor(mcpName)
+	r.mu.RUnlock()
+	if !samplingAllowed(cfg) {
+		return nil, fmt.Errorf("MCP %s requested sampling/createMessage, but its config denies sampling", mcpName)
+	}
+	call, ambiguous := r.activeCall(mcpName)
+	if ambiguous {
+		return nil, fmt.Errorf("MCP %s requested sampling/createMessage, but %s; it was not relayed", mcpName, ambiguousCallReason)
+	}
+	if call == nil || call.caller.Session == nil {
+		return nil, fmt.Errorf("MCP %s requested sampling/createMessage outside of a tool call; there is no downstream client to relay it to", mcpName)
+	}
+	caller := call.caller
+	if p := caller.Session.InitializeParams(); p == nil || p.Capabilities == nil || p.Capabilities.Sampling == nil {
+		return nil, &SamplingUnsupportedError{MCPName: mcpName}
+	}
+
+	r.logger.Debug("relaying sampling request", "mcp_name", mcpName, "session_id", caller.Session.ID())
+	return caller.Session.CreateMessage(ctx, req.Params)
+}
+
+// relayElicit forwards an upstream elicitation request to the interactive
+// downstream session waiting on a call to mcpName. The message is prefixed
+// with "mcp.tool" so the user can tell which call is asking, and the wait is
+// bounded by the MCP's current elicitation_timeout.
+func (r *Registry) relayElicit(ctx context.Context, mcpName string, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+	r.mu.RLock()
+	timeout := GetElicitationTimeout(r.policyFor(mcpName))
+	r.mu.RUnlock()
+	call, ambiguous := r.activeCall(mcpName)
+	switch {
+	case ambiguous:
+		return nil, &ElicitationUnavailableError{MCPName: mcpName, Reason: ambiguousCallReason}
+	case call == nil || call.caller.Session == nil:
+		return nil, &ElicitationUnavailableError{
+			MCPName: mcpName,
+			Reason:  "no client call is waiting on it, so there is no one to ask",
+		}
+	case !call.caller.Interactive:
+		return nil, &ElicitationUnavailableError{
+			MCPName:  mcpName,
+			ToolName: call.tool,
+			Reason:   "the call runs inside a SLOP script, which cannot prompt the user; call the tool directly with execute_tool",
+		}
+	}
+	session := call.caller.Session
+	if p := session.InitializeParams(); p == nil || p.Capabilities == nil || p.Capabilities.Elicitation == nil {
+		return nil, &ElicitationUnavailableError{
+			MCPName:  mcpName,
+			ToolName: call.tool,
+			Reason:   "the downstream client does not support elicitation",
+		}
+	}
+
+	params := *req.Params
+	params.Message = fmt.Sprintf("[%s.%s] %s", mcpName, call.tool, params.Message)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r.logger.Debug("relaying elicitation request", "mcp_name", mcpName, "tool_name", call.tool, "session_id", session.ID())
+	result, err := session.Elicit(ctx, &params)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("MCP %s: no answer to elicitation during '%s' within %s", mcpName, call.tool, timeout)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// relayProgress forwards an upstream progress notification to the downstream
+// session of the call it belongs to, under the downstream progress token.
+// Notifications for unknown or finished calls are dropped.
+func (r *Registry) relayProgress(ctx context.Context, mcpName string, params *mcp.ProgressNotificationParams) {
+	token, ok := params.ProgressToken.(string)
+	if !ok {
+		return
+	}
+	r.callersMu.Lock()
+	call := r.progress[token]
+	r.callersMu.Unlock()
+	if call == nil {
+		return
+	}
+
+	err := call.caller.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+		ProgressToken: call.caller.ProgressToken,
+		Progress:      params.Progress,
+		Total:         params.Total,
+		Message:       params.Message,
+	})
+	if err != nil {
+		r.logger.Debug("failed to relay progress", "mcp_name", mcpName, "tool_name", call.tool, "error", err)
+	}
+}
@@ -0,0 +1,500 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSamplingServer returns an upstream MCP whose "ask" tool requests
+// sampling from its client and echoes the reply (or the error) as text.
+func newSamplingServer() *mcp.Server {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "writer", Version: "1.0.0"}, nil)
+	srv.AddTool(&mcp.Tool{
+		Name:        "ask",
+		InputSchema: map[string]any{"type": "object"},
+	}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if p := req.Session.InitializeParams(); p.Capabilities == nil || p.Capabilities.Sampling == nil {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "no sampling capability"}}}, nil
+		}
+		res, err := req.Session.CreateMessage(ctx, &mcp.CreateMessageParams{
+			Messages:  []*mcp.SamplingMessage{{Role: "user", Content: &mcp.TextContent{Text: "hello"}}},
+			MaxTokens: 10,
+		})
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}}}, nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{res.Content}}, nil
+	})
+	return srv
+}
+
+// newDownstreamSession connects a downstream client (with the given options)
+// to a fresh outer server and returns the outer server's session for it.
+func newDownstreamSession(t *testing.T, opts *mcp.ClientOptions) *mcp.ServerSession {
+	t.Helper()
+	ctx := context.Background()
+	outer := mcp.NewServer(&mcp.Implementation{Name: "slop-mcp", Version: "test"}, nil)
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	ss, err := outer.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "agent", Version: "test"}, opts).Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = cs.Close()
+		_ = ss.Close()
+	})
+	return ss
+}
+
+func resultText(t *testing.T, res *mcp.CallToolResult) string {
+	t.Helper()
+	require.Len(t, res.Content, 1)
+	text, ok := res.Content[0].(*mcp.TextContent)
+	require.True(t, ok, "expected text content, got %T", res.Content[0])
+	return text.Text
+}
+
+func TestSampling_RelayedToCaller(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "writer", Type: "stdio", Command: "x"}, newSamplingServer())
+
+	var got *mcp.CreateMessageParams
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		CreateMessageHandler: func(_ context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			got = req.Params
+			return &mcp.CreateMessageResult{Role: "assistant", Model: "test-model", Content: &mcp.TextContent{Text: "sampled reply"}}, nil
+		},
+	})
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream})
+	res, err := r.ExecuteToolRaw(ctx, "writer", "ask", nil)
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+	assert.Equal(t, "sampled reply", resultText(t, res))
+	require.NotNil(t, got)
+	assert.Equal(t, int64(10), got.MaxTokens)
+
+	call, _ := r.activeCall("writer")
+	assert.Nil(t, call, "call must be released when it returns")
+}
+
+func TestSampling_DownstreamLacksCapability(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "writer", Type: "stdio", Command: "x"}, newSamplingServer())
+	downstream := newDownstreamSession(t, nil)
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream})
+	res, err := r.ExecuteToolRaw(ctx, "writer", "ask", nil)
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, resultText(t, res), "downstream client does not support sampling")
+}
+
+func TestSampling_NoCaller(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "writer", Type: "stdio", Command: "x"}, newSamplingServer())
+
+	res, err := r.ExecuteToolRaw(context.Background(), "writer", "ask", nil)
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, resultText(t, res), "no downstream client")
+}
+
+func TestSampling_DeniedByConfig(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "writer", Type: "stdio", Command: "x", Sampling: config.SamplingDeny}
+	installInMemorySession(t, r, cfg, newSamplingServer())
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		CreateMessageHandler: func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			t.Error("sampling must not reach the downstream client when denied")
+			return nil, nil
+		},
+	})
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream})
+	res, err := r.ExecuteToolRaw(ctx, "writer", "ask", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "no sampling capability", resultText(t, res))
+}
+
+func TestSampling_DeniedByConfigUpdate(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "writer", Type: "stdio", Command: "x"}
+	installInMemorySession(t, r, cfg, newSamplingServer())
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		CreateMessageHandler: func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			t.Error("sampling must not reach the downstream client once denied")
+			return nil, nil
+		},
+	})
+
+	// The session still advertises sampling; the relay follows the new config.
+	cfg.Sampling = config.SamplingDeny
+	_, err := r.UpdateConfig(context.Background(), cfg)
+	require.NoError(t, err)
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream})
+	res, err := r.ExecuteToolRaw(ctx, "writer", "ask", nil)
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, resultText(t, res), "config denies sampling")
+}
+
+func TestActiveCall_NewestWins(t *testing.T) {
+	r := New()
+	first, second := &Caller{}, &Caller{}
+	_, releaseFirst := r.trackCall("m", "a", first)
+	_, releaseSecond := r.trackCall("m", "b", second)
+	call, ambiguous := r.activeCall("m")
+	require.False(t, ambiguous, "both calls are for the same session")
+	assert.Same(t, second, call.caller)
+	assert.Equal(t, "b", call.tool)
+
+	releaseSecond()
+	call, _ = r.activeCall("m")
+	assert.Same(t, first, call.caller)
+	releaseFirst()
+	call, ambiguous = r.activeCall("m")
+	assert.Nil(t, call)
+	assert.False(t, ambiguous)
+}
+
+// addBlockingTool adds a "wait" tool to srv that signals started and then
+// blocks until release is closed, keeping a call in flight.
+func addBlockingTool(srv *mcp.Server, started chan<- struct{}, release <-chan struct{}) {
+	srv.AddTool(&mcp.Tool{
+		Name:        "wait",
+		InputSchema: map[string]any{"type": "object"},
+	}, func(ctx context.Context, _ *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "done"}}}, nil
+	})
+}
+
+// whileOtherSessionCalls runs fn while another client session has a call to
+// the "wait" tool of mcpName in flight.
+func whileOtherSessionCalls(t *testing.T, r *Registry, mcpName string, other *mcp.ServerSession, started <-chan struct{}, release chan struct{}, fn func()) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		ctx := WithCaller(context.Background(), &Caller{Session: other, Interactive: true})
+		_, err := r.ExecuteToolRaw(ctx, mcpName, "wait", nil)
+		done <- err
+	}()
+	<-started
+	fn()
+	close(release)
+	require.NoError(t, <-done)
+}
+
+func TestSampling_RefusedWithTwoSessionsInFlight(t *testing.T) {
+	r := New()
+	srv := newSamplingServer()
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	addBlockingTool(srv, started, release)
+	installInMemorySession(t, r, config.MCPConfig{Name: "writer", Type: "stdio", Command: "x"}, srv)
+
+	refuse := func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		t.Error("a sampling request that cannot be attributed must not reach any client")
+		return nil, nil
+	}
+	other := newDownstreamSession(t, &mcp.ClientOptions{CreateMessageHandler: refuse})
+	asker := newDownstreamSession(t, &mcp.ClientOptions{CreateMessageHandler: refuse})
+
+	whileOtherSessionCalls(t, r, "writer", other, started, release, func() {
+		ctx := WithCaller(context.Background(), &Caller{Session: asker})
+		res, err := r.ExecuteToolRaw(ctx, "writer", "ask", nil)
+		require.NoError(t, err)
+		assert.True(t, res.IsError)
+		assert.Contains(t, resultText(t, res), "more than one client session")
+	})
+}
+
+// newElicitServer returns an upstream MCP whose "confirm" tool elicits a
+// yes/no answer and echoes the action (or the error) as text.
+func newElicitServer() *mcp.Server {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "deployer", Version: "1.0.0"}, nil)
+	srv.AddTool(&mcp.Tool{
+		Name:        "confirm",
+		InputSchema: map[string]any{"type": "object"},
+	}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		res, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+			Message: "Deploy to production?",
+			RequestedSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"ok": map[string]any{"type": "boolean"}},
+			},
+		})
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}}}, nil
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: res.Action}}}, nil
+	})
+	return srv
+}
+
+func TestElicitation_RelayedToInteractiveCaller(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "deployer", Type: "stdio", Command: "x"}, newElicitServer())
+
+	var message string
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		ElicitationHandler: func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			message = req.Params.Message
+			return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"ok": true}}, nil
+		},
+	})
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream, Interactive: true})
+	res, err := r.ExecuteToolRaw(ctx, "deployer", "confirm", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "accept", resultText(t, res))
+	assert.Equal(t, "[deployer.confirm] Deploy to production?", message)
+}
+
+func TestElicitation_RefusedInsideScript(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "deployer", Type: "stdio", Command: "x"}, newElicitServer())
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		ElicitationHandler: func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			t.Error("a non-interactive caller must not be prompted")
+			return nil, nil
+		},
+	})
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream, Interactive: false})
+	res, err := r.ExecuteToolRaw(ctx, "deployer", "confirm", nil)
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, resultText(t, res), "inside a SLOP script")
+}
+
+func TestElicitation_RefusedWithTwoSessionsInFlight(t *testing.T) {
+	r := New()
+	srv := newElicitServer()
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	addBlockingTool(srv, started, release)
+	installInMemorySession(t, r, config.MCPConfig{Name: "deployer", Type: "stdio", Command: "x"}, srv)
+
+	refuse := func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+		t.Error("an elicitation that cannot be attributed must not reach any user")
+		return nil, nil
+	}
+	other := newDownstreamSession(t, &mcp.ClientOptions{ElicitationHandler: refuse})
+	asker := newDownstreamSession(t, &mcp.ClientOptions{ElicitationHandler: refuse})
+
+	whileOtherSessionCalls(t, r, "deployer", other, started, release, func() {
+		ctx := WithCaller(context.Background(), &Caller{Session: asker, Interactive: true})
+		res, err := r.ExecuteToolRaw(ctx, "deployer", "confirm", nil)
+		require.NoError(t, err)
+		assert.True(t, res.IsError)
+		assert.Contains(t, resultText(t, res), "more than one client session")
+	})
+
+	// Once the other session's call is done, the prompt reaches its asker.
+	answered := newDownstreamSession(t, &mcp.ClientOptions{
+		ElicitationHandler: func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			return &mcp.ElicitResult{Action: "decline"}, nil
+		},
+	})
+	ctx := WithCaller(context.Background(), &Caller{Session: answered, Interactive: true})
+	res, err := r.ExecuteToolRaw(ctx, "deployer", "confirm", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "decline", resultText(t, res))
+}
+
+func TestElicitation_NoCaller(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "deployer", Type: "stdio", Command: "x"}, newElicitServer())
+
+	res, err := r.ExecuteToolRaw(context.Background(), "deployer", "confirm", nil)
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, resultText(t, res), "no client call is waiting")
+}
+
+func TestElicitation_DownstreamLacksCapability(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "deployer", Type: "stdio", Command: "x"}, newElicitServer())
+	downstream := newDownstreamSession(t, nil)
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream, Interactive: true})
+	res, err := r.ExecuteToolRaw(ctx, "deployer", "confirm", nil)
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, resultText(t, res), "does not support elicitation")
+}
+
+func TestElicitation_Timeout(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "deployer", Type: "stdio", Command: "x", ElicitationTimeout: "50ms"}
+	installInMemorySession(t, r, cfg, newElicitServer())
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		ElicitationHandler: func(ctx context.Context, _ *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			<-ctx.Done() // the user never answers
+			return nil, ctx.Err()
+		},
+	})
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream, Interactive: true})
+	res, err := r.ExecuteToolRaw(ctx, "deployer", "confirm", nil)
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, resultText(t, res), "no answer to elicitation during 'confirm' within 50ms")
+}
+
+func TestElicitation_TimeoutFollowsConfigUpdate(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "deployer", Type: "stdio", Command: "x"}
+	installInMemorySession(t, r, cfg, newElicitServer())
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		ElicitationHandler: func(ctx context.Context, _ *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+
+	cfg.ElicitationTimeout = "50ms"
+	_, err := r.UpdateConfig(context.Background(), cfg)
+	require.NoError(t, err)
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream, Interactive: true})
+	res, err := r.ExecuteToolRaw(ctx, "deployer", "confirm", nil)
+	require.NoError(t, err)
+	assert.Contains(t, resultText(t, res), "within 50ms")
+}
+
+func TestGetElicitationTimeout(t *testing.T) {
+	assert.Equal(t, DefaultElicitationTimeout, GetElicitationTimeout(config.MCPConfig{}))
+	assert.Equal(t, DefaultElicitationTimeout, GetElicitationTimeout(config.MCPConfig{ElicitationTimeout: "bogus"}))
+	assert.Equal(t, 5*time.Minute, GetElicitationTimeout(config.MCPConfig{ElicitationTimeout: "5m"}))
+}
+
+// newProgressServer returns an upstream MCP with a "build" tool that reports
+// progress under whatever token it was given (or "no token"), and a "deploy"
+// tool that blocks until cancelled, reporting the cancellation on cancelled.
+func newProgressServer(started chan<- struct{}, cancelled chan<- struct{}) *mcp.Server {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "ci", Version: "1.0.0"}, nil)
+	srv.AddTool(&mcp.Tool{
+		Name:        "build",
+		InputSchema: map[string]any{"type": "object"},
+	}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		token := req.Params.GetProgressToken()
+		if token == nil {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "no token"}}}, nil
+		}
+		for i := 1; i <= 2; i++ {
+			if err := req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+				ProgressToken: token,
+				Progress:      float64(i),
+				Total:         2,
+				Message:       fmt.Sprintf("step %d", i),
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "built"}}}, nil
+	})
+	srv.AddTool(&mcp.Tool{
+		Name:        "deploy",
+		InputSchema: map[string]any{"type": "object"},
+	}, func(ctx context.Context, _ *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	return srv
+}
+
+func TestProgress_RelayedUnderDownstreamToken(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "ci", Type: "stdio", Command: "x"}, newProgressServer(nil, nil))
+
+	got := make(chan *mcp.ProgressNotificationParams, 4)
+	downstream := newDownstreamSession(t, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			got <- req.Params
+		},
+	})
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream, Interactive: true, ProgressToken: "agent-7"})
+	res, err := r.ExecuteToolRaw(ctx, "ci", "build", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "built", resultText(t, res))
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case p := <-got:
+			assert.Equal(t, "agent-7", p.ProgressToken)
+			assert.Equal(t, float64(i), p.Progress)
+			assert.Equal(t, float64(2), p.Total)
+			assert.Equal(t, fmt.Sprintf("step %d", i), p.Message)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("progress notification %d not relayed", i)
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		r.callersMu.Lock()
+		defer r.callersMu.Unlock()
+		return len(r.progress) == 0
+	}, 3*progressRouteGrace, 50*time.Millisecond, "progress route must be released after the call returns")
+}
+
+func TestProgress_NoTokenRequested(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "ci", Type: "stdio", Command: "x"}, newProgressServer(nil, nil))
+	downstream := newDownstreamSession(t, nil)
+
+	ctx := WithCaller(context.Background(), &Caller{Session: downstream, Interactive: true})
+	res, err := r.ExecuteToolRaw(ctx, "ci", "build", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "no token", resultText(t, res))
+}
+
+func TestCancellation_PropagatedUpstream(t *testing.T) {
+	r := New()
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	installInMemorySession(t, r, config.MCPConfig{Name: "ci", Type: "stdio", Command: "x"}, newProgressServer(started, cancelled))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := r.ExecuteToolRaw(ctx, "ci", "deploy", nil)
+		errCh <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream tool never started")
+	}
+	cancel()
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Contains(t, err.Error(), "notified to cancel")
+	case <-time.After(5 * time.Second):
+		t.Fatal("call did not return after cancellation")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream handler was not cancelled")
+	}
+	assert.Equal(t, StateConnected, r.GetState("ci"), "cancellation must not demote the MCP")
+}
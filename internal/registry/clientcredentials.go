@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/auth"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
+)
+
+// NewClientCredentialsFlow returns the client_credentials flow of an MCP
+// with an oauth block, saving to the user's token store. The client secret
+// reference is resolved here. Token requests use the MCP's tls and proxy
+// settings, but never its unix socket or the websocket HTTP/1.1 transport.
+func NewClientCredentialsFlow(cfg config.MCPConfig) (*auth.ClientCredentialsFlow, error) {
+	oc := cfg.OAuth
+	if oc == nil {
+		return nil, fmt.Errorf("MCP %s has no oauth block", cfg.Name)
+	}
+	secret, err := secrets.Expand(oc.ClientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("MCP %s oauth client_secret %w", cfg.Name, err)
+	}
+	tokenCfg := cfg
+	tokenCfg.Type = "http"
+	rt, err := baseTransport(tokenCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.ClientCredentialsFlow{
+		ServerName:    cfg.Name,
+		ServerURL:     cfg.URL,
+		TokenEndpoint: oc.TokenEndpoint,
+		ClientID:      oc.ClientID,
+		ClientSecret:  secret,
+		Scopes:        oc.Scopes,
+		HTTPClient:    &http.Client{Transport: rt, Timeout: 30 * time.Second},
+		Store:         auth.NewTokenStore(),
+	}, nil
+}
+
+// clientCredentialsToken returns the stored token of a client_credentials
+// MCP, running the grant again when the token is missing, expired, or was
+// issued for other oauth settings. Like getValidToken, it holds the token
+// file lock across the re-check and the grant, so concurrent requests and
+// processes share one new token.
+func (r *Registry) clientCredentialsToken(ctx context.Context, flow *auth.ClientCredentialsFlow) (*auth.MCPToken, error) {
+	usable := func() *auth.MCPToken {
+		if flow.Store == nil {
+			return nil
+		}
+		token, err := flow.Store.GetToken(flow.ServerName)
+		if err != nil || !flow.Matches(token) || token.IsExpired() {
+			return nil
+		}
+		return token
+	}
+	if token := usable(); token != nil {
+		return token, nil
+	}
+
+	if flow.Store != nil {
+		if unlock, lerr := flow.Store.Lock(); lerr == nil {
+			defer func() { _ = unlock() }()
+			if token := usable(); token != nil {
+				return token, nil
+			}
+		} else {
+			r.logger.Debug("token file lock unavailable, fetching token without cross-process lock", "mcp_name", flow.ServerName, "error", lerr)
+		}
+	}
+
+	grantCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	result, err := flow.Authenticate(grantCtx)
+	if err != nil {
+		return nil, fmt.Errorf("MCP %s oauth: %w", flow.ServerName, err)
+	}
+	return result.Token, nil
+}
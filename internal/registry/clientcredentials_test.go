@@ -0,0 +1,95 @@
+//go:build mcp_go_client_oauth
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/auth"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClientCredentialsServers starts an authorization server that grants
+// "token-N" to client ci with secret s3cret, and an MCP that accepts only
+// the latest such token.
+func newClientCredentialsServers(t *testing.T) (tokenURL, mcpURL string, grants *atomic.Int32) {
+	t.Helper()
+	grants = &atomic.Int32{}
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "ci" || secret != "s3cret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		n := grants.Add(1)
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	t.Cleanup(tokens.Close)
+
+	srv := mcp.NewServer(&mcp.Implementation{Name: "api", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", grants.Load()) {
+			http.Error(w, "invalid_token", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(api.Close)
+	return tokens.URL, api.URL, grants
+}
+
+func TestClientCredentials_GrantsStoresAndRenewsToken(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("SLOP_TEST_CLIENT_SECRET", "s3cret")
+	tokenURL, mcpURL, grants := newClientCredentialsServers(t)
+
+	r := New()
+	t.Cleanup(func() { _ = r.Close() })
+	cfg := config.MCPConfig{
+		Name: "api", Type: "streamable", URL: mcpURL,
+		OAuth: &config.OAuthConfig{ClientID: "ci", ClientSecret: "${env:SLOP_TEST_CLIENT_SECRET}", TokenEndpoint: tokenURL},
+	}
+	require.NoError(t, r.Connect(context.Background(), cfg))
+	assert.Equal(t, int32(1), grants.Load(), "one grant serves every request of the connect")
+
+	store := auth.NewTokenStore()
+	token, err := store.GetToken("api")
+	require.NoError(t, err)
+	require.NotNil(t, token)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, auth.GrantClientCredentials, token.GrantType)
+
+	// An expired token is replaced by a new grant on the next request.
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, store.SetToken(token))
+	result, err := r.ExecuteToolRawJSON(context.Background(), "api", "hello", nil)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, int32(2), grants.Load())
+}
+
+func TestClientCredentials_GrantErrorFailsConnect(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	tokenURL, mcpURL, grants := newClientCredentialsServers(t)
+
+	r := New()
+	t.Cleanup(func() { _ = r.Close() })
+	err := r.Connect(context.Background(), config.MCPConfig{
+		Name: "api", Type: "streamable", URL: mcpURL, Timeout: "5s",
+		OAuth: &config.OAuthConfig{ClientID: "ci", ClientSecret: "wrong", TokenEndpoint: tokenURL},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_client")
+	assert.Zero(t, grants.Load())
+}
@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// IdleTimeoutEnvVar is the environment variable name for the default idle
+// timeout of stdio MCPs.
+const IdleTimeoutEnvVar = "SLOP_MCP_IDLE_TIMEOUT"
+
+// IdleCheckInterval is how often the idle reaper looks for idle MCPs.
+const IdleCheckInterval = 15 * time.Second
+
+// GetIdleTimeout returns how long a stdio MCP may sit unused before its
+// subprocess is shut down; 0 means never. Priority: per-MCP idle_timeout
+// (where "0" disables) > SLOP_MCP_IDLE_TIMEOUT > 0. Network MCPs hold no
+// local process and are never reaped.
+func GetIdleTimeout(cfg config.MCPConfig) time.Duration {
+	switch cfg.Type {
+	case "command", "stdio", "":
+	default:
+		return 0
+	}
+	if cfg.IdleTimeout != "" {
+		if d, err := time.ParseDuration(cfg.IdleTimeout); err == nil && d >= 0 {
+			return d
+		}
+	}
+	if env := os.Getenv(IdleTimeoutEnvVar); env != "" {
+		if d, err := time.ParseDuration(env); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// useConnection returns mcpName's live connection and marks it in use until
+// the returned release is called, so the idle reaper leaves it alone. Looking
+// up and marking under one lock keeps the reaper from closing the session
+// between the two. The count lives outside mcpState because states are
+// replaced by copies (a list_changed refresh, a config update) while calls
+// are in flight, and release must not decrement a superseded copy.
+func (r *Registry) useConnection(mcpName string) (*mcpConnection, func(), bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn, ok := r.connections[mcpName]
+	st := r.states[mcpName]
+	if !ok || st == nil {
+		return conn, func() {}, ok
+	}
+	if r.inflight == nil {
+		r.inflight = make(map[string]int)
+	}
+	r.inflight[mcpName]++
+	st.lastUsed = time.Now()
+	return conn, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.inflight[mcpName]--; r.inflight[mcpName] <= 0 {
+			delete(r.inflight, mcpName)
+		}
+		if cur, ok := r.states[mcpName]; ok {
+			cur.lastUsed = time.Now()
+		}
+	}, true
+}
+
+// StartIdleReaper starts a background goroutine that returns idle stdio MCPs
+// to the cached state (see GetIdleTimeout). Calling it again restarts it.
+func (r *Registry) StartIdleReaper() {
+	r.StopIdleReaper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	r.idleReaperCancel = cancel
+	r.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(IdleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				r.reapIdle(now)
+			}
+		}
+	}()
+}
+
+// StopIdleReaper stops the idle reaper goroutine, if running.
+func (r *Registry) StopIdleReaper() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.idleReaperCancel != nil {
+		r.idleReaperCancel()
+		r.idleReaperCancel = nil
+	}
+}
+
+// idleLocked reports whether the MCP name, in state st, has been unused past
+// its idle timeout as of now. Only connected MCPs whose tools are indexed
+// qualify: the cached state they return to serves search from that tool list.
+// The caller must hold r.mu.
+func (r *Registry) idleLocked(name string, st *mcpState, now time.Time) bool {
+	if st.state != StateConnected || !st.toolsIndexed || r.inflight[name] > 0 {
+		return false
+	}
+	timeout := GetIdleTimeout(st.config)
+	return timeout > 0 && now.Sub(st.lastUsed) >= timeout
+}
+
+// reapIdle disconnects every MCP idle as of now and moves it back to
+// StateCached, keeping its tools indexed; the next call reconnects it through
+// EnsureConnected. Returns the names of the MCPs it disconnected.
+func (r *Registry) reapIdle(now time.Time) []string {
+	r.mu.RLock()
+	var candidates []string
+	for name, st := range r.states {
+		if r.idleLocked(name, st, now) {
+			candidates = append(candidates, name)
+		}
+	}
+	r.mu.RUnlock()
+
+	var reaped []string
+	for _, name := range candidates {
+		// Skip MCPs with a lifecycle operation in flight rather than wait.
+		sem := r.getConnectMu(name)
+		select {
+		case sem <- struct{}{}:
+		default:
+			continue
+		}
+
+		r.mu.Lock()
+		st, ok := r.states[name]
+		conn := r.connections[name]
+		if !ok || conn == nil || !r.idleLocked(name, st, now) {
+			r.mu.Unlock()
+			<-sem
+			continue
+		}
+		r.states[name] = &mcpState{
+			config: st.config,
+			state:  StateCached,
+		}
+		delete(r.connections, name)
+		r.mu.Unlock()
+
+		if err := conn.close(); err != nil {
+			r.logger.Debug("error closing idle MCP session", "mcp_name", name, "error", err)
+		}
+		<-sem
+		r.logger.Info("disconnected idle MCP", "mcp_name", name, "idle_timeout", GetIdleTimeout(st.config))
+		reaped = append(reaped, name)
+	}
+	return reaped
+}
@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIdleTimeout(t *testing.T) {
+	t.Setenv(IdleTimeoutEnvVar, "")
+	assert.Zero(t, GetIdleTimeout(config.MCPConfig{Type: "stdio"}), "disabled by default")
+	assert.Equal(t, 10*time.Minute, GetIdleTimeout(config.MCPConfig{Type: "stdio", IdleTimeout: "10m"}))
+
+	t.Setenv(IdleTimeoutEnvVar, "5m")
+	assert.Equal(t, 5*time.Minute, GetIdleTimeout(config.MCPConfig{}))
+	assert.Equal(t, 10*time.Minute, GetIdleTimeout(config.MCPConfig{IdleTimeout: "10m"}), "per-MCP wins")
+	assert.Zero(t, GetIdleTimeout(config.MCPConfig{IdleTimeout: "0"}), "per-MCP 0 opts out")
+	assert.Equal(t, 5*time.Minute, GetIdleTimeout(config.MCPConfig{IdleTimeout: "bogus"}))
+	assert.Zero(t, GetIdleTimeout(config.MCPConfig{Type: "streamable", IdleTimeout: "10m"}), "network MCPs are never reaped")
+}
+
+func TestReapIdle_ReturnsIdleMCPToCached(t *testing.T) {
+	t.Setenv(IdleTimeoutEnvVar, "")
+	r := New()
+	cs := installInMemorySession(t, r, config.MCPConfig{Name: "ci", Type: "stdio", Command: "x", IdleTimeout: "1m"}, newProgressServer(nil, nil))
+
+	_, err := r.ExecuteToolRaw(context.Background(), "ci", "build", nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, r.reapIdle(time.Now()), "recently used")
+	assert.Equal(t, []string{"ci"}, r.reapIdle(time.Now().Add(2*time.Minute)))
+
+	assert.Equal(t, StateCached, r.GetState("ci"))
+	assert.True(t, r.HasTool("ci", "build"), "tools stay searchable while cached")
+	assert.Error(t, cs.Ping(context.Background(), nil), "the session is closed")
+	assert.Empty(t, r.reapIdle(time.Now().Add(time.Hour)), "cached MCPs are not reaped again")
+}
+
+func TestReapIdle_SkipsMCPsInUse(t *testing.T) {
+	t.Setenv(IdleTimeoutEnvVar, "")
+	r := New()
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	installInMemorySession(t, r, config.MCPConfig{Name: "ci", Type: "stdio", Command: "x", IdleTimeout: "1m"}, newProgressServer(started, cancelled))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.ExecuteToolRaw(ctx, "ci", "deploy", nil)
+		done <- err
+	}()
+	<-started
+
+	assert.Empty(t, r.reapIdle(time.Now().Add(time.Hour)), "a call is in flight")
+	assert.Equal(t, StateConnected, r.GetState("ci"))
+
+	cancel()
+	<-done
+	<-cancelled
+	assert.Equal(t, []string{"ci"}, r.reapIdle(time.Now().Add(time.Hour)))
+}
+
+func TestReapIdle_CallSpanningToolRefresh(t *testing.T) {
+	t.Setenv(IdleTimeoutEnvVar, "")
+	r := New()
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	cs := installInMemorySession(t, r, config.MCPConfig{Name: "ci", Type: "stdio", Command: "x", IdleTimeout: "1m"}, newProgressServer(started, cancelled))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.ExecuteToolRaw(ctx, "ci", "deploy", nil)
+		done <- err
+	}()
+	<-started
+
+	// A refresh that indexes the tools replaces the state with a copy.
+	r.mu.Lock()
+	before := r.states["ci"]
+	before.toolsIndexed = false
+	r.mu.Unlock()
+	r.refreshTools("ci", cs)
+	r.mu.RLock()
+	require.NotSame(t, before, r.states["ci"])
+	r.mu.RUnlock()
+	assert.Empty(t, r.reapIdle(time.Now().Add(time.Hour)), "the call is still in flight")
+
+	cancel()
+	<-done
+	<-cancelled
+	assert.Equal(t, []string{"ci"}, r.reapIdle(time.Now().Add(time.Hour)), "the finished call no longer pins the MCP")
+}
+
+func TestReapIdle_NoTimeout(t *testing.T) {
+	t.Setenv(IdleTimeoutEnvVar, "")
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "ci", Type: "stdio", Command: "x"}, mcp.NewServer(&mcp.Implementation{Name: "ci", Version: "1.0.0"}, nil))
+	assert.Empty(t, r.reapIdle(time.Now().Add(24*time.Hour)))
+	assert.Equal(t, StateConnected, r.GetState("ci"))
+}
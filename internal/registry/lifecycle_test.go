@@ -306,3 +306,34 @@ func TestEnsureConnected_CancelableWhileWaiting(t *testing.T) {
 		t.Fatal("EnsureConnected did not honor context cancellation")
 	}
 }
+
+func TestUpdateConfig_AppliesWithoutReconnect(t *testing.T) {
+	r, _ := newTestRegistryWithCache(t)
+	cfg := config.MCPConfig{Name: "m", Type: "stdio", Command: "m"}
+	installFakeConnection(r, cfg, true)
+	r.AddToolsForTesting("m", []ToolInfo{{Name: "read", MCPName: "m"}, {Name: "delete", MCPName: "m"}})
+	require.Len(t, r.SearchTools("", "m"), 2)
+
+	r.mu.RLock()
+	oldState, oldConn := r.states["m"], r.connections["m"]
+	r.mu.RUnlock()
+
+	cfg.Deny = []string{"delete"}
+	cfg.Timeout = "5s"
+	ok, err := r.UpdateConfig(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, oldState.config.Timeout, "the old state is replaced, not mutated")
+	assert.Empty(t, oldConn.config.Timeout, "the old connection is replaced, not mutated")
+
+	assert.Equal(t, StateConnected, r.GetState("m"), "stays connected")
+	tools := r.SearchTools("", "m")
+	require.Len(t, tools, 1, "the new deny list hides the tool")
+	assert.Equal(t, "read", tools[0].Name)
+	require.Len(t, r.GetConfigs(), 1)
+	assert.Equal(t, "5s", r.GetConfigs()[0].Timeout, "the connection config is replaced too")
+
+	ok, err = r.UpdateConfig(context.Background(), config.MCPConfig{Name: "missing"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,200 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// DefaultQueueTimeout is how long a call waits for a free slot on an MCP
+// with max_concurrent set, unless its queue_timeout says otherwise.
+const DefaultQueueTimeout = 30 * time.Second
+
+// callLimiter caps the calls in flight to one MCP. Calls over the cap wait
+// in FIFO order; a finishing call hands its slot straight to the oldest
+// waiter, so a steady stream of new calls cannot starve queued ones.
+type callLimiter struct {
+	max     int
+	active  int
+	waiters []*queuedCall
+
+	// Lifetime stats for status output.
+	queued    int           // calls that had to wait
+	granted   int           // queued calls that got a slot
+	timeouts  int           // calls refused a slot or that gave up waiting
+	waitTotal time.Duration // summed wait of granted calls
+	waitMax   time.Duration
+}
+
+// queuedCall is one waiter; ready is closed when it is granted a slot or
+// its MCP is unregistered (dropped).
+type queuedCall struct {
+	ready    chan struct{}
+	enqueued time.Time
+	granted  bool
+	dropped  bool
+}
+
+// QueueTimeoutError is returned when a call gave up waiting for one of an
+// MCP's max_concurrent slots.
+type QueueTimeoutError struct {
+	MCPName       string
+	MaxConcurrent int
+	Waited        time.Duration
+	QueueDepth    int // calls still waiting behind this one
+}
+
+func (e *QueueTimeoutError) Error() string {
+	if e.Waited == 0 {
+		return fmt.Sprintf("MCP %s is busy: all %d call slots are in use and queue_timeout is 0", e.MCPName, e.MaxConcurrent)
+	}
+	return fmt.Sprintf("MCP %s is busy: no call slot freed within %s (max_concurrent %d, %d calls queued)",
+		e.MCPName, e.Waited.Round(time.Millisecond), e.MaxConcurrent, e.QueueDepth)
+}
+
+// GetMaxConcurrent returns cfg's call cap; 0 means unlimited.
+func GetMaxConcurrent(cfg config.MCPConfig) int {
+	if cfg.MaxConcurrent > 0 {
+		return cfg.MaxConcurrent
+	}
+	return 0
+}
+
+// GetQueueTimeout returns how long a call may wait for a slot on cfg's MCP.
+// "0" means calls over the cap fail at once instead of queueing.
+func GetQueueTimeout(cfg config.MCPConfig) time.Duration {
+	if cfg.QueueTimeout != "" {
+		if d, err := time.ParseDuration(cfg.QueueTimeout); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return DefaultQueueTimeout
+}
+
+// limiterLocked returns mcpName's limiter, creating it on first use. The
+// caller must hold r.mu for writing.
+func (r *Registry) limiterLocked(mcpName string) *callLimiter {
+	if r.limiters == nil {
+		r.limiters = make(map[string]*callLimiter)
+	}
+	l, ok := r.limiters[mcpName]
+	if !ok {
+		l = &callLimiter{}
+		r.limiters[mcpName] = l
+	}
+	return l
+}
+
+// acquireSlot blocks until mcpName has a free call slot, the MCP's
+// queue_timeout passes, or ctx ends. The returned release must be called
+// once the call finishes. MCPs without max_concurrent are never limited.
+func (r *Registry) acquireSlot(ctx context.Context, mcpName string) (func(), error) {
+	r.mu.Lock()
+	var cfg config.MCPConfig
+	if st, ok := r.states[mcpName]; ok {
+		cfg = st.config
+	}
+	limit := GetMaxConcurrent(cfg)
+	if limit == 0 {
+		r.mu.Unlock()
+		return func() {}, nil
+	}
+	l := r.limiterLocked(mcpName)
+	l.max = limit
+	release := func() {
+		r.mu.Lock()
+		l.releaseLocked()
+		r.mu.Unlock()
+	}
+	if l.active < l.max && len(l.waiters) == 0 {
+		l.active++
+		r.mu.Unlock()
+		return release, nil
+	}
+
+	timeout := GetQueueTimeout(cfg)
+	if timeout == 0 {
+		l.timeouts++
+		r.mu.Unlock()
+		return nil, &QueueTimeoutError{MCPName: mcpName, MaxConcurrent: limit}
+	}
+	w := &queuedCall{ready: make(chan struct{}), enqueued: time.Now()}
+	l.waiters = append(l.waiters, w)
+	l.queued++
+	r.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-w.ready:
+		if w.dropped {
+			return nil, fmt.Errorf("MCP %s was unregistered while the call waited for a slot", mcpName)
+		}
+		return release, nil
+	case <-ctx.Done():
+		err = fmt.Errorf("waiting for a call slot on MCP %s: %w", mcpName, ctx.Err())
+	case <-timer.C:
+		err = &QueueTimeoutError{MCPName: mcpName, MaxConcurrent: limit, Waited: timeout}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w.granted {
+		// Granted while giving up: pass the slot on rather than leak it.
+		l.releaseLocked()
+		return nil, err
+	}
+	for i, q := range l.waiters {
+		if q == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			break
+		}
+	}
+	l.timeouts++
+	if qe, ok := err.(*QueueTimeoutError); ok {
+		qe.QueueDepth = len(l.waiters)
+	}
+	return nil, err
+}
+
+// releaseLocked frees a slot, handing it to the oldest waiter if any. The
+// caller must hold r.mu.
+func (l *callLimiter) releaseLocked() {
+	if len(l.waiters) == 0 {
+		l.active--
+		return
+	}
+	w := l.waiters[0]
+	l.waiters = l.waiters[1:]
+	w.granted = true
+	l.granted++
+	wait := time.Since(w.enqueued)
+	l.waitTotal += wait
+	if wait > l.waitMax {
+		l.waitMax = wait
+	}
+	close(w.ready)
+}
+
+// dropLimiterLocked forgets mcpName's limiter, failing its waiters so they
+// stop at once on the missing MCP instead of sitting out their queue_timeout.
+// A limiter with calls still in flight is kept: an MCP registered again under
+// the name (a reload reconnect) shares it, so those calls keep counting
+// against max_concurrent until they release. The caller must hold r.mu.
+func (r *Registry) dropLimiterLocked(mcpName string) {
+	l, ok := r.limiters[mcpName]
+	if !ok {
+		return
+	}
+	for _, w := range l.waiters {
+		w.dropped = true
+		close(w.ready)
+	}
+	l.waiters = nil
+	if l.active == 0 {
+		delete(r.limiters, mcpName)
+	}
+}
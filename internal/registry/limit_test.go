@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gateServer serves a "hold" tool that records its "id" argument on entry
+// and blocks until gate is closed or yields a value.
+func gateServer(entered chan<- string, gate <-chan struct{}) *mcp.Server {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "fragile", Version: "1.0.0"}, nil)
+	mcp.AddTool(srv, &mcp.Tool{Name: "hold"}, func(ctx context.Context, _ *mcp.CallToolRequest, in struct {
+		ID string `json:"id"`
+	}) (*mcp.CallToolResult, any, error) {
+		entered <- in.ID
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: in.ID}}}, nil, nil
+	})
+	return srv
+}
+
+// waitQueueDepth waits until n calls are queued on mcpName.
+func waitQueueDepth(t *testing.T, r *Registry, mcpName string, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		for _, st := range r.Status() {
+			if st.Name == mcpName {
+				return st.QueueDepth == n
+			}
+		}
+		return false
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestConcurrencyLimit_QueuesInOrder(t *testing.T) {
+	r := New()
+	entered := make(chan string, 8)
+	gate := make(chan struct{}, 8)
+	cfg := config.MCPConfig{Name: "fragile", Type: "stdio", Command: "x", MaxConcurrent: 1}
+	installInMemorySession(t, r, cfg, gateServer(entered, gate))
+
+	var wg sync.WaitGroup
+	call := func(id string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": id})
+			assert.NoError(t, err)
+		}()
+	}
+	call("a")
+	assert.Equal(t, "a", <-entered)
+	call("b")
+	waitQueueDepth(t, r, "fragile", 1)
+	call("c")
+	waitQueueDepth(t, r, "fragile", 2)
+
+	status := r.Status()[0]
+	assert.Equal(t, 1, status.MaxConcurrent)
+	assert.Equal(t, 1, status.ActiveCalls)
+
+	for _, want := range []string{"b", "c"} {
+		gate <- struct{}{}
+		assert.Equal(t, want, <-entered, "queued calls run oldest first")
+	}
+	gate <- struct{}{}
+	wg.Wait()
+
+	status = r.Status()[0]
+	assert.Zero(t, status.ActiveCalls)
+	assert.Zero(t, status.QueueDepth)
+	assert.Equal(t, 2, status.QueuedCalls)
+	assert.NotEmpty(t, status.AvgQueueWait)
+	assert.NotEmpty(t, status.MaxQueueWait)
+}
+
+func TestConcurrencyLimit_QueueTimeout(t *testing.T) {
+	r := New()
+	entered := make(chan string, 2)
+	gate := make(chan struct{})
+	cfg := config.MCPConfig{Name: "fragile", Type: "stdio", Command: "x", MaxConcurrent: 1, QueueTimeout: "50ms"}
+	installInMemorySession(t, r, cfg, gateServer(entered, gate))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": "a"})
+	}()
+	<-entered
+
+	_, err := r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": "b"})
+	var qe *QueueTimeoutError
+	require.ErrorAs(t, err, &qe)
+	assert.Equal(t, 50*time.Millisecond, qe.Waited)
+	assert.Contains(t, err.Error(), "no call slot freed within 50ms")
+
+	close(gate)
+	<-done
+	status := r.Status()[0]
+	assert.Equal(t, 1, status.QueueTimeouts)
+	assert.Zero(t, status.ActiveCalls, "a timed-out waiter does not hold a slot")
+
+	_, err = r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": "c"})
+	require.NoError(t, err)
+	assert.Equal(t, "c", <-entered)
+}
+
+func TestConcurrencyLimit_ZeroQueueTimeoutRejects(t *testing.T) {
+	r := New()
+	entered := make(chan string, 1)
+	gate := make(chan struct{})
+	cfg := config.MCPConfig{Name: "fragile", Type: "stdio", Command: "x", MaxConcurrent: 1, QueueTimeout: "0"}
+	installInMemorySession(t, r, cfg, gateServer(entered, gate))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": "a"})
+	}()
+	<-entered
+
+	_, err := r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": "b"})
+	var qe *QueueTimeoutError
+	require.ErrorAs(t, err, &qe)
+	assert.Contains(t, err.Error(), "queue_timeout is 0")
+
+	close(gate)
+	<-done
+}
+
+func TestConcurrencyLimit_CallerCancelLeavesQueue(t *testing.T) {
+	r := New()
+	entered := make(chan string, 2)
+	gate := make(chan struct{})
+	cfg := config.MCPConfig{Name: "fragile", Type: "stdio", Command: "x", MaxConcurrent: 1}
+	installInMemorySession(t, r, cfg, gateServer(entered, gate))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": "a"})
+	}()
+	<-entered
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := r.ExecuteToolRaw(ctx, "fragile", "hold", map[string]any{"id": "b"})
+		errc <- err
+	}()
+	waitQueueDepth(t, r, "fragile", 1)
+	cancel()
+	require.ErrorIs(t, <-errc, context.Canceled)
+	waitQueueDepth(t, r, "fragile", 0)
+
+	close(gate)
+	<-done
+}
+
+func TestConcurrencyLimit_UnregisterFailsWaitersAndKeepsActiveCalls(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{Name: "fragile", Type: "stdio", Command: "x", MaxConcurrent: 1, QueueTimeout: "0"}
+	r.SetConfigured(cfg)
+
+	releaseA, err := r.acquireSlot(context.Background(), "fragile")
+	require.NoError(t, err)
+
+	queued := cfg
+	queued.QueueTimeout = "5s"
+	r.mu.Lock()
+	r.states["fragile"].config = queued
+	r.mu.Unlock()
+	errc := make(chan error, 1)
+	go func() {
+		_, err := r.acquireSlot(context.Background(), "fragile")
+		errc <- err
+	}()
+	waitQueueDepth(t, r, "fragile", 1)
+
+	// A reload reconnect: unregister, then configure the MCP again.
+	require.NoError(t, r.Unregister(context.Background(), "fragile"))
+	err = <-errc
+	require.Error(t, err, "a dropped waiter must not go on to make its call")
+	assert.Contains(t, err.Error(), "unregistered")
+	r.SetConfigured(cfg)
+
+	_, err = r.acquireSlot(context.Background(), "fragile")
+	var qe *QueueTimeoutError
+	require.ErrorAs(t, err, &qe, "the call from before the reload still holds the only slot")
+
+	releaseA()
+	releaseB, err := r.acquireSlot(context.Background(), "fragile")
+	require.NoError(t, err)
+	releaseB()
+}
+
+func TestGetConcurrencySettings(t *testing.T) {
+	assert.Zero(t, GetMaxConcurrent(config.MCPConfig{}))
+	assert.Equal(t, 4, GetMaxConcurrent(config.MCPConfig{MaxConcurrent: 4}))
+	assert.Equal(t, DefaultQueueTimeout, GetQueueTimeout(config.MCPConfig{}))
+	assert.Equal(t, DefaultQueueTimeout, GetQueueTimeout(config.MCPConfig{QueueTimeout: "bogus"}))
+	assert.Equal(t, 10*time.Second, GetQueueTimeout(config.MCPConfig{QueueTimeout: "10s"}))
+	assert.Zero(t, GetQueueTimeout(config.MCPConfig{QueueTimeout: "0"}))
+}
@@ -0,0 +1,302 @@
+package registry
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/cache"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/overrides"
+)
+
+// maxMemoEntries caps how many results the in-memory memo cache holds; the
+// least recently used one is evicted to make room.
+const maxMemoEntries = 1000
+
+// resultCache memoizes the successful results of tools configured with a
+// cache_ttl. Entries are keyed by MCP, tool, and the canonical hash of the
+// arguments, and are dropped once expired, once the MCP's identity
+// (cache.ConfigHash) changes, or once evicted to stay within limit.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]*cache.CachedResult
+	persist map[string]bool          // keys of entries saved to store
+	hits    map[string]int           // per-entry hit counts, for inspection
+	used    map[string]*list.Element // each key's place in order
+	order   *list.List               // keys, most recently used first
+	limit   int                      // max entries held
+	store   *cache.ResultStore       // holds cache_persist entries across restarts
+}
+
+// CachedResultInfo describes one memoized result for manage_mcps cache_inspect.
+type CachedResultInfo struct {
+	MCPName   string `json:"mcp_name"`
+	ToolName  string `json:"tool_name"`
+	ArgsHash  string `json:"args_hash"`
+	ExpiresAt string `json:"expires_at"` // RFC3339
+	Hits      int    `json:"hits"`
+	Bytes     int    `json:"bytes"`
+	Persisted bool   `json:"persisted,omitempty"`
+}
+
+// GetCacheTTL returns how long results of toolName on cfg's MCP are
+// memoized (0 = not at all) and whether they are persisted to disk.
+func GetCacheTTL(cfg config.MCPConfig, toolName string) (time.Duration, bool) {
+	t, ok := cfg.ToolSettings(toolName)
+	if !ok || t.CacheTTL == "" {
+		return 0, false
+	}
+	d, err := time.ParseDuration(t.CacheTTL)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, t.CachePersist
+}
+
+// memoKey keys a call by MCP, tool, and canonical argument hash. args is
+// whatever callRaw received: a map, verbatim JSON, or nil.
+func memoKey(mcpName, toolName string, args any) (string, string, bool) {
+	var v any = map[string]any{}
+	switch a := args.(type) {
+	case nil:
+	case json.RawMessage:
+		if t := bytes.TrimSpace(a); len(t) > 0 && string(t) != "null" {
+			dec := json.NewDecoder(bytes.NewReader(t))
+			dec.UseNumber()
+			if err := dec.Decode(&v); err != nil {
+				return "", "", false
+			}
+		}
+	case map[string]any:
+		if len(a) > 0 {
+			v = a
+		}
+	default:
+		v = a
+	}
+	hash, err := overrides.CanonicalHash(v)
+	if err != nil {
+		return "", "", false
+	}
+	return mcpName + "\x00" + toolName + "\x00" + hash, hash, true
+}
+
+// memo returns the result cache, loading persisted entries on first use.
+func (r *Registry) memo() *resultCache {
+	r.mu.RLock()
+	rc, store := r.results, r.cache
+	r.mu.RUnlock()
+	if rc != nil {
+		return rc
+	}
+
+	rc = newResultCache(maxMemoEntries)
+	if store != nil {
+		rc.store = store.Results()
+		loaded, err := rc.store.Load()
+		if err != nil {
+			r.logger.Debug("failed to load result cache", "error", err)
+		}
+		// Load the latest-expiring entries last, so they are the ones
+		// kept when the file holds more than the limit.
+		keys := make([]string, 0, len(loaded))
+		for key := range loaded {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return loaded[keys[i]].ExpiresAt.Before(loaded[keys[j]].ExpiresAt) })
+		for _, key := range keys {
+			rc.entries[key] = loaded[key]
+			rc.persist[key] = true
+			rc.touchLocked(key)
+			rc.evictLocked()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results == nil {
+		r.results = rc
+	}
+	return r.results
+}
+
+func newResultCache(limit int) *resultCache {
+	return &resultCache{
+		entries: make(map[string]*cache.CachedResult),
+		persist: make(map[string]bool),
+		hits:    make(map[string]int),
+		used:    make(map[string]*list.Element),
+		order:   list.New(),
+		limit:   limit,
+	}
+}
+
+// get returns a copy of the live result stored under key, if any.
+func (c *resultCache) get(key, configHash string) (*mcp.CallToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(e.ExpiresAt) || e.ConfigHash != configHash {
+		c.deleteLocked(key)
+		return nil, false
+	}
+	var res mcp.CallToolResult
+	if err := json.Unmarshal(e.Result, &res); err != nil {
+		c.deleteLocked(key)
+		return nil, false
+	}
+	c.hits[key]++
+	c.touchLocked(key)
+	return &res, true
+}
+
+// put stores result as entry's payload under key, saving it to disk when
+// persist is set.
+func (c *resultCache) put(key string, entry *cache.CachedResult, result *mcp.CallToolResult, persist bool) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	entry.Result = data
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	c.hits[key] = 0
+	c.touchLocked(key)
+	wasPersisted := c.persist[key]
+	if persist {
+		c.persist[key] = true
+	} else {
+		delete(c.persist, key)
+	}
+	evicted := c.evictLocked()
+	if c.store == nil || !(persist || wasPersisted || len(evicted) > 0) {
+		return nil
+	}
+	return c.store.Update(func(saved map[string]*cache.CachedResult) {
+		if persist {
+			saved[key] = entry
+		} else {
+			delete(saved, key)
+		}
+		for _, k := range evicted {
+			delete(saved, k)
+		}
+	})
+}
+
+// touchLocked marks key as the most recently used. The caller must hold c.mu.
+func (c *resultCache) touchLocked(key string) {
+	if el, ok := c.used[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.used[key] = c.order.PushFront(key)
+}
+
+// evictLocked drops least recently used entries until the cache is within
+// its limit, and returns the persisted ones among them so the caller can
+// drop them from disk too. The caller must hold c.mu.
+func (c *resultCache) evictLocked() []string {
+	var persisted []string
+	for len(c.entries) > c.limit {
+		key := c.order.Back().Value.(string)
+		if c.persist[key] {
+			persisted = append(persisted, key)
+		}
+		c.deleteLocked(key)
+	}
+	return persisted
+}
+
+// deleteLocked drops key. The caller must hold c.mu and, for a persisted
+// entry, update the store afterwards; expired persisted entries are skipped
+// on load anyway, so the lookup paths leave the file alone.
+func (c *resultCache) deleteLocked(key string) {
+	delete(c.entries, key)
+	delete(c.persist, key)
+	delete(c.hits, key)
+	if el, ok := c.used[key]; ok {
+		c.order.Remove(el)
+		delete(c.used, key)
+	}
+}
+
+// CachedResults lists the live memoized results, optionally only mcpName's.
+func (r *Registry) CachedResults(mcpName string) []CachedResultInfo {
+	c := r.memo()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	var out []CachedResultInfo
+	for key, e := range c.entries {
+		if !now.Before(e.ExpiresAt) {
+			c.deleteLocked(key)
+			continue
+		}
+		if mcpName != "" && e.MCPName != mcpName {
+			continue
+		}
+		// Persisted entries may come from a hand-edited results.json.
+		argsHash := e.ArgsHash
+		if len(argsHash) > 16 {
+			argsHash = argsHash[:16]
+		}
+		out = append(out, CachedResultInfo{
+			MCPName:   e.MCPName,
+			ToolName:  e.ToolName,
+			ArgsHash:  argsHash,
+			ExpiresAt: e.ExpiresAt.Format(time.RFC3339),
+			Hits:      c.hits[key],
+			Bytes:     len(e.Result),
+			Persisted: c.persist[key],
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].MCPName != out[j].MCPName {
+			return out[i].MCPName < out[j].MCPName
+		}
+		if out[i].ToolName != out[j].ToolName {
+			return out[i].ToolName < out[j].ToolName
+		}
+		return out[i].ArgsHash < out[j].ArgsHash
+	})
+	return out
+}
+
+// FlushResults drops memoized results, all of them or only mcpName's (and
+// only toolName's, when given), and returns how many were dropped.
+func (r *Registry) FlushResults(mcpName, toolName string) (int, error) {
+	c := r.memo()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	matches := func(e *cache.CachedResult) bool {
+		return (mcpName == "" || e.MCPName == mcpName) && (toolName == "" || e.ToolName == toolName)
+	}
+	n := 0
+	for key, e := range c.entries {
+		if matches(e) {
+			c.deleteLocked(key)
+			n++
+		}
+	}
+	if c.store == nil {
+		return n, nil
+	}
+	// Flush from disk too, including results other processes saved.
+	return n, c.store.Update(func(saved map[string]*cache.CachedResult) {
+		for key, e := range saved {
+			if matches(e) {
+				delete(saved, key)
+			}
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/cache"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServer serves list_repos, which answers with how many times it has
+// been called, and fail, which always returns an error result.
+func countingServer(calls *atomic.Int32) *mcp.Server {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "gh", Version: "1.0.0"}, nil)
+	srv.AddTool(&mcp.Tool{Name: "list_repos", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			n := calls.Add(1)
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("call %d", n)}}}, nil
+		})
+	srv.AddTool(&mcp.Tool{Name: "fail", InputSchema: map[string]any{"type": "object"}},
+		func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls.Add(1)
+			return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "nope"}}}, nil
+		})
+	return srv
+}
+
+func memoConfig(persist bool) config.MCPConfig {
+	return config.MCPConfig{
+		Name: "gh", Type: "stdio", Command: "gh-mcp",
+		Tools: []config.ToolConfig{{Pattern: "*", CacheTTL: "1m", CachePersist: persist}},
+	}
+}
+
+func text(t *testing.T, res *mcp.CallToolResult) string {
+	t.Helper()
+	require.NotEmpty(t, res.Content)
+	return res.Content[0].(*mcp.TextContent).Text
+}
+
+func TestMemo_ReusesResultsByCanonicalArgs(t *testing.T) {
+	r := NewWithCache(logging.Nop(), cache.NewStoreWithPath(filepath.Join(t.TempDir(), "tools.json")))
+	var calls atomic.Int32
+	installInMemorySession(t, r, memoConfig(false), countingServer(&calls))
+	ctx := context.Background()
+
+	res, err := r.ExecuteToolRawJSON(ctx, "gh", "list_repos", json.RawMessage(`{"org":"acme","page":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, "call 1", text(t, res))
+
+	res, err = r.ExecuteToolRaw(ctx, "gh", "list_repos", map[string]any{"page": 1, "org": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "call 1", text(t, res), "same arguments in another key order hit the cache")
+
+	res, err = r.ExecuteToolRaw(ctx, "gh", "list_repos", map[string]any{"org": "other"})
+	require.NoError(t, err)
+	assert.Equal(t, "call 2", text(t, res))
+
+	_, err = r.ExecuteToolRaw(ctx, "gh", "fail", nil)
+	require.NoError(t, err)
+	_, err = r.ExecuteToolRaw(ctx, "gh", "fail", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), calls.Load(), "error results are not memoized")
+
+	entries := r.CachedResults("gh")
+	require.Len(t, entries, 2)
+	hits := entries[0].Hits + entries[1].Hits
+	assert.Equal(t, 1, hits)
+	assert.Empty(t, r.CachedResults("other-mcp"))
+
+	n, err := r.FlushResults("gh", "list_repos")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	res, err = r.ExecuteToolRaw(ctx, "gh", "list_repos", map[string]any{"org": "other"})
+	require.NoError(t, err)
+	assert.Equal(t, "call 5", text(t, res), "a flushed result is fetched again")
+}
+
+func TestMemo_ExpiresAndTracksConfig(t *testing.T) {
+	r := NewWithCache(logging.Nop(), cache.NewStoreWithPath(filepath.Join(t.TempDir(), "tools.json")))
+	var calls atomic.Int32
+	installInMemorySession(t, r, memoConfig(false), countingServer(&calls))
+	ctx := context.Background()
+
+	_, err := r.ExecuteToolRaw(ctx, "gh", "list_repos", nil)
+	require.NoError(t, err)
+	c := r.memo()
+	c.mu.Lock()
+	for _, e := range c.entries {
+		e.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	c.mu.Unlock()
+	res, err := r.ExecuteToolRaw(ctx, "gh", "list_repos", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "call 2", text(t, res), "an expired result is not served")
+
+	c.mu.Lock()
+	for _, e := range c.entries {
+		e.ConfigHash = "stale"
+	}
+	c.mu.Unlock()
+	res, err = r.ExecuteToolRaw(ctx, "gh", "list_repos", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "call 3", text(t, res), "a result from another MCP config is not served")
+}
+
+func TestMemo_Persisted(t *testing.T) {
+	store := cache.NewStoreWithPath(filepath.Join(t.TempDir(), "tools.json"))
+	r := NewWithCache(logging.Nop(), store)
+	var calls atomic.Int32
+	installInMemorySession(t, r, memoConfig(true), countingServer(&calls))
+	_, err := r.ExecuteToolRaw(context.Background(), "gh", "list_repos", nil)
+	require.NoError(t, err)
+
+	// A fresh registry serves the result from disk without connecting.
+	r2 := NewWithCache(logging.Nop(), store)
+	r2.SetConfigured(memoConfig(true))
+	entries := r2.CachedResults("")
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Persisted)
+	res, err := r2.ExecuteToolRaw(context.Background(), "gh", "list_repos", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "call 1", text(t, res))
+
+	n, err := r2.FlushResults("", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, NewWithCache(logging.Nop(), store).CachedResults(""), "flush also clears the file")
+}
+
+func TestMemo_PersistKeepsOtherProcessResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.json")
+	// Two registries with their own stores stand in for two processes
+	// sharing one results.json.
+	a := NewWithCache(logging.Nop(), cache.NewStoreWithPath(path))
+	b := NewWithCache(logging.Nop(), cache.NewStoreWithPath(path))
+	var calls atomic.Int32
+	installInMemorySession(t, a, memoConfig(true), countingServer(&calls))
+	installInMemorySession(t, b, memoConfig(true), countingServer(&calls))
+	a.memo()
+	b.memo()
+
+	_, err := a.ExecuteToolRaw(context.Background(), "gh", "list_repos", map[string]any{"org": "a"})
+	require.NoError(t, err)
+	_, err = b.ExecuteToolRaw(context.Background(), "gh", "list_repos", map[string]any{"org": "b"})
+	require.NoError(t, err)
+
+	assert.Len(t, NewWithCache(logging.Nop(), cache.NewStoreWithPath(path)).CachedResults(""), 2)
+
+	n, err := a.FlushResults("gh", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, NewWithCache(logging.Nop(), cache.NewStoreWithPath(path)).CachedResults(""),
+		"flush drops matching results of other processes from disk")
+}
+
+func TestMemo_EvictsLeastRecentlyUsed(t *testing.T) {
+	store := cache.NewStoreWithPath(filepath.Join(t.TempDir(), "tools.json"))
+	r := NewWithCache(logging.Nop(), store)
+	r.results = newResultCache(2)
+	r.results.store = store.Results()
+	var calls atomic.Int32
+	installInMemorySession(t, r, memoConfig(true), countingServer(&calls))
+	ctx := context.Background()
+	call := func(org string) string {
+		res, err := r.ExecuteToolRaw(ctx, "gh", "list_repos", map[string]any{"org": org})
+		require.NoError(t, err)
+		return text(t, res)
+	}
+
+	assert.Equal(t, "call 1", call("a"))
+	assert.Equal(t, "call 2", call("b"))
+	assert.Equal(t, "call 1", call("a"), "a is now the most recently used")
+	assert.Equal(t, "call 3", call("c"), "c evicts b")
+	assert.Len(t, r.CachedResults(""), 2)
+	assert.Equal(t, "call 1", call("a"))
+	assert.Equal(t, "call 4", call("b"), "b was evicted")
+
+	assert.Len(t, NewWithCache(logging.Nop(), store).CachedResults(""), 2, "evicted results are dropped from disk too")
+}
+
+func TestMemo_ShortPersistedArgsHash(t *testing.T) {
+	store := cache.NewStoreWithPath(filepath.Join(t.TempDir(), "tools.json"))
+	require.NoError(t, store.Results().Update(func(saved map[string]*cache.CachedResult) {
+		saved["gh\x00list_repos\x00ab"] = &cache.CachedResult{
+			MCPName: "gh", ToolName: "list_repos", ArgsHash: "ab",
+			Result: []byte(`{"content":[]}`), ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}))
+
+	entries := NewWithCache(logging.Nop(), store).CachedResults("")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ab", entries[0].ArgsHash)
+}
+
+func TestGetCacheTTL(t *testing.T) {
+	ttl, persist := GetCacheTTL(memoConfig(true), "anything")
+	assert.Equal(t, time.Minute, ttl)
+	assert.True(t, persist)
+	ttl, _ = GetCacheTTL(config.MCPConfig{}, "anything")
+	assert.Zero(t, ttl)
+}
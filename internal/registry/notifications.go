@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"context"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// clientOptions builds the MCP client options for a connection to cfg.Name,
+// wiring server-to-client notifications back into the registry and relaying
+// server-to-client requests to the downstream caller. Sampling is only
+// advertised to the upstream when the config allows it; since capabilities
+// are fixed for the session, a config reload that changes sampling
+// reconnects. The relays read the rest of the config as each request
+// arrives, so an updated config applies without one.
+func (r *Registry) clientOptions(cfg config.MCPConfig) *mcp.ClientOptions {
+	mcpName := cfg.Name
+	opts := &mcp.ClientOptions{
+		// Notification handlers must not block the session's read loop, and
+		// the refresh itself issues a request on the same session.
+		ToolListChangedHandler: func(_ context.Context, req *mcp.ToolListChangedRequest) {
+			go r.refreshTools(mcpName, req.Session)
+		},
+		PromptListChangedHandler: func(_ context.Context, req *mcp.PromptListChangedRequest) {
+			go r.refreshPrompts(mcpName, req.Session)
+		},
+		// Relayed synchronously so progress reaches the caller in order.
+		ProgressNotificationHandler: func(ctx context.Context, req *mcp.ProgressNotificationClientRequest) {
+			r.relayProgress(ctx, mcpName, req.Params)
+		},
+		ElicitationHandler: func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			return r.relayElicit(ctx, mcpName, req)
+		},
+	}
+	if samplingAllowed(cfg) {
+		opts.CreateMessageHandler = func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			return r.relayCreateMessage(ctx, mcpName, req)
+		}
+	}
+	return opts
+}
+
+// SetToolsChangedHandler registers fn to be called (from a background
+// goroutine) after an MCP's tool list was re-fetched in response to
+// notifications/tools/list_changed and the index rebuilt. Pass nil to clear.
+func (r *Registry) SetToolsChangedHandler(fn func(mcpName string)) {
+	r.mu.Lock()
+	r.toolsChanged = fn
+	r.mu.Unlock()
+}
+
+// SetIndexChangedHandler registers fn to be called after every rebuild of
+// the search index: a connect, disconnect, cache load, tool list refresh,
+// config update, or override change. It runs on the rebuilding goroutine
+// after the new index is visible, so fn must not block. Pass nil to clear.
+func (r *Registry) SetIndexChangedHandler(fn func()) {
+	r.mu.Lock()
+	r.indexChanged = fn
+	r.mu.Unlock()
+}
+
+func (r *Registry) notifyIndexChanged() {
+	r.mu.RLock()
+	fn := r.indexChanged
+	r.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// refreshTools re-fetches an MCP's tool list after tools/list_changed, then
+// swaps it in, rebuilds the index, and rewrites the cache entry. It holds the
+// per-MCP lifecycle semaphore so it cannot interleave with a reconnect, and
+// drops the result if session is no longer the MCP's live session.
+func (r *Registry) refreshTools(mcpName string, session *mcp.ClientSession) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.refreshTimeout(mcpName))
+	defer cancel()
+
+	release, err := r.acquireConnectMu(ctx, mcpName)
+	if err != nil {
+		r.logger.Debug("tool list refresh abandoned", "mcp_name", mcpName, "error", err)
+		return
+	}
+	defer release()
+
+	if !r.isLiveSession(mcpName, session) {
+		return
+	}
+
+	tools, err := fetchTools(ctx, mcpName, session)
+	if err != nil {
+		r.logger.Warn("failed to refresh tools after list_changed", "mcp_name", mcpName, "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	if conn, ok := r.connections[mcpName]; !ok || conn.session != session {
+		r.mu.Unlock()
+		return
+	}
+	r.tools[mcpName] = tools
+	if st, ok := r.states[mcpName]; ok && !st.toolsIndexed {
+		next := *st
+		next.toolsIndexed = true
+		r.states[mcpName] = &next
+	}
+	notify := r.toolsChanged
+	r.mu.Unlock()
+
+	r.rebuildIndex()
+	r.updateCache(mcpName)
+	r.logger.Info("refreshed tools after list_changed", "mcp_name", mcpName, "tool_count", len(tools))
+
+	if notify != nil {
+		notify(mcpName)
+	}
+}
+
+// refreshPrompts re-lists an MCP's prompts after prompts/list_changed.
+func (r *Registry) refreshPrompts(mcpName string, session *mcp.ClientSession) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.refreshTimeout(mcpName))
+	defer cancel()
+
+	prompts, err := fetchPrompts(ctx, mcpName, session)
+	if err != nil {
+		r.logger.Warn("failed to refresh prompts after list_changed", "mcp_name", mcpName, "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	if conn, ok := r.connections[mcpName]; ok && conn.session == session {
+		// Connections are replaced wholesale, never mutated in place, so
+		// readers holding the old pointer keep a consistent view.
+		next := *conn
+		next.prompts = prompts
+		r.connections[mcpName] = &next
+	}
+	r.mu.Unlock()
+}
+
+// isLiveSession reports whether session is still the installed session for mcpName.
+func (r *Registry) isLiveSession(mcpName string, session *mcp.ClientSession) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conn, ok := r.connections[mcpName]
+	return ok && conn.session == session
+}
+
+// refreshTimeout bounds a list_changed refresh by the MCP's connection timeout.
+func (r *Registry) refreshTimeout(mcpName string) time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if st, ok := r.states[mcpName]; ok {
+		return GetConnectionTimeout(st.config)
+	}
+	return DefaultConnectionTimeout
+}
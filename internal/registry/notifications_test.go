@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addEchoTool(srv *mcp.Server, name string) {
+	srv.AddTool(&mcp.Tool{
+		Name:        name,
+		Description: "tool " + name,
+		InputSchema: map[string]any{"type": "object"},
+	}, func(context.Context, *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: name}}}, nil
+	})
+}
+
+func TestToolListChanged_RefreshesIndexAndCache(t *testing.T) {
+	r, store := newTestRegistryWithCache(t)
+	changed := make(chan string, 4)
+	r.SetToolsChangedHandler(func(name string) { changed <- name })
+
+	srv := mcp.NewServer(&mcp.Implementation{Name: "plugins", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "first")
+	cfg := config.MCPConfig{Name: "plugins", Type: "stdio", Command: "x"}
+	installInMemorySession(t, r, cfg, srv)
+	require.True(t, r.HasTool("plugins", "first"))
+	require.False(t, r.HasTool("plugins", "second"))
+
+	// Adding a tool on a live server emits notifications/tools/list_changed.
+	addEchoTool(srv, "second")
+
+	select {
+	case name := <-changed:
+		assert.Equal(t, "plugins", name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("tools-changed handler not called")
+	}
+
+	assert.True(t, r.HasTool("plugins", "second"))
+	assert.Len(t, r.SearchTools("", "plugins"), 2)
+
+	entry, err := store.GetEntry("plugins")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Len(t, entry.Tools, 2)
+}
+
+func TestIndexChangedHandler_CalledAfterEveryRebuild(t *testing.T) {
+	r := New()
+	var seen []int
+	r.SetIndexChangedHandler(func() { seen = append(seen, len(r.SearchTools("", ""))) })
+
+	r.AddToolsForTesting("m", []ToolInfo{{Name: "a", MCPName: "m"}})
+	r.SetOverrideProvider(nil)
+	r.AddToolsForTesting("m", nil)
+	assert.Equal(t, []int{1, 1, 0}, seen, "the handler sees the rebuilt index")
+
+	r.SetIndexChangedHandler(nil)
+	r.AddToolsForTesting("m", []ToolInfo{{Name: "a", MCPName: "m"}})
+	assert.Len(t, seen, 3)
+}
+
+func TestRefreshTools_IgnoresSupersededSession(t *testing.T) {
+	r, _ := newTestRegistryWithCache(t)
+	called := false
+	r.SetToolsChangedHandler(func(string) { called = true })
+
+	srv := mcp.NewServer(&mcp.Implementation{Name: "old", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "stale")
+	cfg := config.MCPConfig{Name: "m", Type: "stdio", Command: "x"}
+	oldSession := installInMemorySession(t, r, cfg, srv)
+
+	fresh := mcp.NewServer(&mcp.Implementation{Name: "new", Version: "1.0.0"}, nil)
+	addEchoTool(fresh, "current")
+	installInMemorySession(t, r, cfg, fresh)
+
+	r.refreshTools("m", oldSession)
+
+	assert.False(t, called)
+	assert.True(t, r.HasTool("m", "current"))
+	assert.False(t, r.HasTool("m", "stale"))
+}
+
+func TestPromptListChanged_RefreshesPrompts(t *testing.T) {
+	r, _ := newTestRegistryWithCache(t)
+	srv := newPromptServer()
+	installInMemorySession(t, r, config.MCPConfig{Name: "lib", Type: "stdio", Command: "x"}, srv)
+	require.Len(t, r.SearchPrompts("", "lib"), 2)
+
+	srv.AddPrompt(&mcp.Prompt{Name: "explain", Description: "Explain code"},
+		func(context.Context, *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return &mcp.GetPromptResult{}, nil
+		})
+
+	assert.Eventually(t, func() bool {
+		return len(r.SearchPrompts("", "lib")) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// ToolBlockedError is returned when a call targets a tool that the MCP's
+// allow/deny policy hides.
+type ToolBlockedError struct {
+	MCPName  string
+	ToolName string
+}
+
+func (e *ToolBlockedError) Error() string {
+	return fmt.Sprintf("tool '%s' on MCP '%s' is blocked by the MCP's allow/deny policy", e.ToolName, e.MCPName)
+}
+
+// policyFor returns the config carrying mcpName's allow/deny policy; the zero
+// config (which allows everything) for MCPs the registry does not track.
+// The caller must hold r.mu.
+func (r *Registry) policyFor(mcpName string) config.MCPConfig {
+	if st, ok := r.states[mcpName]; ok {
+		return st.config
+	}
+	return config.MCPConfig{}
+}
+
+// checkToolPolicy returns a *ToolBlockedError when mcpName's policy hides toolName.
+func (r *Registry) checkToolPolicy(mcpName, toolName string) error {
+	r.mu.RLock()
+	cfg := r.policyFor(mcpName)
+	r.mu.RUnlock()
+	if !cfg.ToolAllowed(toolName) {
+		return &ToolBlockedError{MCPName: mcpName, ToolName: toolName}
+	}
+	return nil
+}
+
+// snapshotTools copies the tool map for an index build, leaving out the
+// tools each MCP's policy hides. The caller must hold r.mu.
+func (r *Registry) snapshotTools() map[string][]ToolInfo {
+	snapshot := make(map[string][]ToolInfo, len(r.tools))
+	for name, tools := range r.tools {
+		cfg := r.policyFor(name)
+		cp := make([]ToolInfo, 0, len(tools))
+		for _, t := range tools {
+			if cfg.ToolAllowed(t.Name) {
+				cp = append(cp, t)
+			}
+		}
+		snapshot[name] = cp
+	}
+	return snapshot
+}
+
+// filteredTools returns the sorted names of mcpName's tools that its policy
+// hides. The caller must hold r.mu.
+func (r *Registry) filteredTools(mcpName string) []string {
+	cfg := r.policyFor(mcpName)
+	if len(cfg.Allow) == 0 && len(cfg.Deny) == 0 {
+		return nil
+	}
+	var names []string
+	for _, t := range r.tools[mcpName] {
+		if !cfg.ToolAllowed(t.Name) {
+			names = append(names, t.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,66 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolPolicy_HidesAndBlocksTools(t *testing.T) {
+	r := New()
+	r.SetCached(config.MCPConfig{
+		Name:    "github",
+		Type:    "stdio",
+		Command: "github-mcp",
+		Deny:    []string{"delete_*"},
+	}, []ToolInfo{
+		{Name: "list_repos", MCPName: "github"},
+		{Name: "delete_repo", MCPName: "github"},
+		{Name: "delete_branch", MCPName: "github"},
+	})
+
+	tools := r.SearchTools("", "github")
+	require.Len(t, tools, 1)
+	assert.Equal(t, "list_repos", tools[0].Name)
+	assert.False(t, r.HasTool("github", "delete_repo"))
+
+	meta := r.GetMetadata(context.Background())
+	require.Len(t, meta, 1)
+	require.Len(t, meta[0].Tools, 1)
+	assert.Equal(t, "list_repos", meta[0].Tools[0].Name)
+
+	// Blocked before any connect attempt, so the unreachable command is never run.
+	_, err := r.ExecuteToolRaw(context.Background(), "github", "delete_repo", nil)
+	var blocked *ToolBlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, "delete_repo", blocked.ToolName)
+	assert.Equal(t, StateCached, r.GetState("github"))
+
+	status := r.Status()
+	require.Len(t, status, 1)
+	assert.Equal(t, 1, status[0].ToolCount)
+	assert.Equal(t, []string{"delete_*"}, status[0].Deny)
+	assert.Equal(t, []string{"delete_branch", "delete_repo"}, status[0].FilteredTools)
+}
+
+func TestToolPolicy_AllowList(t *testing.T) {
+	r := New()
+	r.SetCached(config.MCPConfig{
+		Name:    "fs",
+		Type:    "stdio",
+		Command: "fs-mcp",
+		Allow:   []string{"read_*"},
+	}, []ToolInfo{
+		{Name: "read_file", MCPName: "fs"},
+		{Name: "write_file", MCPName: "fs"},
+	})
+
+	assert.True(t, r.HasTool("fs", "read_file"))
+	assert.False(t, r.HasTool("fs", "write_file"))
+	_, err := r.ExecuteTool(context.Background(), "fs", "write_file", nil)
+	var blocked *ToolBlockedError
+	assert.ErrorAs(t, err, &blocked)
+}
@@ -0,0 +1,289 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PromptMessage is one rendered message of a prompt. Content is converted
+// with the same rules as tool results (contentItemToAny).
+type PromptMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+// PromptResult is the rendered result of prompts/get.
+type PromptResult struct {
+	MCPName     string          `json:"mcp_name"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptNotFoundError is returned when an MCP does not serve a prompt.
+type PromptNotFoundError struct {
+	MCPName          string
+	PromptName       string
+	AvailablePrompts []string
+	SimilarPrompts   []string
+}
+
+func (e *PromptNotFoundError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "prompt '%s' not found in MCP '%s'", e.PromptName, e.MCPName)
+	if len(e.SimilarPrompts) > 0 {
+		fmt.Fprintf(&b, "\n\nDid you mean: %s", strings.Join(e.SimilarPrompts, ", "))
+	}
+	if len(e.AvailablePrompts) > 0 {
+		fmt.Fprintf(&b, "\n\nAvailable prompts: %s", strings.Join(e.AvailablePrompts, ", "))
+	} else {
+		b.WriteString("\n\nThis MCP serves no prompts.")
+	}
+	return b.String()
+}
+
+// GetPrompt renders a prompt on an MCP, connecting it on demand. Arguments
+// are validated against the prompt's declared arguments before the call:
+// required arguments must be present and undeclared ones are rejected.
+// Non-string values are JSON-encoded, since prompt arguments are strings on
+// the wire.
+func (r *Registry) GetPrompt(ctx context.Context, mcpName, promptName string, args map[string]any) (*PromptResult, error) {
+	if promptName == "" {
+		return nil, fmt.Errorf("prompt name is required")
+	}
+
+	session, done, err := r.lazySession(ctx, mcpName)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	prompts := r.promptsFor(mcpName)
+	var prompt *PromptInfo
+	for i := range prompts {
+		if prompts[i].Name == promptName {
+			prompt = &prompts[i]
+			break
+		}
+	}
+	if prompt == nil {
+		available := make([]string, 0, len(prompts))
+		for _, p := range prompts {
+			available = append(available, p.Name)
+		}
+		sort.Strings(available)
+		return nil, &PromptNotFoundError{
+			MCPName:          mcpName,
+			PromptName:       promptName,
+			AvailablePrompts: available,
+			SimilarPrompts:   findSimilarTools(promptName, available),
+		}
+	}
+
+	wireArgs, err := promptArguments(*prompt, args)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := session.GetPrompt(ctx, &mcp.GetPromptParams{Name: promptName, Arguments: wireArgs})
+	if err != nil {
+		if isConnectionError(err) {
+			r.markConnectionFailed(mcpName, err)
+			return nil, fmt.Errorf("MCP %s connection lost getting prompt '%s': %w", mcpName, promptName, err)
+		}
+		return nil, fmt.Errorf("error getting prompt '%s' on '%s': %w", promptName, mcpName, err)
+	}
+
+	out := &PromptResult{
+		MCPName:     mcpName,
+		Name:        promptName,
+		Description: result.Description,
+		Messages:    make([]PromptMessage, 0, len(result.Messages)),
+	}
+	for _, msg := range result.Messages {
+		if msg == nil {
+			continue
+		}
+		out.Messages = append(out.Messages, PromptMessage{
+			Role:    string(msg.Role),
+			Content: contentItemToAny(msg.Content),
+		})
+	}
+	return out, nil
+}
+
+// promptArguments validates args against a prompt's declared arguments and
+// converts them to the string map prompts/get expects.
+func promptArguments(prompt PromptInfo, args map[string]any) (map[string]string, error) {
+	declared := make(map[string]bool, len(prompt.Arguments))
+	var missing []string
+	for _, a := range prompt.Arguments {
+		declared[a.Name] = true
+		if a.Required {
+			if v, ok := args[a.Name]; !ok || v == nil {
+				missing = append(missing, a.Name)
+			}
+		}
+	}
+
+	var unknown []string
+	for name := range args {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(missing) > 0 || len(unknown) > 0 {
+		sort.Strings(unknown)
+		var b strings.Builder
+		fmt.Fprintf(&b, "invalid arguments for prompt '%s'", prompt.Name)
+		if len(missing) > 0 {
+			fmt.Fprintf(&b, "; missing required: %s", strings.Join(missing, ", "))
+		}
+		if len(unknown) > 0 {
+			fmt.Fprintf(&b, "; unknown: %s", strings.Join(unknown, ", "))
+		}
+		if len(prompt.Arguments) > 0 {
+			names := make([]string, 0, len(prompt.Arguments))
+			for _, a := range prompt.Arguments {
+				names = append(names, a.Name)
+			}
+			fmt.Fprintf(&b, " (accepted: %s)", strings.Join(names, ", "))
+		} else {
+			b.WriteString(" (prompt takes no arguments)")
+		}
+		return nil, fmt.Errorf("%s", b.String())
+	}
+
+	if len(args) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(args))
+	for name, v := range args {
+		switch x := v.(type) {
+		case nil:
+			// Optional argument explicitly set to null: omit it.
+		case string:
+			out[name] = x
+		default:
+			data, err := json.Marshal(x)
+			if err != nil {
+				return nil, fmt.Errorf("argument '%s' for prompt '%s': %w", name, prompt.Name, err)
+			}
+			out[name] = string(data)
+		}
+	}
+	return out, nil
+}
+
+// SearchPrompts returns prompts of connected MCPs matching query (ranked like
+// SearchTools), optionally limited to one MCP. Prompts are listed at connect
+// time, so cached MCPs contribute none until their first connection.
+func (r *Registry) SearchPrompts(query, mcpName string) []PromptInfo {
+	r.mu.RLock()
+	var all []PromptInfo
+	for name, conn := range r.connections {
+		if mcpName != "" && name != mcpName {
+			continue
+		}
+		all = append(all, conn.prompts...)
+	}
+	r.mu.RUnlock()
+
+	less := func(a, b PromptInfo) bool {
+		if a.MCPName != b.MCPName {
+			return a.MCPName < b.MCPName
+		}
+		return a.Name < b.Name
+	}
+
+	if query == "" {
+		sort.Slice(all, func(i, j int) bool { return less(all[i], all[j]) })
+		return all
+	}
+
+	queryLower := strings.ToLower(query)
+	queryNorm := normalize(query)
+	queryTerms := tokenize(query)
+
+	type scoredPrompt struct {
+		prompt PromptInfo
+		score  int
+	}
+	var scored []scoredPrompt
+	for _, p := range all {
+		// Score through the tool ranker: prompts match on the same
+		// name/description/MCP-name signals as tools.
+		score := scoreTool(ToolInfo{Name: p.Name, Description: p.Description, MCPName: p.MCPName},
+			p.MCPName, strings.ToLower(p.MCPName), queryLower, queryNorm, queryTerms)
+		if score > 0 {
+			scored = append(scored, scoredPrompt{prompt: p, score: score})
+		}
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return less(scored[i].prompt, scored[j].prompt)
+	})
+
+	results := make([]PromptInfo, len(scored))
+	for i, s := range scored {
+		results[i] = s.prompt
+	}
+	return results
+}
+
+// promptsFor returns the prompts listed for a connected MCP.
+func (r *Registry) promptsFor(mcpName string) []PromptInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if conn, ok := r.connections[mcpName]; ok {
+		return conn.prompts
+	}
+	return nil
+}
+
+// fetchPrompts lists the prompts of a session. MCPs that do not advertise the
+// prompts capability return nil without a round-trip. Like fetchTools, no
+// registry state is touched.
+func fetchPrompts(ctx context.Context, mcpName string, session *mcp.ClientSession) ([]PromptInfo, error) {
+	if init := session.InitializeResult(); init == nil || init.Capabilities == nil || init.Capabilities.Prompts == nil {
+		return nil, nil
+	}
+	result, err := session.ListPrompts(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]PromptInfo, 0, len(result.Prompts))
+	for _, p := range result.Prompts {
+		info := toPromptInfo(p)
+		info.MCPName = mcpName
+		prompts = append(prompts, info)
+	}
+	return prompts, nil
+}
+
+// toPromptInfo converts an SDK prompt to PromptInfo (MCPName left unset).
+func toPromptInfo(prompt *mcp.Prompt) PromptInfo {
+	info := PromptInfo{
+		Name:        prompt.Name,
+		Description: prompt.Description,
+	}
+	if prompt.Arguments != nil {
+		info.Arguments = make([]ArgumentInfo, 0, len(prompt.Arguments))
+		for _, arg := range prompt.Arguments {
+			info.Arguments = append(info.Arguments, ArgumentInfo{
+				Name:        arg.Name,
+				Description: arg.Description,
+				Required:    arg.Required,
+			})
+		}
+	}
+	return info
+}
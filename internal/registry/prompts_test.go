@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPromptServer() *mcp.Server {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "prompts", Version: "1.0.0"}, nil)
+	srv.AddPrompt(&mcp.Prompt{
+		Name:        "code_review",
+		Description: "Review a diff for bugs",
+		Arguments: []*mcp.PromptArgument{
+			{Name: "diff", Description: "Unified diff", Required: true},
+			{Name: "max_comments", Description: "Comment budget"},
+		},
+	}, func(_ context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return &mcp.GetPromptResult{
+			Description: "Code review",
+			Messages: []*mcp.PromptMessage{
+				{Role: "user", Content: &mcp.TextContent{Text: "Review: " + req.Params.Arguments["diff"] + " max=" + req.Params.Arguments["max_comments"]}},
+			},
+		}, nil
+	})
+	srv.AddPrompt(&mcp.Prompt{Name: "summarize", Description: "Summarize text"},
+		func(_ context.Context, _ *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return &mcp.GetPromptResult{Messages: []*mcp.PromptMessage{
+				{Role: "assistant", Content: &mcp.TextContent{Text: "ok"}},
+			}}, nil
+		})
+	return srv
+}
+
+func TestGetPrompt_RendersMessages(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "lib", Type: "stdio", Command: "x"}, newPromptServer())
+
+	res, err := r.GetPrompt(context.Background(), "lib", "code_review", map[string]any{
+		"diff":         "+x",
+		"max_comments": float64(3),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "lib", res.MCPName)
+	assert.Equal(t, "code_review", res.Name)
+	assert.Equal(t, "Code review", res.Description)
+	require.Len(t, res.Messages, 1)
+	assert.Equal(t, "user", res.Messages[0].Role)
+	assert.Equal(t, "Review: +x max=3", res.Messages[0].Content)
+}
+
+func TestGetPrompt_ValidatesArguments(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "lib", Type: "stdio", Command: "x"}, newPromptServer())
+	ctx := context.Background()
+
+	_, err := r.GetPrompt(ctx, "lib", "code_review", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing required: diff")
+
+	_, err = r.GetPrompt(ctx, "lib", "code_review", map[string]any{"diff": "x", "bogus": 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown: bogus")
+	assert.Contains(t, err.Error(), "accepted: diff, max_comments")
+
+	_, err = r.GetPrompt(ctx, "lib", "summarize", map[string]any{"text": "x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prompt takes no arguments")
+}
+
+func TestGetPrompt_NotFoundSuggestsSimilar(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "lib", Type: "stdio", Command: "x"}, newPromptServer())
+
+	_, err := r.GetPrompt(context.Background(), "lib", "code-review", nil)
+	require.Error(t, err)
+	var notFound *PromptNotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, []string{"code_review", "summarize"}, notFound.AvailablePrompts)
+	assert.Contains(t, notFound.SimilarPrompts, "code_review")
+}
+
+func TestGetPrompt_MCPNotFound(t *testing.T) {
+	r := New()
+	_, err := r.GetPrompt(context.Background(), "missing", "p", nil)
+	var notFound *MCPNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestSearchPrompts(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "lib", Type: "stdio", Command: "x"}, newPromptServer())
+	installInMemorySession(t, r, config.MCPConfig{Name: "plain", Type: "stdio", Command: "x"},
+		mcp.NewServer(&mcp.Implementation{Name: "plain", Version: "1.0.0"}, nil))
+
+	all := r.SearchPrompts("", "")
+	require.Len(t, all, 2)
+	assert.Equal(t, "code_review", all[0].Name)
+	assert.Equal(t, "lib", all[0].MCPName)
+
+	hits := r.SearchPrompts("bugs", "")
+	require.Len(t, hits, 1)
+	assert.Equal(t, "code_review", hits[0].Name)
+	require.Len(t, hits[0].Arguments, 2)
+	assert.True(t, hits[0].Arguments[0].Required)
+
+	assert.Empty(t, r.SearchPrompts("", "plain"))
+	assert.Empty(t, r.SearchPrompts("nothing-matches-this", ""))
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/standardbeagle/slop-mcp/internal/cache"
 	"github.com/standardbeagle/slop-mcp/internal/config"
 	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/secrets"
 )
 
 // OverrideProvider supplies per-tool description overrides and custom tool
@@ -111,6 +112,7 @@ func (t ToolInfo) UpstreamDescription() string {
 // PromptInfo represents a prompt from an MCP.
 type PromptInfo struct {
 	Name        string         `json:"name"`
+	MCPName     string         `json:"mcp_name,omitempty"`
 	Description string         `json:"description,omitempty"`
 	Arguments   []ArgumentInfo `json:"arguments,omitempty"`
 }
@@ -185,8 +187,9 @@ const (
 
 // Health check constants.
 const (
-	DefaultHealthCheckTimeout  = 5 * time.Second // Timeout for individual health check pings
-	DefaultHealthCheckInterval = 0               // Default is disabled (0 = no background health checks)
+	DefaultHealthCheckTimeout  = 5 * time.Second  // Timeout for individual health check pings
+	DefaultHealthCheckInterval = 0                // Default is disabled (0 = no background health checks)
+	DefaultWebSocketKeepalive  = 30 * time.Second // Ping interval of websocket MCPs; a missing pong marks the connection lost
 )
 
 // HealthStatus represents the health check status of an MCP.
@@ -210,6 +213,23 @@ type MCPFullStatus struct {
 	HealthStatus      HealthStatus `json:"health_status,omitempty"`
 	LastHealthCheck   string       `json:"last_health_check,omitempty"`
 	HealthError       string       `json:"health_error,omitempty"`
+	Allow             []string     `json:"allow,omitempty"`          // tool allow globs from the config
+	Deny              []string     `json:"deny,omitempty"`           // tool deny globs from the config
+	FilteredTools     []string     `json:"filtered_tools,omitempty"` // upstream tools hidden and blocked by Allow/Deny
+
+	// Circuit breaker; omitted while closed with no recent failures.
+	Circuit          CircuitState `json:"circuit,omitempty"`
+	CircuitFailures  int          `json:"circuit_failures,omitempty"`
+	CircuitOpenUntil string       `json:"circuit_open_until,omitempty"` // RFC3339
+
+	// Concurrency limit; omitted when max_concurrent is unset.
+	MaxConcurrent int    `json:"max_concurrent,omitempty"`
+	ActiveCalls   int    `json:"active_calls,omitempty"`
+	QueueDepth    int    `json:"queue_depth,omitempty"`    // calls waiting for a slot now
+	QueuedCalls   int    `json:"queued_calls,omitempty"`   // calls that had to wait, ever
+	QueueTimeouts int    `json:"queue_timeouts,omitempty"` // calls refused a slot or that gave up waiting
+	AvgQueueWait  string `json:"avg_queue_wait,omitempty"`
+	MaxQueueWait  string `json:"max_queue_wait,omitempty"`
 }
 
 // mcpState tracks the state of a configured MCP.
@@ -224,13 +244,26 @@ type mcpState struct {
 	healthStatus      HealthStatus // Last health check result
 	lastHealthCheck   time.Time    // Time of last health check
 	healthError       error        // Error from last health check if unhealthy
+	lastUsed          time.Time    // Last connect or call start/end (drives the idle reaper)
 }
 
 // mcpConnection holds an MCP client session.
 type mcpConnection struct {
-	session   *mcp.ClientSession
-	transport mcp.Transport
-	config    config.MCPConfig
+	session    *mcp.ClientSession
+	transport  mcp.Transport
+	httpClient *http.Client // nil for stdio and plain unix socket MCPs
+	config     config.MCPConfig
+	prompts    []PromptInfo // listed at connect; nil when the MCP serves none
+}
+
+// close ends the session, then closes the idle connections of the MCP's
+// HTTP transport, which would otherwise stay open after the session.
+func (c *mcpConnection) close() error {
+	err := c.session.Close()
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+	return err
 }
 
 // Registry manages multiple MCP connections.
@@ -244,10 +277,23 @@ type Registry struct {
 	mu                sync.RWMutex
 	rebuildMu         sync.Mutex
 	healthCheckCancel context.CancelFunc       // cancels background health check goroutine
+	idleReaperCancel  context.CancelFunc       // cancels the idle reaper goroutine
 	cache             *cache.Store             // disk cache for tool metadata
 	connectMu         map[string]chan struct{} // per-MCP semaphore serializing lifecycle ops
 	closed            bool                     // set by Close; blocks late connection installs
 	reconnecting      map[string]bool          // MCPs with an in-flight auto-reconnect goroutine
+	toolsChanged      func(mcpName string)     // optional; notified after a list_changed refresh
+	indexChanged      func()                   // optional; notified after every index rebuild
+	callersMu         sync.Mutex
+	callers           map[string][]*inflightCall // in-flight downstream calls per MCP, oldest first
+	progress          map[string]*inflightCall   // in-flight calls by upstream progress token
+	breakers          map[string]*circuitBreaker // per-MCP circuit breakers (guarded by mu)
+	limiters          map[string]*callLimiter    // per-MCP max_concurrent queues (guarded by mu)
+	retryAfter        map[string]time.Duration   // latest 429 Retry-After per MCP (guarded by mu)
+	results           *resultCache               // memoized cache_ttl results; created on first use
+	stderrLogs        map[string]*stderrLog      // stdio MCP stderr buffers, kept across reconnects (guarded by mu)
+	inflight          map[string]int             // calls using each MCP's connection; outlives state copies (guarded by mu)
+	progressSeq       atomic.Uint64
 }
 
 // newRegistry initialises a Registry and seeds the atomic index pointer.
@@ -265,6 +311,8 @@ func New() *Registry {
 		logger:      logging.Default(),
 		cache:       cache.NewStore(),
 		connectMu:   make(map[string]chan struct{}),
+		callers:     make(map[string][]*inflightCall),
+		progress:    make(map[string]*inflightCall),
 	})
 }
 
@@ -277,6 +325,8 @@ func NewWithLogger(logger logging.Logger) *Registry {
 		logger:      logger,
 		cache:       cache.NewStore(),
 		connectMu:   make(map[string]chan struct{}),
+		callers:     make(map[string][]*inflightCall),
+		progress:    make(map[string]*inflightCall),
 	})
 }
 
@@ -289,50 +339,45 @@ func NewWithCache(logger logging.Logger, cacheStore *cache.Store) *Registry {
 		logger:      logger,
 		cache:       cacheStore,
 		connectMu:   make(map[string]chan struct{}),
+		callers:     make(map[string][]*inflightCall),
+		progress:    make(map[string]*inflightCall),
 	})
 }
 
-// rebuildIndex snapshots the tool map under RLock, releases the lock, builds a
-// new immutable ToolIndex (applying any registered OverrideProvider), and
-// atomically stores it. Safe to call from any goroutine while mu is NOT held by
-// the caller — it acquires RLock internally.
+// rebuildIndex snapshots the tool map under RLock (minus the tools each MCP's
+// allow/deny policy hides), releases the lock, builds a new immutable
+// ToolIndex (applying any registered OverrideProvider), and atomically stores
+// it. Safe to call from any goroutine while mu is NOT held by the caller — it
+// acquires RLock internally.
 func (r *Registry) rebuildIndex() {
 	r.rebuildMu.Lock()
-	defer r.rebuildMu.Unlock()
-
 	r.mu.RLock()
-	snapshot := make(map[string][]ToolInfo, len(r.tools))
-	for k, v := range r.tools {
-		cp := make([]ToolInfo, len(v))
-		copy(cp, v)
-		snapshot[k] = cp
-	}
+	snapshot := r.snapshotTools()
 	provider := r.overrides
 	r.mu.RUnlock()
 
 	idx := buildIndex(snapshot, provider)
 	r.indexPtr.Store(idx)
+	r.rebuildMu.Unlock()
+
+	r.notifyIndexChanged()
 }
 
 // SetOverrideProvider registers an OverrideProvider and triggers a rebuild.
 // Pass nil to clear the provider.
 func (r *Registry) SetOverrideProvider(p OverrideProvider) {
 	r.rebuildMu.Lock()
-	defer r.rebuildMu.Unlock()
-
 	r.mu.Lock()
 	r.overrides = p
 	// Take snapshot while holding write lock so we can release before CPU work.
-	snapshot := make(map[string][]ToolInfo, len(r.tools))
-	for k, v := range r.tools {
-		cp := make([]ToolInfo, len(v))
-		copy(cp, v)
-		snapshot[k] = cp
-	}
+	snapshot := r.snapshotTools()
 	r.mu.Unlock()
 
 	idx := buildIndex(snapshot, p)
 	r.indexPtr.Store(idx)
+	r.rebuildMu.Unlock()
+
+	r.notifyIndexChanged()
 }
 
 // loadIndex returns the current immutable search index.
@@ -355,6 +400,47 @@ func (r *Registry) SetConfigured(cfg config.MCPConfig) {
 	}
 }
 
+// UpdateConfig replaces the stored config of a tracked MCP without
+// reconnecting. It is for edits that leave cache.ConfigHash unchanged, such
+// as timeouts, limits, or the tool policy; a changed command, URL, env, or
+// headers needs a reconnect instead. The state and connection are replaced
+// by updated copies, never mutated in place, since readers may hold the old
+// pointers without the lock. It reports whether the MCP was tracked.
+func (r *Registry) UpdateConfig(ctx context.Context, cfg config.MCPConfig) (bool, error) {
+	release, err := r.acquireConnectMu(ctx, cfg.Name)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	r.mu.Lock()
+	st, ok := r.states[cfg.Name]
+	if !ok {
+		r.mu.Unlock()
+		return false, nil
+	}
+	nextState := *st
+	nextState.config = cfg
+	r.states[cfg.Name] = &nextState
+	if conn := r.connections[cfg.Name]; conn != nil {
+		nextConn := *conn
+		nextConn.config = cfg
+		r.connections[cfg.Name] = &nextConn
+	}
+	l := r.stderrLogs[cfg.Name]
+	r.mu.Unlock()
+
+	if l != nil {
+		l.resize(GetStderrLines(cfg))
+		if err := l.setTee(cfg.StderrLog); err != nil {
+			r.logger.Warn("cannot open stderr log", "mcp_name", cfg.Name, "path", cfg.StderrLog, "error", err)
+		}
+	}
+	// allow/deny may have changed which tools are visible.
+	r.rebuildIndex()
+	return true, nil
+}
+
 // GetState returns the current state of an MCP.
 func (r *Registry) GetState(name string) MCPState {
 	r.mu.RLock()
@@ -641,6 +727,10 @@ func (r *Registry) Status() []MCPFullStatus {
 			State:             state.state,
 			Source:            state.config.Source.String(),
 			ReconnectAttempts: state.reconnectAttempts,
+			Allow:             state.config.Allow,
+			Deny:              state.config.Deny,
+			FilteredTools:     r.filteredTools(name),
+			MaxConcurrent:     GetMaxConcurrent(state.config),
 		}
 
 		// Add tool count if connected or cached
@@ -666,6 +756,25 @@ func (r *Registry) Status() []MCPFullStatus {
 			status.HealthError = state.healthError.Error()
 		}
 
+		if b, ok := r.breakers[name]; ok && (b.state != CircuitClosed || b.failures > 0) {
+			status.Circuit = b.state
+			status.CircuitFailures = b.failures
+			if b.state == CircuitOpen {
+				status.CircuitOpenUntil = b.openUntil.Format(time.RFC3339)
+			}
+		}
+
+		if l, ok := r.limiters[name]; ok {
+			status.ActiveCalls = l.active
+			status.QueueDepth = len(l.waiters)
+			status.QueuedCalls = l.queued
+			status.QueueTimeouts = l.timeouts
+			if l.granted > 0 {
+				status.AvgQueueWait = (l.waitTotal / time.Duration(l.granted)).Round(time.Millisecond).String()
+				status.MaxQueueWait = l.waitMax.Round(time.Millisecond).String()
+			}
+		}
+
 		result = append(result, status)
 	}
 
@@ -716,6 +825,10 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 			reconnectAttempts: prevAttempts,
 			lastFailure:       time.Now(),
 		}
+		// A missing login is not an outage; retrying will not fix it.
+		if state == StateError {
+			r.breakerFailureLocked(cfg, err)
+		}
 		r.mu.Unlock()
 		return err
 	}
@@ -724,20 +837,31 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 	client := mcp.NewClient(&mcp.Implementation{
 		Name:    "slop-mcp",
 		Version: ClientVersion,
-	}, nil)
+	}, r.clientOptions(cfg))
 
 	// Create transport based on type
 	var transport mcp.Transport
+	var httpClient *http.Client // the HTTP transports' client, closed with the connection
 	switch cfg.Type {
 	case "command", "stdio", "":
+		// Resolve ${env:...}/${file:...}/${secret:...} references only here,
+		// so the stored config (and its cache hash) keeps the reference.
+		env, err := secrets.ExpandMap(cfg.Env)
+		if err != nil {
+			return setError(fmt.Errorf("MCP %s env %w", cfg.Name, err), StateError)
+		}
 		cmd := exec.Command(cfg.Command, cfg.Args...)
-		if len(cfg.Env) > 0 {
+		if len(env) > 0 {
 			// Start with current environment, then add custom vars
 			cmd.Env = os.Environ()
-			for k, v := range cfg.Env {
+			for k, v := range env {
 				cmd.Env = append(cmd.Env, k+"="+v)
 			}
 		}
+		// Capture stderr instead of discarding it: the last lines are quoted
+		// in errors and served by the logs action.
+		cmd.Stderr = r.stderrFor(cfg)
+		cmd.WaitDelay = stderrWaitDelay
 		transport = &mcp.CommandTransport{
 			Command: cmd,
 		}
@@ -747,9 +871,12 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 			Endpoint: cfg.URL,
 		}
 		// Apply custom headers and/or OAuth token (refreshes expired tokens automatically)
-		if httpClient := r.buildHTTPClient(ctx, cfg); httpClient != nil {
-			sseTransport.HTTPClient = httpClient
+		hc, err := r.buildHTTPClient(ctx, cfg)
+		if err != nil {
+			return setError(err, StateError)
 		}
+		httpClient = hc
+		sseTransport.HTTPClient = httpClient
 		transport = sseTransport
 
 	case "http", "streamable":
@@ -757,11 +884,42 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 			Endpoint: cfg.URL,
 		}
 		// Apply custom headers and/or OAuth token (refreshes expired tokens automatically)
-		if httpClient := r.buildHTTPClient(ctx, cfg); httpClient != nil {
-			streamTransport.HTTPClient = httpClient
+		hc, err := r.buildHTTPClient(ctx, cfg)
+		if err != nil {
+			return setError(err, StateError)
 		}
+		httpClient = hc
+		streamTransport.HTTPClient = httpClient
 		transport = streamTransport
 
+	case "websocket":
+		// Apply custom headers and/or OAuth token to the upgrade request
+		hc, err := r.buildHTTPClient(ctx, cfg)
+		if err != nil {
+			return setError(err, StateError)
+		}
+		httpClient = hc
+		transport = &websocketTransport{url: cfg.URL, client: httpClient}
+
+	case "unix":
+		if cfg.Socket == "" {
+			return setError(fmt.Errorf("MCP %s: unix transport requires a socket path", cfg.Name), StateError)
+		}
+		if cfg.URL == "" {
+			transport = &unixSocketTransport{path: cfg.Socket}
+			break
+		}
+		// Streamable HTTP over the socket, with headers and OAuth as above
+		hc, err := r.buildHTTPClient(ctx, cfg)
+		if err != nil {
+			return setError(err, StateError)
+		}
+		httpClient = hc
+		transport = &mcp.StreamableClientTransport{
+			Endpoint:   cfg.URL,
+			HTTPClient: httpClient,
+		}
+
 	default:
 		err := fmt.Errorf("unknown MCP transport type: %s", cfg.Type)
 		return setError(err, StateError)
@@ -775,6 +933,9 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 	// Connect
 	session, err := client.Connect(connectCtx, transport, nil)
 	if err != nil {
+		if httpClient != nil {
+			httpClient.CloseIdleConnections()
+		}
 		// Check if error indicates authentication required. Match specific
 		// auth signals only -- a bare "authentication" substring also appears
 		// in unrelated failures (e.g. TLS handshake text) and would wrongly
@@ -788,7 +949,7 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 			strings.Contains(errLower, "token expired") {
 			return setError(fmt.Errorf("authentication required: %w", err), StateNeedsAuth)
 		}
-		return setError(fmt.Errorf("failed to connect to MCP %s: %w", cfg.Name, err), StateError)
+		return setError(r.withStderr(cfg.Name, fmt.Errorf("failed to connect to MCP %s: %w", cfg.Name, err)), StateError)
 	}
 
 	// Fetch the tool list before installing the connection (fresh timeout,
@@ -799,6 +960,10 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 	if indexErr != nil {
 		r.logger.Warn("failed to index tools", "mcp_name", cfg.Name, "error", indexErr)
 	}
+	prompts, promptErr := fetchPrompts(indexCtx, cfg.Name, session)
+	if promptErr != nil {
+		r.logger.Warn("failed to list prompts", "mcp_name", cfg.Name, "error", promptErr)
+	}
 
 	// Install connection, state, and tools atomically (short critical
 	// section). Capture any prior connection so its session can be closed
@@ -809,33 +974,42 @@ func (r *Registry) connectLocked(ctx context.Context, cfg config.MCPConfig) erro
 	// of leaking a live subprocess/socket past shutdown.
 	if r.closed {
 		r.mu.Unlock()
-		if cerr := session.Close(); cerr != nil {
+		conn := &mcpConnection{session: session, httpClient: httpClient}
+		if cerr := conn.close(); cerr != nil {
 			r.logger.Debug("closing session after registry shutdown", "mcp_name", cfg.Name, "error", cerr)
 		}
 		return fmt.Errorf("registry is closed")
 	}
 	old := r.connections[cfg.Name]
 	r.connections[cfg.Name] = &mcpConnection{
-		session:   session,
-		transport: transport,
-		config:    cfg,
+		session:    session,
+		transport:  transport,
+		httpClient: httpClient,
+		config:     cfg,
+		prompts:    prompts,
 	}
 	r.states[cfg.Name] = &mcpState{
 		config:       cfg,
 		state:        StateConnected,
 		toolsIndexed: indexErr == nil,
+		lastUsed:     time.Now(),
 	}
 	if indexErr == nil {
 		r.tools[cfg.Name] = tools
 	}
+	r.breakerSuccessLocked(cfg.Name)
 	r.mu.Unlock()
 
 	if old != nil {
-		if cerr := old.session.Close(); cerr != nil {
+		if cerr := old.close(); cerr != nil {
 			r.logger.Debug("error closing replaced MCP session", "mcp_name", cfg.Name, "error", cerr)
 		}
 	}
 
+	if wt, ok := transport.(*websocketTransport); ok && wt.conn != nil {
+		r.watchWebSocket(cfg.Name, session, wt.conn)
+	}
+
 	r.rebuildIndex()
 
 	toolCount := r.loadIndex().CountForMCP(cfg.Name)
@@ -885,7 +1059,7 @@ func (r *Registry) disconnectLocked(name string) error {
 
 	// Close the session outside the registry lock. Subprocess teardown can
 	// block, and holding the write lock here stalls unrelated registry calls.
-	if err := conn.session.Close(); err != nil {
+	if err := conn.close(); err != nil {
 		// Debug level: expected during shutdown, errors stored in state for status queries
 		r.logger.Debug("error closing MCP session", "mcp_name", name, "error", err)
 	}
@@ -916,10 +1090,13 @@ func (r *Registry) Unregister(ctx context.Context, name string) error {
 	delete(r.connections, name)
 	delete(r.tools, name)
 	delete(r.states, name)
+	delete(r.breakers, name)
+	r.dropLimiterLocked(name)
+	r.dropStderrLocked(name)
 	r.mu.Unlock()
 
 	if conn != nil {
-		if err := conn.session.Close(); err != nil {
+		if err := conn.close(); err != nil {
 			r.logger.Debug("error closing MCP session", "mcp_name", name, "error", err)
 		}
 	}
@@ -1412,6 +1589,12 @@ func (r *Registry) HasMCP(name string) bool {
 	return false
 }
 
+// HasTool reports whether the tool index lists toolName for mcpName.
+// Lock-free: reads from the atomic index snapshot.
+func (r *Registry) HasTool(mcpName, toolName string) bool {
+	return r.loadIndex().GetTool(mcpName, toolName) != nil
+}
+
 // SearchTools searches tools by query and/or MCP name.
 // Lock-free: reads from the atomic index snapshot.
 func (r *Registry) SearchTools(query, mcpName string) []ToolInfo {
@@ -1426,6 +1609,7 @@ func (r *Registry) GetMetadata(ctx context.Context) []MCPMetadata {
 	type metaSnapshot struct {
 		meta    MCPMetadata
 		session *mcp.ClientSession
+		config  config.MCPConfig
 	}
 
 	r.mu.RLock()
@@ -1436,7 +1620,7 @@ func (r *Registry) GetMetadata(ctx context.Context) []MCPMetadata {
 			Type:   state.config.Type,
 			State:  state.state,
 			Source: state.config.Source.String(),
-		}}
+		}, config: state.config}
 
 		// For cached MCPs, return tools from index (without connecting)
 		if state.state == StateCached {
@@ -1469,6 +1653,9 @@ func (r *Registry) GetMetadata(ctx context.Context) []MCPMetadata {
 			if toolsResult, err := conn.ListTools(ctx, nil); err == nil {
 				metadata.Tools = make([]ToolInfo, 0, len(toolsResult.Tools))
 				for _, tool := range toolsResult.Tools {
+					if !snap.config.ToolAllowed(tool.Name) {
+						continue
+					}
 					var inputSchema map[string]any
 					if schema, ok := tool.InputSchema.(map[string]any); ok {
 						inputSchema = schema
@@ -1486,21 +1673,7 @@ func (r *Registry) GetMetadata(ctx context.Context) []MCPMetadata {
 			if promptsResult, err := conn.ListPrompts(ctx, nil); err == nil {
 				metadata.Prompts = make([]PromptInfo, 0, len(promptsResult.Prompts))
 				for _, prompt := range promptsResult.Prompts {
-					promptInfo := PromptInfo{
-						Name:        prompt.Name,
-						Description: prompt.Description,
-					}
-					if prompt.Arguments != nil {
-						promptInfo.Arguments = make([]ArgumentInfo, 0, len(prompt.Arguments))
-						for _, arg := range prompt.Arguments {
-							promptInfo.Arguments = append(promptInfo.Arguments, ArgumentInfo{
-								Name:        arg.Name,
-								Description: arg.Description,
-								Required:    arg.Required,
-							})
-						}
-					}
-					metadata.Prompts = append(metadata.Prompts, promptInfo)
+					metadata.Prompts = append(metadata.Prompts, toPromptInfo(prompt))
 				}
 			}
 
@@ -1610,6 +1783,62 @@ func (r *Registry) ExecuteToolRawJSON(ctx context.Context, mcpName, toolName str
 // response. args is passed straight to the SDK as CallToolParams.Arguments; a
 // nil interface lets the SDK normalize it to an empty object.
 func (r *Registry) callRaw(ctx context.Context, mcpName, toolName string, args any) (*mcp.CallToolResult, error) {
+	if err := r.checkToolPolicy(mcpName, toolName); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	cfg := r.policyFor(mcpName)
+	r.mu.RUnlock()
+	timeout := GetToolTimeout(cfg, toolName)
+
+	ttl, persist := GetCacheTTL(cfg, toolName)
+	key, argsHash, memoize := "", "", false
+	if ttl > 0 {
+		key, argsHash, memoize = memoKey(mcpName, toolName, args)
+	}
+	if memoize {
+		if cached, ok := r.memo().get(key, cache.ConfigHash(cfg)); ok {
+			return cached, nil
+		}
+	}
+
+	var result *mcp.CallToolResult
+	err := r.callWithRetry(ctx, cfg, toolName, func() error {
+		var err error
+		result, err = r.callOnce(ctx, mcpName, toolName, args, timeout)
+		return err
+	})
+	if err == nil && memoize && !result.IsError {
+		entry := &cache.CachedResult{
+			MCPName:    mcpName,
+			ToolName:   toolName,
+			ArgsHash:   argsHash,
+			ConfigHash: cache.ConfigHash(cfg),
+			ExpiresAt:  time.Now().Add(ttl),
+		}
+		if perr := r.memo().put(key, entry, result, persist); perr != nil {
+			r.logger.Debug("failed to persist memoized result", "mcp_name", mcpName, "tool_name", toolName, "error", perr)
+		}
+	}
+	return result, err
+}
+
+// callOnce makes a single attempt of callRaw, bounding the upstream call by
+// timeout when it is non-zero.
+func (r *Registry) callOnce(ctx context.Context, mcpName, toolName string, args any, timeout time.Duration) (*mcp.CallToolResult, error) {
+	probe, err := r.breakerAllow(mcpName)
+	if err != nil {
+		return nil, err
+	}
+	if probe {
+		defer r.breakerRelease(mcpName)
+	}
+	releaseSlot, err := r.acquireSlot(ctx, mcpName)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseSlot()
+
 	// Lazy-connect through EnsureConnected: cached/configured MCPs dial on
 	// demand, an in-flight connect (StateConnecting) is awaited instead of
 	// racing past it, and StateError retries once per ErrorRetryInterval.
@@ -1622,10 +1851,7 @@ func (r *Registry) callRaw(ctx context.Context, mcpName, toolName string, args a
 		state = r.GetState(mcpName)
 	}
 
-	r.mu.RLock()
-	conn, ok := r.connections[mcpName]
-	r.mu.RUnlock()
-
+	conn, done, ok := r.useConnection(mcpName)
 	if !ok {
 		if state != "" {
 			return nil, fmt.Errorf("MCP %s is in state %s, cannot execute tool %s", mcpName, state, toolName)
@@ -1635,13 +1861,39 @@ func (r *Registry) callRaw(ctx context.Context, mcpName, toolName string, args a
 			AvailableMCPs: r.listNames(),
 		}
 	}
+	defer done()
 
-	// Call tool and return raw result
-	result, err := conn.session.CallTool(ctx, &mcp.CallToolParams{
+	params := &mcp.CallToolParams{
 		Name:      toolName,
 		Arguments: args,
-	})
+	}
+	if c := callerFrom(ctx); c != nil {
+		call, release := r.trackCall(mcpName, toolName, c)
+		defer release()
+		if call.progressToken != "" {
+			params.SetProgressToken(call.progressToken)
+		}
+	}
+
+	callCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Call tool and return raw result
+	result, err := conn.session.CallTool(callCtx, params)
 	if err != nil {
+		// The caller cancelled or timed out. The SDK has already sent
+		// notifications/cancelled upstream, and the MCP itself is fine, so
+		// this must not be mistaken for a connection failure.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("call to tool '%s' on '%s' stopped: %w (the MCP was notified to cancel it)", toolName, mcpName, ctxErr)
+		}
+		if callCtx.Err() != nil {
+			return nil, &ToolTimeoutError{MCPName: mcpName, ToolName: toolName, Timeout: timeout}
+		}
 		// Transport death (crashed subprocess, dropped socket): demote the MCP
 		// to StateError and drop the dead session so the next call reconnects
 		// instead of hitting the same corpse. Check this first -- a dead
@@ -1649,7 +1901,7 @@ func (r *Registry) callRaw(ctx context.Context, mcpName, toolName string, args a
 		// otherwise be misread as tool-not-found.
 		if isConnectionError(err) {
 			r.markConnectionFailed(mcpName, err)
-			return nil, fmt.Errorf("MCP %s connection lost calling tool '%s': %w", mcpName, toolName, err)
+			return nil, r.withStderr(mcpName, fmt.Errorf("MCP %s connection lost calling tool '%s': %w", mcpName, toolName, err))
 		}
 		// Check for tool not found errors
 		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unknown") {
@@ -1663,11 +1915,13 @@ func (r *Registry) callRaw(ctx context.Context, mcpName, toolName string, args a
 		}
 		// Check for protocol/validation errors
 		if protocolErr := parseProtocolError(mcpName, toolName, err); protocolErr != nil {
+			protocolErr.Stderr = r.stderrTail(mcpName)
 			return nil, protocolErr
 		}
 		return nil, fmt.Errorf("error calling tool '%s' on '%s': %w", toolName, mcpName, err)
 	}
 
+	r.breakerSuccess(mcpName)
 	return result, nil
 }
 
@@ -1726,10 +1980,11 @@ func (r *Registry) markConnectionFailed(name string, cause error) {
 	}
 	delete(r.connections, name)
 	delete(r.tools, name)
+	r.breakerFailureLocked(st.config, cause)
 	r.mu.Unlock()
 
 	if conn != nil {
-		if cerr := conn.session.Close(); cerr != nil {
+		if cerr := conn.close(); cerr != nil {
 			r.logger.Debug("closing dead MCP session", "mcp_name", name, "error", cerr)
 		}
 	}
@@ -1842,8 +2097,9 @@ func (r *Registry) createParameterError(mcpName, toolName, originalError string,
 
 // Close closes all MCP connections and stops background health checks.
 func (r *Registry) Close() error {
-	// Stop background health check first (uses its own lock)
+	// Stop background goroutines first (they use their own lock)
 	r.StopBackgroundHealthCheck()
+	r.StopIdleReaper()
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -1854,7 +2110,7 @@ func (r *Registry) Close() error {
 
 	var lastErr error
 	for name, conn := range r.connections {
-		if err := conn.session.Close(); err != nil {
+		if err := conn.close(); err != nil {
 			lastErr = err
 			// Debug level: subprocess exit codes during shutdown are expected
 			r.logger.Debug("error closing MCP", "mcp_name", name, "error", err)
@@ -1866,6 +2122,10 @@ func (r *Registry) Close() error {
 		}
 	}
 	r.connections = make(map[string]*mcpConnection)
+	// Sessions are closed, so no subprocess writes to these anymore.
+	for _, l := range r.stderrLogs {
+		_ = l.setTee("")
+	}
 	return lastErr
 }
 
@@ -2206,6 +2466,8 @@ type MCPProtocolError struct {
 	ErrorCode     string // e.g., "invalid_type"
 	Path          string // e.g., "params.arguments"
 	Suggestion    string // actionable fix
+	// Stderr holds the last stderr lines of a stdio MCP, if any.
+	Stderr []string
 }
 
 func (e *MCPProtocolError) Error() string {
@@ -2223,6 +2485,8 @@ func (e *MCPProtocolError) Error() string {
 		sb.WriteString(fmt.Sprintf("\nFix: %s\n", e.Suggestion))
 	}
 
+	sb.WriteString(formatStderrTail(e.MCPName, e.Stderr))
+
 	return sb.String()
 }
 
@@ -2547,15 +2811,20 @@ func (t *headersTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	return t.base.RoundTrip(r)
 }
 
+func (t *headersTransport) CloseIdleConnections() { closeIdleConnections(t.base) }
+
 // oauthTransport injects config headers plus a freshly-resolved OAuth Bearer
 // token on every request. Resolving per request (rather than baking the token
 // in at connect) means a token that expires mid-session is transparently
-// refreshed instead of 401ing until the connection is torn down.
+// refreshed instead of 401ing until the connection is torn down. With a
+// client_credentials flow, the token comes from the grant instead, and a
+// failed grant fails the request with the authorization server's error.
 type oauthTransport struct {
-	base          http.RoundTripper
-	staticHeaders map[string]string
-	reg           *Registry
-	serverName    string
+	base              http.RoundTripper
+	staticHeaders     map[string]string
+	reg               *Registry
+	serverName        string
+	clientCredentials *auth.ClientCredentialsFlow
 }
 
 func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -2563,20 +2832,60 @@ func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	for k, v := range t.staticHeaders {
 		r.Header.Set(k, v)
 	}
-	if token := t.reg.getValidToken(r.Context(), t.serverName); token != nil {
+	if t.clientCredentials != nil {
+		token, err := t.reg.clientCredentialsToken(r.Context(), t.clientCredentials)
+		if err != nil {
+			return nil, err
+		}
+		r.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	} else if token := t.reg.getValidToken(r.Context(), t.serverName); token != nil {
 		r.Header.Set("Authorization", "Bearer "+token.AccessToken)
 	}
 	return t.base.RoundTrip(r)
 }
 
-// buildHTTPClient creates an HTTP client with custom headers and/or OAuth token.
-// When the MCP has a stored OAuth token, the returned client refreshes the token
-// per request (see oauthTransport); otherwise it just applies static config
-// headers.
-func (r *Registry) buildHTTPClient(_ context.Context, cfg config.MCPConfig) *http.Client {
-	staticHeaders := make(map[string]string, len(cfg.Headers))
-	for k, v := range cfg.Headers {
-		staticHeaders[k] = v
+// CloseIdleConnections also closes those of the client_credentials grant's
+// client, which has a transport of its own under tls or proxy settings.
+func (t *oauthTransport) CloseIdleConnections() {
+	closeIdleConnections(t.base)
+	if t.clientCredentials != nil && t.clientCredentials.HTTPClient != nil {
+		t.clientCredentials.HTTPClient.CloseIdleConnections()
+	}
+}
+
+// buildHTTPClient creates an HTTP client with custom headers and/or OAuth token,
+// over the MCP's baseTransport (its tls and proxy settings included).
+// When the MCP has an oauth block or a stored OAuth token, the returned client
+// resolves the token per request (see oauthTransport); otherwise it just
+// applies static config headers. Either way it records 429 Retry-After values for the retry policy.
+// Secret references in the headers are resolved here; one that cannot be
+// resolved is an error.
+func (r *Registry) buildHTTPClient(_ context.Context, cfg config.MCPConfig) (*http.Client, error) {
+	roundTripper, err := baseTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	base := &retryAfterTransport{base: roundTripper, reg: r, mcpName: cfg.Name}
+	staticHeaders, err := secrets.ExpandMap(cfg.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("MCP %s header %w", cfg.Name, err)
+	}
+
+	// A client_credentials MCP gets its token from the grant, stored or not.
+	if cfg.OAuth != nil {
+		flow, err := NewClientCredentialsFlow(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Client{
+			Transport: &oauthTransport{
+				base:              base,
+				staticHeaders:     staticHeaders,
+				reg:               r,
+				serverName:        cfg.Name,
+				clientCredentials: flow,
+			},
+		}, nil
 	}
 
 	// If this MCP has a stored OAuth token, use the refreshing transport so the
@@ -2585,23 +2894,23 @@ func (r *Registry) buildHTTPClient(_ context.Context, cfg config.MCPConfig) *htt
 		if tok, _ := store.GetToken(cfg.Name); tok != nil {
 			return &http.Client{
 				Transport: &oauthTransport{
-					base:          http.DefaultTransport,
+					base:          base,
 					staticHeaders: staticHeaders,
 					reg:           r,
 					serverName:    cfg.Name,
 				},
-			}
+			}, nil
 		}
 	}
 
-	// No OAuth: static headers only, or the default client when there are none.
+	// No OAuth: static headers only, or just the Retry-After recorder.
 	if len(staticHeaders) == 0 {
-		return nil
+		return &http.Client{Transport: base}, nil
 	}
 	return &http.Client{
 		Transport: &headersTransport{
-			base:    http.DefaultTransport,
+			base:    base,
 			headers: staticHeaders,
 		},
-	}
+	}, nil
 }
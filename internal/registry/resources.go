@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// ResourceReadResult is the converted result of reading an upstream resource.
+// Contents holds one entry per ResourceContents item, converted with the same
+// rules as tool-result embedded resources (contentItemToAny).
+type ResourceReadResult struct {
+	MCPName  string `json:"mcp_name"`
+	URI      string `json:"uri"`
+	Contents []any  `json:"contents"`
+}
+
+// ReadResource reads a resource from an MCP, connecting it on demand. When
+// vars is non-empty, uri is treated as an RFC 6570 resource template (as
+// advertised in ResourceTemplateInfo.URITemplate) and expanded first.
+func (r *Registry) ReadResource(ctx context.Context, mcpName, uri string, vars map[string]any) (*ResourceReadResult, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("uri is required")
+	}
+	if len(vars) > 0 {
+		expanded, err := expandResourceTemplate(uri, vars)
+		if err != nil {
+			return nil, err
+		}
+		uri = expanded
+	}
+
+	session, done, err := r.lazySession(ctx, mcpName)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if init := session.InitializeResult(); init != nil && init.Capabilities != nil && init.Capabilities.Resources == nil {
+		return nil, fmt.Errorf("MCP %s does not expose resources", mcpName)
+	}
+
+	result, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		if isConnectionError(err) {
+			r.markConnectionFailed(mcpName, err)
+			return nil, fmt.Errorf("MCP %s connection lost reading resource '%s': %w", mcpName, uri, err)
+		}
+		return nil, fmt.Errorf("error reading resource '%s' on '%s': %w", uri, mcpName, err)
+	}
+
+	out := &ResourceReadResult{MCPName: mcpName, URI: uri, Contents: make([]any, 0, len(result.Contents))}
+	for _, rc := range result.Contents {
+		out.Contents = append(out.Contents, contentItemToAny(&mcp.EmbeddedResource{Resource: rc}))
+	}
+	return out, nil
+}
+
+// lazySession returns the live session for an MCP, lazily connecting cached,
+// configured, and retry-eligible errored MCPs the same way callRaw does.
+// The session is marked in use until the returned release is called. An open
+// circuit fails fast, as in callRaw.
+func (r *Registry) lazySession(ctx context.Context, mcpName string) (*mcp.ClientSession, func(), error) {
+	probe, err := r.breakerAllow(mcpName)
+	if err != nil {
+		return nil, nil, err
+	}
+	endProbe := func() {}
+	if probe {
+		endProbe = func() { r.breakerRelease(mcpName) }
+	}
+
+	state := r.GetState(mcpName)
+	switch state {
+	case StateCached, StateConfigured, StateConnecting, StateError:
+		if err := r.EnsureConnected(ctx, mcpName); err != nil {
+			endProbe()
+			return nil, nil, fmt.Errorf("lazy-connect failed for MCP %s: %w", mcpName, err)
+		}
+		state = r.GetState(mcpName)
+	}
+
+	conn, done, ok := r.useConnection(mcpName)
+	if !ok || conn.session == nil {
+		done()
+		endProbe()
+		if state != "" {
+			return nil, nil, fmt.Errorf("MCP %s is in state %s", mcpName, state)
+		}
+		return nil, nil, &MCPNotFoundError{
+			Name:          mcpName,
+			AvailableMCPs: r.listNames(),
+		}
+	}
+	return conn.session, func() { done(); endProbe() }, nil
+}
+
+// expandResourceTemplate expands an RFC 6570 URI template. Every variable the
+// template names must be supplied, and unknown variables are rejected so a
+// typo cannot silently expand to an empty path segment.
+func expandResourceTemplate(tmpl string, vars map[string]any) (string, error) {
+	t, err := uritemplate.New(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid resource template %q: %w", tmpl, err)
+	}
+
+	names := t.Varnames()
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+		if _, ok := vars[name]; !ok {
+			return "", fmt.Errorf("resource template %q requires variable %q", tmpl, name)
+		}
+	}
+
+	var unknown []string
+	for name := range vars {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("unknown variable(s) %s for resource template %q (expected: %s)",
+			strings.Join(unknown, ", "), tmpl, strings.Join(names, ", "))
+	}
+
+	values := uritemplate.Values{}
+	for name, v := range vars {
+		values.Set(name, templateValue(v))
+	}
+	return t.Expand(values)
+}
+
+// templateValue converts a decoded JSON value into a URI template value:
+// arrays become lists, objects become key/value pairs, scalars become strings.
+func templateValue(v any) uritemplate.Value {
+	switch x := v.(type) {
+	case string:
+		return uritemplate.String(x)
+	case []any:
+		items := make([]string, 0, len(x))
+		for _, el := range x {
+			items = append(items, fmt.Sprint(el))
+		}
+		return uritemplate.List(items...)
+	case []string:
+		return uritemplate.List(x...)
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kv := make([]string, 0, 2*len(keys))
+		for _, k := range keys {
+			kv = append(kv, k, fmt.Sprint(x[k]))
+		}
+		return uritemplate.KV(kv...)
+	default:
+		return uritemplate.String(fmt.Sprint(x))
+	}
+}
@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResourceServer() *mcp.Server {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "resources", Version: "1.0.0"}, nil)
+	srv.AddResource(&mcp.Resource{URI: "file:///readme.md", Name: "readme", MIMEType: "text/markdown"},
+		func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+				{URI: req.Params.URI, MIMEType: "text/markdown", Text: "# hello"},
+			}}, nil
+		})
+	srv.AddResourceTemplate(&mcp.ResourceTemplate{URITemplate: "db://tables/{table}/rows/{id}", Name: "row"},
+		func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+				{URI: req.Params.URI, MIMEType: "application/octet-stream", Blob: []byte{0x01, 0x02}},
+			}}, nil
+		})
+	return srv
+}
+
+func TestReadResource_Text(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "docs", Type: "stdio", Command: "x"}, newResourceServer())
+
+	res, err := r.ReadResource(context.Background(), "docs", "file:///readme.md", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "docs", res.MCPName)
+	assert.Equal(t, "file:///readme.md", res.URI)
+	require.Len(t, res.Contents, 1)
+	assert.Equal(t, map[string]any{
+		"type":     "resource",
+		"uri":      "file:///readme.md",
+		"mimeType": "text/markdown",
+		"text":     "# hello",
+	}, res.Contents[0])
+}
+
+func TestReadResource_TemplateExpansion(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "db", Type: "stdio", Command: "x"}, newResourceServer())
+
+	res, err := r.ReadResource(context.Background(), "db", "db://tables/{table}/rows/{id}", map[string]any{
+		"table": "users",
+		"id":    float64(42),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "db://tables/users/rows/42", res.URI)
+	require.Len(t, res.Contents, 1)
+	item := res.Contents[0].(map[string]any)
+	assert.Equal(t, []byte{0x01, 0x02}, item["blob"])
+	assert.NotContains(t, item, "text")
+}
+
+func TestReadResource_NotFoundMCP(t *testing.T) {
+	r := New()
+	_, err := r.ReadResource(context.Background(), "missing", "file:///x", nil)
+	require.Error(t, err)
+	var notFound *MCPNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestReadResource_RequiresURI(t *testing.T) {
+	r := New()
+	_, err := r.ReadResource(context.Background(), "docs", "", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "uri is required")
+}
+
+func TestReadResource_NoResourcesCapability(t *testing.T) {
+	r := New()
+	srv := mcp.NewServer(&mcp.Implementation{Name: "tools-only", Version: "1.0.0"}, nil)
+	installInMemorySession(t, r, config.MCPConfig{Name: "plain", Type: "stdio", Command: "x"}, srv)
+
+	_, err := r.ReadResource(context.Background(), "plain", "file:///x", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not expose resources")
+}
+
+func TestExpandResourceTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		vars    map[string]any
+		want    string
+		wantErr string
+	}{
+		{name: "simple", tmpl: "file:///{path}", vars: map[string]any{"path": "a b"}, want: "file:///a%20b"},
+		{name: "reserved", tmpl: "file:///{+path}", vars: map[string]any{"path": "dir/file.txt"}, want: "file:///dir/file.txt"},
+		{name: "list", tmpl: "tags://{?tags*}", vars: map[string]any{"tags": []any{"a", "b"}}, want: "tags://?tags=a&tags=b"},
+		{name: "number", tmpl: "issue://{n}", vars: map[string]any{"n": float64(7)}, want: "issue://7"},
+		{name: "missing", tmpl: "file:///{path}", vars: map[string]any{"other": "x"}, wantErr: `requires variable "path"`},
+		{name: "unknown", tmpl: "file:///{path}", vars: map[string]any{"path": "x", "extra": "y"}, wantErr: "unknown variable(s) extra"},
+		{name: "invalid", tmpl: "file:///{path", vars: map[string]any{"path": "x"}, wantErr: "invalid resource template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandResourceTemplate(tt.tmpl, tt.vars)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), tt.wantErr), "error %q should contain %q", err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHasTool(t *testing.T) {
+	r := New()
+	r.AddToolsForTesting("docs", []ToolInfo{{Name: "search", MCPName: "docs"}})
+	assert.True(t, r.HasTool("docs", "search"))
+	assert.False(t, r.HasTool("docs", "read_resource"))
+	assert.False(t, r.HasTool("other", "search"))
+}
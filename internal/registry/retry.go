@@ -0,0 +1,253 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// Retry policy defaults, used when an MCP's retry node leaves them unset.
+const (
+	DefaultRetryAttempts   = 3
+	DefaultRetryBackoff    = 500 * time.Millisecond
+	DefaultRetryMaxBackoff = 10 * time.Second
+)
+
+// ToolTimeoutError is returned when a call outlives its tool's configured
+// timeout. The MCP is notified to cancel the call and is not marked failed.
+type ToolTimeoutError struct {
+	MCPName  string
+	ToolName string
+	Timeout  time.Duration
+}
+
+func (e *ToolTimeoutError) Error() string {
+	return fmt.Sprintf("tool '%s' on '%s' timed out after %s (the MCP was notified to cancel it)", e.ToolName, e.MCPName, e.Timeout)
+}
+
+// GetToolTimeout returns the per-call timeout for toolName on cfg's MCP: the
+// first matching tool node's timeout, else the MCP's tool_timeout, else 0
+// (no timeout of its own).
+func GetToolTimeout(cfg config.MCPConfig, toolName string) time.Duration {
+	if t, ok := cfg.ToolSettings(toolName); ok && t.Timeout != "" {
+		if d, err := time.ParseDuration(t.Timeout); err == nil && d > 0 {
+			return d
+		}
+	}
+	if cfg.ToolTimeout != "" {
+		if d, err := time.ParseDuration(cfg.ToolTimeout); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// ToolTimeout returns the configured per-call timeout for toolName on
+// mcpName, or 0 when none is configured.
+func (r *Registry) ToolTimeout(mcpName, toolName string) time.Duration {
+	r.mu.RLock()
+	cfg := r.policyFor(mcpName)
+	r.mu.RUnlock()
+	return GetToolTimeout(cfg, toolName)
+}
+
+// retryPolicy is the resolved retry behavior for one tool.
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	connection bool
+	rateLimit  bool
+}
+
+// retryPolicyFor resolves cfg's retry policy for toolName. Tools not marked
+// idempotent, and MCPs without a retry node, get a single attempt.
+func retryPolicyFor(cfg config.MCPConfig, toolName string) retryPolicy {
+	t, ok := cfg.ToolSettings(toolName)
+	if !ok || !t.Idempotent || cfg.Retry == nil {
+		return retryPolicy{attempts: 1}
+	}
+	rc := cfg.Retry
+	p := retryPolicy{
+		attempts:   DefaultRetryAttempts,
+		backoff:    DefaultRetryBackoff,
+		maxBackoff: DefaultRetryMaxBackoff,
+	}
+	if rc.MaxAttempts > 0 {
+		p.attempts = rc.MaxAttempts
+	}
+	if d, err := time.ParseDuration(rc.Backoff); err == nil && d >= 0 {
+		p.backoff = d
+	}
+	if d, err := time.ParseDuration(rc.MaxBackoff); err == nil && d >= 0 {
+		p.maxBackoff = d
+	}
+	if len(rc.On) == 0 {
+		p.connection, p.rateLimit = true, true
+	}
+	for _, class := range rc.On {
+		switch class {
+		case config.RetryOnConnection:
+			p.connection = true
+		case config.RetryOnRateLimit:
+			p.rateLimit = true
+		}
+	}
+	return p
+}
+
+// retryable reports whether err falls in one of the policy's retry-on
+// classes. Errors the registry raises itself (open circuit, full queue,
+// timeouts, blocked tools) never do.
+func (p retryPolicy) retryable(err error) bool {
+	var (
+		open    *CircuitOpenError
+		queue   *QueueTimeoutError
+		timeout *ToolTimeoutError
+	)
+	if errors.As(err, &open) || errors.As(err, &queue) || errors.As(err, &timeout) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if p.rateLimit && isRateLimitError(err) {
+		return true
+	}
+	return p.connection && isConnectionError(err)
+}
+
+// delay returns how long to wait before attempt+1: the backoff doubled per
+// earlier retry, or the server's Retry-After if longer, capped at maxBackoff.
+func (p retryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	d := p.backoff
+	for i := 1; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	if retryAfter > d {
+		d = retryAfter
+	}
+	return min(d, p.maxBackoff)
+}
+
+// RateLimitError is an HTTP 429 from an MCP. retryAfterTransport returns it
+// in place of the response, so the status survives the SDK's error wrapping
+// and is matched by type rather than by message text.
+type RateLimitError struct {
+	MCPName    string
+	RetryAfter time.Duration // 0 when the response had no usable Retry-After
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("MCP %s is rate limiting requests (HTTP 429, retry after %s)", e.MCPName, e.RetryAfter)
+	}
+	return fmt.Sprintf("MCP %s is rate limiting requests (HTTP 429)", e.MCPName)
+}
+
+// isRateLimitError reports whether err is an HTTP 429 from the MCP.
+func isRateLimitError(err error) bool {
+	var rl *RateLimitError
+	return errors.As(err, &rl)
+}
+
+// callWithRetry runs call, retrying per cfg's retry policy for toolName.
+func (r *Registry) callWithRetry(ctx context.Context, cfg config.MCPConfig, toolName string, call func() error) error {
+	policy := retryPolicyFor(cfg, toolName)
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= policy.attempts || ctx.Err() != nil || !policy.retryable(err) {
+			return err
+		}
+		wait := policy.delay(attempt, r.takeRetryAfter(cfg.Name))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		r.allowReconnect(cfg.Name)
+		r.logger.Debug("retrying idempotent tool call",
+			"mcp_name", cfg.Name, "tool_name", toolName, "attempt", attempt+1, "wait", wait, "error", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// allowReconnect lifts the ErrorRetryInterval wait of an MCP whose
+// connection just failed, so a retry reconnects instead of failing fast.
+func (r *Registry) allowReconnect(mcpName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if st, ok := r.states[mcpName]; ok && st.state == StateError {
+		st.lastFailure = time.Time{}
+	}
+}
+
+// retryAfterTransport turns an MCP's 429 responses into a RateLimitError and
+// records their Retry-After so the retry policy can honor it.
+type retryAfterTransport struct {
+	base    http.RoundTripper
+	reg     *Registry
+	mcpName string
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	rl := &RateLimitError{MCPName: t.mcpName}
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		rl.RetryAfter = d
+		t.reg.noteRetryAfter(t.mcpName, d)
+	}
+	return nil, rl
+}
+
+func (t *retryAfterTransport) CloseIdleConnections() { closeIdleConnections(t.base) }
+
+// parseRetryAfter parses a Retry-After header: delay seconds or an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// noteRetryAfter remembers the latest Retry-After mcpName sent.
+func (r *Registry) noteRetryAfter(mcpName string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.retryAfter == nil {
+		r.retryAfter = make(map[string]time.Duration)
+	}
+	r.retryAfter[mcpName] = d
+}
+
+// takeRetryAfter returns and clears the Retry-After mcpName last sent.
+func (r *Registry) takeRetryAfter(mcpName string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.retryAfter[mcpName]
+	delete(r.retryAfter, mcpName)
+	return d
+}
@@ -0,0 +1,167 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetToolTimeout(t *testing.T) {
+	cfg := config.MCPConfig{
+		ToolTimeout: "1m",
+		Tools: []config.ToolConfig{
+			{Pattern: "deploy_*", Timeout: "15m"},
+			{Pattern: "deploy_prod"},
+		},
+	}
+	assert.Equal(t, 15*time.Minute, GetToolTimeout(cfg, "deploy_prod"), "first matching tool node wins")
+	assert.Equal(t, time.Minute, GetToolTimeout(cfg, "list_jobs"))
+	assert.Zero(t, GetToolTimeout(config.MCPConfig{}, "anything"))
+}
+
+func TestToolTimeout_BoundsTheCall(t *testing.T) {
+	r := New()
+	entered := make(chan string, 1)
+	cfg := config.MCPConfig{
+		Name: "fragile", Type: "stdio", Command: "x",
+		Tools: []config.ToolConfig{{Pattern: "hold", Timeout: "50ms"}},
+	}
+	installInMemorySession(t, r, cfg, gateServer(entered, make(chan struct{})))
+
+	_, err := r.ExecuteToolRaw(context.Background(), "fragile", "hold", map[string]any{"id": "a"})
+	var te *ToolTimeoutError
+	require.ErrorAs(t, err, &te)
+	assert.Equal(t, 50*time.Millisecond, te.Timeout)
+	assert.Equal(t, StateConnected, r.GetState("fragile"), "a timeout is not a connection failure")
+}
+
+func TestRetryPolicy_OnlyIdempotentTools(t *testing.T) {
+	cfg := config.MCPConfig{
+		Name:  "api",
+		Tools: []config.ToolConfig{{Pattern: "get_*", Idempotent: true}},
+		Retry: &config.RetryConfig{MaxAttempts: 4, Backoff: "1ms"},
+	}
+	assert.Equal(t, 4, retryPolicyFor(cfg, "get_user").attempts)
+	assert.Equal(t, 1, retryPolicyFor(cfg, "create_user").attempts, "not marked idempotent")
+
+	noRetry := cfg
+	noRetry.Retry = nil
+	assert.Equal(t, 1, retryPolicyFor(noRetry, "get_user").attempts, "retry is opt-in")
+}
+
+func TestCallWithRetry(t *testing.T) {
+	r := New()
+	cfg := config.MCPConfig{
+		Name:  "api",
+		Tools: []config.ToolConfig{{Pattern: "*", Idempotent: true}},
+		Retry: &config.RetryConfig{MaxAttempts: 3, Backoff: "1ms", On: []string{config.RetryOnConnection}},
+	}
+	failing := func(errs ...error) (func() error, *int) {
+		calls := 0
+		return func() error {
+			calls++
+			if calls <= len(errs) {
+				return errs[calls-1]
+			}
+			return nil
+		}, &calls
+	}
+
+	call, calls := failing(io.EOF, io.EOF)
+	require.NoError(t, r.callWithRetry(context.Background(), cfg, "get", call))
+	assert.Equal(t, 3, *calls)
+
+	call, calls = failing(io.EOF, io.EOF, io.EOF)
+	require.ErrorIs(t, r.callWithRetry(context.Background(), cfg, "get", call), io.EOF)
+	assert.Equal(t, 3, *calls, "max_attempts counts the first call")
+
+	call, calls = failing(errors.New("Too Many Requests"))
+	require.Error(t, r.callWithRetry(context.Background(), cfg, "get", call))
+	assert.Equal(t, 1, *calls, "rate_limit is not in this policy's classes")
+
+	call, calls = failing(&CircuitOpenError{MCPName: "api", LastErr: io.EOF})
+	require.Error(t, r.callWithRetry(context.Background(), cfg, "get", call))
+	assert.Equal(t, 1, *calls, "an open circuit is never retried")
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := retryPolicy{backoff: 100 * time.Millisecond, maxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, p.delay(1, 0))
+	assert.Equal(t, 400*time.Millisecond, p.delay(3, 0))
+	assert.Equal(t, time.Second, p.delay(10, 0))
+	assert.Equal(t, 700*time.Millisecond, p.delay(1, 700*time.Millisecond), "Retry-After wins when longer")
+	assert.Equal(t, time.Second, p.delay(1, time.Hour), "capped at max_backoff")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	d, ok := parseRetryAfter("7", now)
+	require.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(3*time.Second).Format(http.TimeFormat), now)
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	assert.True(t, isRateLimitError(fmt.Errorf("calling tool: %w", &RateLimitError{MCPName: "api"})))
+	for _, msg := range []string{
+		"dial tcp 127.0.0.1:4290: connect: connection refused",
+		"no record with id 14293",
+		"parse error on line 429",
+		"Too Many Requests",
+	} {
+		assert.False(t, isRateLimitError(errors.New(msg)), msg)
+	}
+}
+
+func TestRetry_HTTP429(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "api", Version: "1.0.0"}, nil)
+	mcp.AddTool(srv, &mcp.Tool{Name: "get_user"}, func(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ada"}}}, nil, nil
+	})
+	mcpHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil)
+	var limited atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if strings.Contains(string(body), `"tools/call"`) && limited.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		mcpHandler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(ts.Close)
+
+	r := New()
+	t.Cleanup(func() { _ = r.Close() })
+	cfg := config.MCPConfig{
+		Name: "api", Type: "streamable", URL: ts.URL,
+		Tools: []config.ToolConfig{{Pattern: "get_*", Idempotent: true}},
+		Retry: &config.RetryConfig{Backoff: "1ms", On: []string{config.RetryOnRateLimit}},
+	}
+	require.NoError(t, r.Connect(context.Background(), cfg))
+
+	res, err := r.ExecuteToolRaw(context.Background(), "api", "get_user", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ada", res.Content[0].(*mcp.TextContent).Text)
+	assert.Equal(t, int32(2), limited.Load(), "the 429 was retried once")
+}
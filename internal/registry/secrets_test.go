@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnect_ResolvesEnvReferences(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	t.Setenv("SLOP_TEST_API_KEY", "k-123")
+	r := New()
+	cfg := config.MCPConfig{
+		Name:    "envy",
+		Type:    "stdio",
+		Command: "sh",
+		Args:    []string{"-c", `echo "key=$API_KEY" >&2; exit 1`},
+		Env:     map[string]string{"API_KEY": "${env:SLOP_TEST_API_KEY}"},
+	}
+	require.Error(t, r.Connect(context.Background(), cfg))
+
+	lines, err := r.StderrLines("envy", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key=k-123"}, lines, "the subprocess sees the resolved value")
+	assert.Equal(t, "${env:SLOP_TEST_API_KEY}", r.states["envy"].config.Env["API_KEY"], "the stored config keeps the reference")
+}
+
+func TestConnect_UnresolvableReferenceFails(t *testing.T) {
+	r := New()
+	err := r.Connect(context.Background(), config.MCPConfig{
+		Name:    "envy",
+		Type:    "stdio",
+		Command: "true",
+		Env:     map[string]string{"API_KEY": "${env:SLOP_TEST_DEFINITELY_UNSET}"},
+	})
+	assert.ErrorContains(t, err, "MCP envy env API_KEY: environment variable SLOP_TEST_DEFINITELY_UNSET is not set")
+	assert.Equal(t, StateError, r.GetState("envy"))
+
+	err = r.Connect(context.Background(), config.MCPConfig{
+		Name:    "api",
+		Type:    "streamable",
+		URL:     "http://127.0.0.1:1/mcp",
+		Headers: map[string]string{"Authorization": "Bearer ${env:SLOP_TEST_DEFINITELY_UNSET}"},
+	})
+	assert.ErrorContains(t, err, "MCP api header Authorization: environment variable")
+}
+
+func TestConnect_ResolvesHeaderReferences(t *testing.T) {
+	t.Setenv("SLOP_TEST_TOKEN", "tok-456")
+	srv := mcp.NewServer(&mcp.Implementation{Name: "api", Version: "1.0.0"}, nil)
+	mcpHandler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil)
+	var authorized atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") == "Bearer tok-456" {
+			authorized.Add(1)
+		}
+		mcpHandler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(ts.Close)
+
+	r := New()
+	t.Cleanup(func() { _ = r.Close() })
+	require.NoError(t, r.Connect(context.Background(), config.MCPConfig{
+		Name:    "secret-headers-api",
+		Type:    "streamable",
+		URL:     ts.URL,
+		Headers: map[string]string{"Authorization": "Bearer ${env:SLOP_TEST_TOKEN}"},
+	}))
+	assert.Positive(t, authorized.Load())
+}
@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// installInMemorySession connects a client to srv over in-memory transports
+// and installs the resulting session as a connected MCP named cfg.Name,
+// bypassing connectLocked but mirroring what it installs: the registry's
+// client options, the tool list (indexed), and the prompt list. Both ends
+// are closed on test cleanup.
+func installInMemorySession(t *testing.T, r *Registry, cfg config.MCPConfig, srv *mcp.Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	ss, err := srv.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "slop-mcp-test", Version: "test"}, r.clientOptions(cfg))
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cs.Close()
+		_ = ss.Close()
+	})
+
+	tools, err := fetchTools(ctx, cfg.Name, cs)
+	if err != nil {
+		t.Fatalf("list tools: %v", err)
+	}
+	prompts, err := fetchPrompts(ctx, cfg.Name, cs)
+	if err != nil {
+		t.Fatalf("list prompts: %v", err)
+	}
+
+	r.mu.Lock()
+	r.connections[cfg.Name] = &mcpConnection{session: cs, config: cfg, prompts: prompts}
+	r.states[cfg.Name] = &mcpState{
+		config:       cfg,
+		state:        StateConnected,
+		toolsIndexed: true,
+	}
+	r.tools[cfg.Name] = tools
+	r.mu.Unlock()
+	r.rebuildIndex()
+	return cs
+}
@@ -0,0 +1,243 @@
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// DefaultStderrLines is how many stderr lines are kept per stdio MCP unless
+// its stderr_lines says otherwise.
+const DefaultStderrLines = 200
+
+// stderrTailLines is how many of the last stderr lines are quoted in
+// connection and protocol errors.
+const stderrTailLines = 10
+
+// maxStderrLine caps one buffered line, so a subprocess that writes without
+// newlines cannot grow the buffer without bound.
+const maxStderrLine = 4096
+
+// stderrWaitDelay bounds how long a stdio MCP's exit waits for its stderr to
+// close. Captured stderr is copied through a pipe, and a process the MCP
+// forked can hold that pipe open long after the MCP itself is gone.
+const stderrWaitDelay = time.Second
+
+// stderrLog keeps the last lines a stdio MCP wrote to stderr in a ring, and
+// optionally appends everything to a log file. It outlives reconnects, so
+// the output of a crashed subprocess is still there after it is restarted.
+type stderrLog struct {
+	mu      sync.Mutex
+	lines   []string // ring of complete lines
+	next    int      // slot the next line goes to once the ring is full
+	max     int
+	partial []byte // unterminated tail of the last write
+	tee     *os.File
+	teePath string
+}
+
+func newStderrLog(max int) *stderrLog {
+	return &stderrLog{max: max}
+}
+
+// Write records p; it never fails, so a full log file cannot stall the
+// subprocess writing to the pipe.
+func (l *stderrLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tee != nil {
+		_, _ = l.tee.Write(p)
+	}
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			l.partial = append(l.partial, p...)
+			break
+		}
+		l.partial = append(l.partial, p[:i]...)
+		l.addLocked(string(bytes.TrimSuffix(l.partial, []byte("\r"))))
+		l.partial = l.partial[:0]
+		p = p[i+1:]
+	}
+	for len(l.partial) > maxStderrLine {
+		l.addLocked(string(l.partial[:maxStderrLine]))
+		l.partial = append(l.partial[:0], l.partial[maxStderrLine:]...)
+	}
+	return n, nil
+}
+
+func (l *stderrLog) addLocked(line string) {
+	if len(l.lines) < l.max {
+		l.lines = append(l.lines, line)
+		return
+	}
+	l.lines[l.next] = line
+	l.next = (l.next + 1) % l.max
+}
+
+// Tail returns up to the last n lines, oldest first, including a trailing
+// line not yet ended by a newline. n <= 0 returns everything buffered.
+func (l *stderrLog) Tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	all := make([]string, 0, len(l.lines)+1)
+	all = append(all, l.lines[l.next:]...)
+	all = append(all, l.lines[:l.next]...)
+	if len(l.partial) > 0 {
+		all = append(all, string(l.partial))
+	}
+	if n > 0 && len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return all
+}
+
+// resize changes the ring capacity, keeping the newest lines.
+func (l *stderrLog) resize(max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if max == l.max {
+		return
+	}
+	lines := append(append([]string(nil), l.lines[l.next:]...), l.lines[:l.next]...)
+	if len(lines) > max {
+		lines = lines[len(lines)-max:]
+	}
+	l.lines, l.next, l.max = lines, 0, max
+}
+
+// setTee starts appending to path (closing any previous file); an empty
+// path stops teeing.
+func (l *stderrLog) setTee(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if path == l.teePath {
+		return nil
+	}
+	if l.tee != nil {
+		_ = l.tee.Close()
+		l.tee, l.teePath = nil, ""
+	}
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	l.tee, l.teePath = f, path
+	return nil
+}
+
+// GetStderrLines returns how many stderr lines are kept for cfg's MCP.
+func GetStderrLines(cfg config.MCPConfig) int {
+	if cfg.StderrLines > 0 {
+		return cfg.StderrLines
+	}
+	return DefaultStderrLines
+}
+
+// stderrFor returns the stderr sink for cfg's subprocess, creating it on
+// first use and applying any change to stderr_lines or stderr_log.
+func (r *Registry) stderrFor(cfg config.MCPConfig) *stderrLog {
+	r.mu.Lock()
+	if r.stderrLogs == nil {
+		r.stderrLogs = make(map[string]*stderrLog)
+	}
+	l, ok := r.stderrLogs[cfg.Name]
+	if !ok {
+		l = newStderrLog(GetStderrLines(cfg))
+		r.stderrLogs[cfg.Name] = l
+	}
+	r.mu.Unlock()
+
+	l.resize(GetStderrLines(cfg))
+	if err := l.setTee(cfg.StderrLog); err != nil {
+		r.logger.Warn("cannot open stderr log", "mcp_name", cfg.Name, "path", cfg.StderrLog, "error", err)
+	}
+	return l
+}
+
+// dropStderrLocked forgets name's stderr buffer and closes its log file.
+// The caller must hold r.mu for writing.
+func (r *Registry) dropStderrLocked(name string) {
+	if l, ok := r.stderrLogs[name]; ok {
+		_ = l.setTee("")
+		delete(r.stderrLogs, name)
+	}
+}
+
+// StderrLines returns up to the last n lines a stdio MCP wrote to stderr,
+// oldest first; n <= 0 returns everything buffered. An MCP that has not
+// been started yet has no lines.
+func (r *Registry) StderrLines(name string, n int) ([]string, error) {
+	r.mu.RLock()
+	st, ok := r.states[name]
+	l := r.stderrLogs[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, &MCPNotFoundError{Name: name, AvailableMCPs: r.listNames()}
+	}
+	switch st.config.Type {
+	case "command", "stdio", "":
+	default:
+		return nil, fmt.Errorf("MCP %s uses the %s transport; only stdio MCPs have stderr logs", name, st.config.Type)
+	}
+	if l == nil {
+		return []string{}, nil
+	}
+	return l.Tail(n), nil
+}
+
+// stderrTail returns the last few stderr lines of mcpName, or nil when it
+// has none.
+func (r *Registry) stderrTail(mcpName string) []string {
+	r.mu.RLock()
+	l := r.stderrLogs[mcpName]
+	r.mu.RUnlock()
+	if l == nil {
+		return nil
+	}
+	return l.Tail(stderrTailLines)
+}
+
+// withStderr appends the last stderr lines of mcpName to err, so a crashed
+// subprocess explains itself in the error the caller sees.
+func (r *Registry) withStderr(mcpName string, err error) error {
+	lines := r.stderrTail(mcpName)
+	if len(lines) == 0 {
+		return err
+	}
+	return &stderrError{err: err, mcpName: mcpName, lines: lines}
+}
+
+// stderrError is an error annotated with the stderr tail of its MCP.
+type stderrError struct {
+	err     error
+	mcpName string
+	lines   []string
+}
+
+func (e *stderrError) Error() string {
+	return e.err.Error() + formatStderrTail(e.mcpName, e.lines)
+}
+
+func (e *stderrError) Unwrap() error { return e.err }
+
+// formatStderrTail renders stderr lines for the end of an error message.
+func formatStderrTail(mcpName string, lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\nLast stderr lines from MCP %s:\n  %s", mcpName, strings.Join(lines, "\n  "))
+}
@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStderrLog_RingKeepsNewestLines(t *testing.T) {
+	l := newStderrLog(3)
+	_, _ = l.Write([]byte("one\ntwo\r\nthr"))
+	assert.Equal(t, []string{"one", "two", "thr"}, l.Tail(0), "an unterminated line is still shown")
+
+	_, _ = l.Write([]byte("ee\nfour\nfive\n"))
+	assert.Equal(t, []string{"three", "four", "five"}, l.Tail(0))
+	assert.Equal(t, []string{"four", "five"}, l.Tail(2))
+
+	l.resize(2)
+	assert.Equal(t, []string{"four", "five"}, l.Tail(0))
+	l.resize(5)
+	_, _ = l.Write([]byte("six\n"))
+	assert.Equal(t, []string{"four", "five", "six"}, l.Tail(0))
+}
+
+func TestStderrLog_SplitsOverlongLines(t *testing.T) {
+	l := newStderrLog(10)
+	_, _ = l.Write([]byte(strings.Repeat("x", maxStderrLine+10)))
+	lines := l.Tail(0)
+	require.Len(t, lines, 2)
+	assert.Len(t, lines[0], maxStderrLine)
+	assert.Len(t, lines[1], 10)
+}
+
+func TestStderrLog_TeesToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "mcp.log")
+	l := newStderrLog(10)
+	require.NoError(t, l.setTee(path))
+	_, _ = l.Write([]byte("hello\n"))
+	require.NoError(t, l.setTee(""))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(data))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestConnect_StderrInErrorAndLogs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	logPath := filepath.Join(t.TempDir(), "crashy.log")
+	r := New()
+	cfg := config.MCPConfig{
+		Name:      "crashy",
+		Type:      "stdio",
+		Command:   "sh",
+		Args:      []string{"-c", "echo 'starting up' >&2; echo 'fatal: missing API key' >&2; exit 1"},
+		StderrLog: logPath,
+	}
+
+	err := r.Connect(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fatal: missing API key")
+	assert.Contains(t, err.Error(), "Last stderr lines from MCP crashy")
+
+	lines, err := r.StderrLines("crashy", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"starting up", "fatal: missing API key"}, lines)
+	lines, err = r.StderrLines("crashy", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fatal: missing API key"}, lines)
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "fatal: missing API key")
+
+	require.NoError(t, r.Unregister(context.Background(), "crashy"))
+	_, err = r.StderrLines("crashy", 0)
+	var notFound *MCPNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+// TestStdioMCPHelper is not a real test: run as a subprocess with
+// SLOP_TEST_STDIO_MCP set, it serves an MCP with an echo tool over stdio.
+func TestStdioMCPHelper(t *testing.T) {
+	if os.Getenv("SLOP_TEST_STDIO_MCP") == "" {
+		t.Skip("helper process")
+	}
+	srv := mcp.NewServer(&mcp.Implementation{Name: "helper", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "echo")
+	_ = srv.Run(context.Background(), &mcp.StdioTransport{})
+	os.Exit(0)
+}
+
+func TestDisconnect_ForkedProcessHoldsStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	t.Setenv("SLOP_TEST_STDIO_MCP", "1")
+	r := New()
+	// The backgrounded sleep inherits the MCP's stderr pipe and outlives it.
+	cfg := config.MCPConfig{
+		Name:    "forky",
+		Type:    "stdio",
+		Command: "sh",
+		Args:    []string{"-c", `sleep 30 >/dev/null & exec "$0" -test.run='^TestStdioMCPHelper$'`, os.Args[0]},
+	}
+	require.NoError(t, r.Connect(context.Background(), cfg))
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- r.Disconnect(context.Background(), "forky") }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Disconnect waited for the forked process to close stderr")
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestStderrLines_OnlyStdio(t *testing.T) {
+	r := New()
+	installInMemorySession(t, r, config.MCPConfig{Name: "api", Type: "streamable", URL: "http://example.invalid"}, newProgressServer(nil, nil))
+	_, err := r.StderrLines("api", 0)
+	assert.ErrorContains(t, err, "only stdio MCPs")
+
+	installInMemorySession(t, r, config.MCPConfig{Name: "local", Type: "stdio", Command: "x"}, newProgressServer(nil, nil))
+	lines, err := r.StderrLines("local", 0)
+	require.NoError(t, err)
+	assert.Empty(t, lines, "not started through a subprocess yet")
+}
+
+func TestMCPProtocolError_IncludesStderr(t *testing.T) {
+	err := parseProtocolError("m", "t", fmt.Errorf("Invalid params"))
+	require.NotNil(t, err)
+	err.Stderr = []string{"TypeError: x is undefined"}
+	assert.Contains(t, err.Error(), "Last stderr lines from MCP m:\n  TypeError: x is undefined")
+}
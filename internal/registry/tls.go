@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// BuildTLSConfig loads the tls settings of an MCP, checking each file the
+// way mcp doctor does: the CA bundle must hold at least one certificate, and
+// the client certificate must match its key and be within its validity
+// period. The CAs are trusted in addition to the system roots. It returns
+// nil for an MCP without tls settings.
+func BuildTLSConfig(cfg config.MCPConfig) (*tls.Config, error) {
+	tc := cfg.TLS
+	if tc == nil {
+		return nil, nil
+	}
+	out := &tls.Config{
+		ServerName:         tc.ServerName,
+		InsecureSkipVerify: tc.InsecureSkipVerify,
+	}
+
+	if tc.CA != "" {
+		path, err := cfg.ResolvePath(tc.CA)
+		if err != nil {
+			return nil, fmt.Errorf("MCP %s tls ca: %w", cfg.Name, err)
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("MCP %s tls ca: %w", cfg.Name, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("MCP %s tls ca %s: no PEM certificates found", cfg.Name, path)
+		}
+		out.RootCAs = pool
+	}
+
+	if tc.Cert != "" {
+		certPath, err := cfg.ResolvePath(tc.Cert)
+		if err != nil {
+			return nil, fmt.Errorf("MCP %s tls cert: %w", cfg.Name, err)
+		}
+		keyPath, err := cfg.ResolvePath(tc.Key)
+		if err != nil {
+			return nil, fmt.Errorf("MCP %s tls key: %w", cfg.Name, err)
+		}
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("MCP %s tls cert %s and key %s: %w", cfg.Name, certPath, keyPath, err)
+		}
+		if leaf := cert.Leaf; leaf != nil {
+			now := time.Now()
+			if now.After(leaf.NotAfter) {
+				return nil, fmt.Errorf("MCP %s tls cert %s expired on %s", cfg.Name, certPath, leaf.NotAfter.Format(time.DateOnly))
+			}
+			if now.Before(leaf.NotBefore) {
+				return nil, fmt.Errorf("MCP %s tls cert %s is not valid until %s", cfg.Name, certPath, leaf.NotBefore.Format(time.DateOnly))
+			}
+		}
+		out.Certificates = []tls.Certificate{cert}
+	}
+	return out, nil
+}
+
+// sharedTransport is a transport many MCPs use. It hides
+// CloseIdleConnections, so tearing down one MCP's client leaves the pooled
+// connections of the others alone.
+type sharedTransport struct{ http.RoundTripper }
+
+// closeIdleConnections closes the idle connections of rt, unless it is
+// shared.
+func closeIdleConnections(rt http.RoundTripper) {
+	if c, ok := rt.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// baseTransport returns the transport under an MCP's HTTP client: HTTP/1.1
+// only for a websocket MCP, dialing the socket for a unix MCP, and
+// http.DefaultTransport otherwise. A unix MCP, and an MCP with tls or proxy
+// settings, gets a transport of its own, whose idle connections the client's
+// CloseIdleConnections closes; the others get a sharedTransport.
+func baseTransport(cfg config.MCPConfig) (http.RoundTripper, error) {
+	base := http.DefaultTransport.(*http.Transport)
+	switch cfg.Type {
+	case "websocket":
+		base = http1Transport()
+	case "unix":
+		base = unixHTTPTransport(cfg.Socket)
+	}
+	if cfg.TLS == nil && cfg.Proxy == "" {
+		if cfg.Type == "unix" {
+			return base, nil
+		}
+		return sharedTransport{base}, nil
+	}
+	t := base.Clone()
+
+	if cfg.TLS != nil {
+		tlsCfg, err := BuildTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if t.TLSClientConfig != nil {
+			// Keep the ALPN restriction of the websocket transport.
+			tlsCfg.NextProtos = t.TLSClientConfig.NextProtos
+		}
+		t.TLSClientConfig = tlsCfg
+	}
+
+	switch cfg.Proxy {
+	case "":
+	case config.ProxyNone:
+		t.Proxy = nil
+	default:
+		u, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("MCP %s proxy: %w", cfg.Name, err)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	return t, nil
+}
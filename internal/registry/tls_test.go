@@ -0,0 +1,254 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPKI is a private CA with a server certificate for "mcp.internal" and
+// a client certificate, all written as PEM files to dir.
+type testPKI struct {
+	dir    string
+	pool   *x509.CertPool
+	server tls.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage, notAfter time.Time) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-2 * time.Hour),
+			NotAfter:     notAfter,
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		return der, key
+	}
+	writeKeyPair := func(base string, der []byte, key *ecdsa.PrivateKey) {
+		writePEM(t, filepath.Join(dir, base+".pem"), "CERTIFICATE", der)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		writePEM(t, filepath.Join(dir, base+".key"), "EC PRIVATE KEY", keyDER)
+	}
+
+	serverDER, serverKey := issue(2, "mcp.internal", x509.ExtKeyUsageServerAuth, time.Now().Add(time.Hour))
+	clientDER, clientKey := issue(3, "client", x509.ExtKeyUsageClientAuth, time.Now().Add(time.Hour))
+	expiredDER, expiredKey := issue(4, "client", x509.ExtKeyUsageClientAuth, time.Now().Add(-time.Hour))
+	writeKeyPair("client", clientDER, clientKey)
+	writeKeyPair("expired", expiredDER, expiredKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	return &testPKI{
+		dir:    dir,
+		pool:   pool,
+		server: tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey},
+	}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+}
+
+func TestTLS_MutualTLSWithPrivateCA(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := mcp.NewServer(&mcp.Implementation{Name: "corp", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	ts := httptest.NewUnstartedServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.server},
+		ClientCAs:    pki.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0) // the rejected handshakes below
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	r, _ := newTestRegistryWithCache(t)
+	t.Cleanup(func() { _ = r.Close() })
+	cfg := config.MCPConfig{
+		Name:    "corp",
+		Type:    "streamable",
+		URL:     ts.URL,
+		Timeout: "5s",
+		File:    filepath.Join(pki.dir, "config.kdl"),
+		// Relative to the config file; the server certificate names
+		// mcp.internal, not the 127.0.0.1 the URL dials.
+		TLS: &config.TLSConfig{CA: "ca.pem", Cert: "client.pem", Key: "client.key", ServerName: "mcp.internal"},
+	}
+	require.NoError(t, r.Connect(context.Background(), cfg))
+	assert.True(t, r.HasTool("corp", "hello"))
+
+	noCert := cfg
+	noCert.Name = "corp-nocert"
+	noCert.TLS = &config.TLSConfig{CA: "ca.pem", ServerName: "mcp.internal"}
+	assert.Error(t, r.Connect(context.Background(), noCert), "the server requires a client certificate")
+
+	noCA := cfg
+	noCA.Name = "corp-noca"
+	noCA.TLS = &config.TLSConfig{Cert: "client.pem", Key: "client.key", ServerName: "mcp.internal"}
+	assert.Error(t, r.Connect(context.Background(), noCA), "the private CA is not a system root")
+}
+
+func TestBuildTLSConfig_ChecksFiles(t *testing.T) {
+	pki := newTestPKI(t)
+	notPEM := filepath.Join(pki.dir, "notes.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+	base := config.MCPConfig{Name: "corp", File: filepath.Join(pki.dir, "config.kdl")}
+
+	tlsCfg, err := BuildTLSConfig(base)
+	require.NoError(t, err)
+	assert.Nil(t, tlsCfg, "no tls settings")
+
+	for _, tt := range []struct {
+		tls  config.TLSConfig
+		want string
+	}{
+		{config.TLSConfig{CA: "missing.pem"}, "missing.pem"},
+		{config.TLSConfig{CA: "notes.txt"}, "no PEM certificates found"},
+		{config.TLSConfig{Cert: "client.pem", Key: "expired.key"}, "private key does not match"},
+		{config.TLSConfig{Cert: "expired.pem", Key: "expired.key"}, "expired on"},
+	} {
+		cfg := base
+		tc := tt.tls
+		cfg.TLS = &tc
+		_, err := BuildTLSConfig(cfg)
+		require.Error(t, err, "%+v", tt.tls)
+		assert.Contains(t, err.Error(), tt.want)
+		assert.Contains(t, err.Error(), "MCP corp tls")
+	}
+
+	cfg := base
+	cfg.TLS = &config.TLSConfig{CA: "ca.pem", Cert: "client.pem", Key: "client.key", ServerName: "mcp.internal"}
+	tlsCfg, err = BuildTLSConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "mcp.internal", tlsCfg.ServerName)
+	assert.Len(t, tlsCfg.Certificates, 1)
+	assert.NotNil(t, tlsCfg.RootCAs)
+}
+
+func TestBaseTransport_ProxySettings(t *testing.T) {
+	rt, err := baseTransport(config.MCPConfig{Name: "plain", Type: "http"})
+	require.NoError(t, err)
+	assert.Equal(t, sharedTransport{http.DefaultTransport}, rt, "no settings share the default transport")
+
+	req, _ := http.NewRequest(http.MethodGet, "https://mcp.example.com/mcp", nil)
+	rt, err = baseTransport(config.MCPConfig{Name: "p", Type: "http", Proxy: "http://proxy.example:3128"})
+	require.NoError(t, err)
+	u, err := rt.(*http.Transport).Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.example:3128", u.Host)
+
+	t.Setenv("HTTPS_PROXY", "http://env-proxy.example:3128")
+	rt, err = baseTransport(config.MCPConfig{Name: "direct", Type: "http", Proxy: config.ProxyNone})
+	require.NoError(t, err)
+	assert.Nil(t, rt.(*http.Transport).Proxy, "none ignores the environment")
+
+	rt, err = baseTransport(config.MCPConfig{Name: "ws", Type: "websocket", Proxy: config.ProxyNone, TLS: &config.TLSConfig{ServerName: "mcp.internal"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http/1.1"}, rt.(*http.Transport).TLSClientConfig.NextProtos, "websocket stays on HTTP/1.1")
+}
+
+func TestProxy_RoutesMCPTraffic(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "far", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv },
+		&mcp.StreamableHTTPOptions{DisableLocalhostProtection: true})
+	hosts := make(chan string, 16)
+	// A forward proxy receives absolute-form requests; this one answers
+	// them itself instead of forwarding.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case hosts <- r.URL.Host:
+		default:
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	r, _ := newTestRegistryWithCache(t)
+	t.Cleanup(func() { _ = r.Close() })
+	// .invalid never resolves, so the connect only works through the proxy.
+	host := net.JoinHostPort("mcp.invalid", "80")
+	require.NoError(t, r.Connect(context.Background(), config.MCPConfig{
+		Name: "far", Type: "streamable", URL: "http://" + host + "/mcp", Proxy: proxy.URL,
+	}))
+	assert.True(t, r.HasTool("far", "hello"))
+	assert.Equal(t, host, <-hosts)
+}
+
+func TestDisconnect_ClosesOwnTransportConnections(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "far", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	ts := httptest.NewUnstartedServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil))
+	var open atomic.Int32
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			open.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			open.Add(-1)
+		}
+	}
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	r, _ := newTestRegistryWithCache(t)
+	t.Cleanup(func() { _ = r.Close() })
+	// proxy none gives the MCP a transport of its own.
+	require.NoError(t, r.Connect(context.Background(), config.MCPConfig{
+		Name: "far", Type: "streamable", URL: ts.URL, Proxy: config.ProxyNone,
+	}))
+	assert.Positive(t, open.Load())
+
+	require.NoError(t, r.Disconnect(context.Background(), "far"))
+	assert.Eventually(t, func() bool { return open.Load() == 0 }, 2*time.Second, 10*time.Millisecond,
+		"the transport's idle connections are closed with the session")
+}
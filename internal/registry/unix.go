@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// unixSocketTransport connects to an MCP listening on a unix domain socket
+// that speaks newline-delimited JSON-RPC, as a stdio MCP does on its pipes.
+type unixSocketTransport struct {
+	path string
+}
+
+func (t *unixSocketTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", t.path)
+	if err != nil {
+		return nil, err
+	}
+	// The connection is closed once, through the reader.
+	return (&mcp.IOTransport{Reader: conn, Writer: nopWriteCloser{conn}}).Connect(ctx)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// unixHTTPTransport is http.DefaultTransport with every connection dialed to
+// the unix socket at path, so a URL's host only fills in the Host header.
+func unixHTTPTransport(path string) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	}
+	return t
+}
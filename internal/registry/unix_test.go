@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/cache"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenUnix listens on a fresh socket in a temp dir and returns its path.
+func listenUnix(t *testing.T) (net.Listener, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	return ln, path
+}
+
+func TestUnixTransport_NewlineDelimitedJSONRPC(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "daemon", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	ln, path := listenUnix(t)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				ss, err := srv.Connect(context.Background(), &mcp.IOTransport{Reader: conn, Writer: conn}, nil)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				_ = ss.Wait()
+			}()
+		}
+	}()
+
+	r, store := newTestRegistryWithCache(t)
+	t.Cleanup(func() { _ = r.Close() })
+	cfg := config.MCPConfig{Name: "daemon", Type: "unix", Socket: path}
+	r.SetConfigured(cfg)
+	require.NoError(t, r.EnsureConnected(context.Background(), "daemon"))
+
+	result, err := r.ExecuteToolRawJSON(context.Background(), "daemon", "hello", nil)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "hello", result.Content[0].(*mcp.TextContent).Text)
+
+	checks := r.HealthCheck(context.Background(), "daemon")
+	require.Len(t, checks, 1)
+	assert.Equal(t, HealthStatusHealthy, checks[0].Status)
+
+	entry, err := store.GetEntry("daemon")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, cache.ConfigHash(cfg), entry.ConfigHash)
+	assert.Len(t, entry.Tools, 1)
+}
+
+func TestUnixTransport_StreamableHTTP(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "daemon", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	ln, path := listenUnix(t)
+	auths := make(chan string, 8)
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil)
+	hs := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case auths <- r.Header.Get("Authorization"):
+		default:
+		}
+		handler.ServeHTTP(w, r)
+	})}
+	go func() { _ = hs.Serve(ln) }()
+	t.Cleanup(func() { _ = hs.Close() })
+
+	r, _ := newTestRegistryWithCache(t)
+	t.Cleanup(func() { _ = r.Close() })
+	cfg := config.MCPConfig{
+		Name:    "daemon",
+		Type:    "unix",
+		Socket:  path,
+		URL:     "http://daemon/mcp",
+		Headers: map[string]string{"Authorization": "Bearer abc"},
+	}
+	require.NoError(t, r.Connect(context.Background(), cfg))
+	assert.Equal(t, "Bearer abc", <-auths, "config headers are sent over the socket")
+	assert.True(t, r.HasTool("daemon", "hello"))
+
+	checks := r.HealthCheck(context.Background(), "daemon")
+	require.Len(t, checks, 1)
+	assert.Equal(t, HealthStatusHealthy, checks[0].Status)
+}
+
+func TestUnixTransport_Errors(t *testing.T) {
+	r, _ := newTestRegistryWithCache(t)
+	t.Cleanup(func() { _ = r.Close() })
+
+	err := r.Connect(context.Background(), config.MCPConfig{Name: "nosock", Type: "unix"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a socket path")
+
+	path := filepath.Join(t.TempDir(), "missing.sock")
+	err = r.Connect(context.Background(), config.MCPConfig{Name: "missing", Type: "unix", Socket: path, Timeout: "1s"})
+	require.Error(t, err)
+	assert.Equal(t, StateError, r.GetState("missing"))
+}
@@ -0,0 +1,145 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/websocket"
+)
+
+// http1Transport is http.DefaultTransport restricted to HTTP/1.1, which the
+// WebSocket upgrade handshake needs.
+var http1Transport = sync.OnceValue(func() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = false
+	t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.NextProtos = []string{"http/1.1"}
+	return t
+})
+
+// websocketTransport connects to an MCP that speaks JSON-RPC over WebSocket,
+// one message per frame. The upgrade request is sent through client, so it
+// carries the MCP's headers and OAuth token like the HTTP transports do.
+type websocketTransport struct {
+	url    string
+	client *http.Client
+	conn   *websocketConn // set by Connect
+}
+
+func (t *websocketTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	ws, err := websocket.Dial(ctx, t.client, t.url, websocket.MCPSubprotocol)
+	if err != nil {
+		return nil, err
+	}
+	c := &websocketConn{ws: ws, pongs: make(chan struct{}, 1)}
+	ws.SetPongHandler(func([]byte) {
+		select {
+		case c.pongs <- struct{}{}:
+		default:
+		}
+	})
+	t.conn = c
+	return c, nil
+}
+
+// websocketConn adapts a WebSocket connection to mcp.Connection.
+type websocketConn struct {
+	ws    *websocket.Conn
+	pongs chan struct{}
+}
+
+func (c *websocketConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := c.ws.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	return jsonrpc.DecodeMessage(data)
+}
+
+func (c *websocketConn) Write(_ context.Context, msg jsonrpc.Message) error {
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return err
+	}
+	return c.ws.WriteMessage(data)
+}
+
+func (c *websocketConn) Close() error { return c.ws.Close() }
+
+func (c *websocketConn) SessionID() string { return "" }
+
+// errWebSocketClosed reports a websocket MCP connection that closed.
+var errWebSocketClosed = errors.New("websocket connection closed")
+
+// keepalive pings the server every interval until the connection closes.
+// Each pong is reported to alive. The end of the connection is reported to
+// lost: a close by either side, a ping that cannot be sent, or a ping that
+// gets no pong within timeout, which also closes the connection.
+func (c *websocketConn) keepalive(interval, timeout time.Duration, alive func(), lost func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ws.Done():
+			lost(errWebSocketClosed)
+			return
+		case <-ticker.C:
+		}
+		select {
+		case <-c.pongs: // a stale pong from before this ping
+		default:
+		}
+		if err := c.ws.Ping(nil); err != nil {
+			c.ws.Close()
+			lost(fmt.Errorf("websocket keepalive ping failed: %w", err))
+			return
+		}
+		timer := time.NewTimer(timeout)
+		select {
+		case <-c.pongs:
+			timer.Stop()
+			alive()
+		case <-c.ws.Done():
+			timer.Stop()
+			lost(errWebSocketClosed)
+			return
+		case <-timer.C:
+			c.ws.Close()
+			lost(fmt.Errorf("websocket keepalive: connection closed after no pong within %s", timeout))
+			return
+		}
+	}
+}
+
+// watchWebSocket starts the keepalive of a websocket MCP's live session.
+// Pongs record the MCP as healthy, as a passing health check would. A lost
+// connection records it as unhealthy and marks it for reconnect, unless the
+// session was already replaced or disconnected (which closes it too).
+func (r *Registry) watchWebSocket(name string, session *mcp.ClientSession, conn *websocketConn) {
+	go conn.keepalive(DefaultWebSocketKeepalive, DefaultHealthCheckTimeout,
+		func() {
+			if r.isLiveSession(name, session) {
+				r.updateHealthState(name, HealthStatusHealthy, time.Now(), nil)
+			}
+		},
+		func(err error) {
+			if !r.isLiveSession(name, session) {
+				return
+			}
+			r.markConnectionFailed(name, err)
+			r.updateHealthState(name, HealthStatusUnhealthy, time.Now(), err)
+		})
+}
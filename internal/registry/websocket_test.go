@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acceptedTransport serves an mcp.Server on an accepted WebSocket.
+type acceptedTransport struct{ ws *websocket.Conn }
+
+func (t acceptedTransport) Connect(context.Context) (mcp.Connection, error) {
+	return &websocketConn{ws: t.ws, pongs: make(chan struct{}, 1)}, nil
+}
+
+// newWebSocketMCP serves srv over WebSocket and returns its ws:// URL and a
+// channel that receives each server-side connection and the upgrade
+// request's Authorization header.
+func newWebSocketMCP(t *testing.T, srv *mcp.Server) (string, <-chan *websocket.Conn, <-chan string) {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 4)
+	auths := make(chan string, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, websocket.MCPSubprotocol)
+		if err != nil {
+			return
+		}
+		auths <- r.Header.Get("Authorization")
+		conns <- ws
+		ss, err := srv.Connect(r.Context(), acceptedTransport{ws}, nil)
+		if err != nil {
+			ws.Close()
+			return
+		}
+		_ = ss.Wait()
+	}))
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http"), conns, auths
+}
+
+func TestWebSocketTransport_ConnectsAndCallsTools(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "ws", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	url, _, auths := newWebSocketMCP(t, srv)
+
+	r := NewWithLogger(logging.Nop())
+	t.Cleanup(func() { _ = r.Close() })
+	cfg := config.MCPConfig{Name: "ws", Type: "websocket", URL: url, Headers: map[string]string{"Authorization": "Bearer abc"}}
+	require.NoError(t, r.Connect(context.Background(), cfg))
+	assert.Equal(t, "Bearer abc", <-auths, "config headers are sent with the upgrade request")
+	assert.True(t, r.HasTool("ws", "hello"))
+
+	result, err := r.ExecuteToolRawJSON(context.Background(), "ws", "hello", nil)
+	require.NoError(t, err)
+	require.Len(t, result.Content, 1)
+	assert.Equal(t, "hello", result.Content[0].(*mcp.TextContent).Text)
+}
+
+func TestWebSocketTransport_ServerCloseMarksConnectionLost(t *testing.T) {
+	srv := mcp.NewServer(&mcp.Implementation{Name: "ws", Version: "1.0.0"}, nil)
+	addEchoTool(srv, "hello")
+	url, conns, _ := newWebSocketMCP(t, srv)
+
+	r := NewWithLogger(logging.Nop())
+	t.Cleanup(func() { _ = r.Close() })
+	require.NoError(t, r.Connect(context.Background(), config.MCPConfig{Name: "ws", Type: "websocket", URL: url, MaxRetries: -1}))
+	require.Equal(t, StateConnected, r.GetState("ws"))
+
+	(<-conns).Close()
+	require.Eventually(t, func() bool { return r.GetState("ws") == StateError }, 5*time.Second, 10*time.Millisecond)
+	status, _, _ := r.GetHealthStatus("ws")
+	assert.Equal(t, HealthStatusUnhealthy, status)
+}
+
+func TestWebSocketKeepalive(t *testing.T) {
+	answering := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		// Answer pings until told to stop reading.
+		go func() { <-answering; ws.Close() }()
+		for {
+			if _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+
+	tr := &websocketTransport{url: ts.URL, client: &http.Client{Transport: http1Transport()}}
+	_, err := tr.Connect(context.Background())
+	require.NoError(t, err)
+
+	// The session's read loop is what delivers pongs.
+	go func() {
+		for {
+			if _, err := tr.conn.ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	alive := make(chan struct{}, 8)
+	lost := make(chan error, 1)
+	go tr.conn.keepalive(10*time.Millisecond, time.Second,
+		func() {
+			select {
+			case alive <- struct{}{}:
+			default:
+			}
+		},
+		func(err error) { lost <- err })
+
+	select {
+	case <-alive:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no pong reported")
+	}
+	close(answering)
+	select {
+	case err := <-lost:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("lost connection not reported")
+	}
+}
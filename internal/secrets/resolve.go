@@ -0,0 +1,168 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Resolver expands references in config values:
+//
+//	${env:NAME}     the environment variable NAME
+//	${file:PATH}    the contents of PATH, without trailing newlines; ~ is the home directory
+//	${secret:NAME}  the secret NAME from the encrypted store
+//
+// Any other "${...}", such as a bare ${HOME} meant for a shell, is left as
+// is. "$${" is a literal "${". A reference that cannot be resolved is an
+// error, never an empty string, so a missing credential fails the connect
+// loudly.
+type Resolver struct {
+	Secrets *Store // nil uses NewStore()
+}
+
+// Expand resolves the references in s with the default store.
+func Expand(s string) (string, error) {
+	return (&Resolver{}).Expand(s)
+}
+
+// ExpandMap resolves the references in every value of m with the default store.
+func ExpandMap(m map[string]string) (map[string]string, error) {
+	return (&Resolver{}).ExpandMap(m)
+}
+
+// referenceKinds are the prefixes of the references Expand resolves.
+var referenceKinds = []string{"env:", "file:", "secret:"}
+
+// hasKind reports whether s, the text after a "${", starts with a reference
+// kind.
+func hasKind(s string) bool {
+	for _, kind := range referenceKinds {
+		if strings.HasPrefix(s, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasReference reports whether s contains a reference Expand would resolve.
+func HasReference(s string) bool {
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			return false
+		}
+		if (i == 0 || s[i-1] != '$') && hasKind(s[i+2:]) && strings.IndexByte(s[i:], '}') > 0 {
+			return true
+		}
+		s = s[i+2:]
+	}
+}
+
+// IsReference reports whether s is exactly one reference and nothing else.
+func IsReference(s string) bool {
+	return strings.HasPrefix(s, "${") && hasKind(s[2:]) && strings.IndexByte(s, '}') == len(s)-1
+}
+
+// Expand resolves the references in s.
+func (r *Resolver) Expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			sb.WriteString(s[:i-1])
+			sb.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+		if !hasKind(s[i+2:]) {
+			sb.WriteString(s[:i+2])
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference %q", s[i:])
+		}
+		v, err := r.lookup(s[i+2 : i+end])
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(s[:i])
+		sb.WriteString(v)
+		s = s[i+end+1:]
+	}
+}
+
+// ExpandMap resolves the references in every value of m. The error names the
+// key whose value failed.
+func (r *Resolver) ExpandMap(m map[string]string) (map[string]string, error) {
+	if len(m) == 0 {
+		return m, nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		resolved, err := r.Expand(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = resolved
+	}
+	return out, nil
+}
+
+func (r *Resolver) lookup(ref string) (string, error) {
+	kind, arg, _ := strings.Cut(ref, ":")
+	if arg == "" {
+		return "", fmt.Errorf("empty reference ${%s}", ref)
+	}
+	switch kind {
+	case "env":
+		v, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set (referenced as ${%s})", arg, ref)
+		}
+		return v, nil
+	case "file":
+		path, err := expandHome(arg)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading ${%s}: %w", ref, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "secret":
+		store := r.Secrets
+		if store == nil {
+			store = NewStore()
+		}
+		v, err := store.Get(arg)
+		if err != nil {
+			return "", fmt.Errorf("resolving ${%s}: %w (set it with: slop-mcp secret set %s)", ref, err, arg)
+		}
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown reference ${%s}: use env, file, or secret", ref)
+	}
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("expanding %s: %w", path, err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Expand(t *testing.T) {
+	store := newTestStore(t)
+	require.NoError(t, store.Set("gh", "ghp_secret"))
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".secrets"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".secrets", "api"), []byte("file-value\n"), 0o600))
+	t.Setenv("SLOP_TEST_TOKEN", "env-value")
+
+	r := &Resolver{Secrets: store}
+	tests := map[string]string{
+		"plain":                               "plain",
+		"${env:SLOP_TEST_TOKEN}":              "env-value",
+		"Bearer ${env:SLOP_TEST_TOKEN}":       "Bearer env-value",
+		"${file:~/.secrets/api}":              "file-value",
+		"token ${secret:gh} end":              "token ghp_secret end",
+		"$${env:SLOP_TEST_TOKEN}":             "${env:SLOP_TEST_TOKEN}",
+		"${secret:gh}:${env:SLOP_TEST_TOKEN}": "ghp_secret:env-value",
+		// Without a kind, ${...} is not a reference and passes through.
+		"Bearer ${SLOP_TEST_TOKEN}":   "Bearer ${SLOP_TEST_TOKEN}",
+		"${HOME}/app:${secret:gh}":    "${HOME}/app:ghp_secret",
+		"${vault:x}":                  "${vault:x}",
+		"awk '{print ${1}}'; echo ${": "awk '{print ${1}}'; echo ${",
+	}
+	for in, want := range tests {
+		got, err := r.Expand(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+}
+
+func TestResolver_Errors(t *testing.T) {
+	r := &Resolver{Secrets: newTestStore(t)}
+	t.Setenv("SLOP_TEST_UNSET", "")
+	os.Unsetenv("SLOP_TEST_UNSET")
+
+	for in, want := range map[string]string{
+		"${env:SLOP_TEST_UNSET}":  "environment variable SLOP_TEST_UNSET is not set",
+		"${secret:missing}":       "slop-mcp secret set missing",
+		"${file:/does/not/exist}": "reading ${file:/does/not/exist}",
+		"${env:}":                 "empty reference",
+		"abc ${env:X":             "unterminated reference",
+	} {
+		_, err := r.Expand(in)
+		assert.ErrorContains(t, err, want, in)
+	}
+}
+
+func TestResolver_ExpandMapNamesKey(t *testing.T) {
+	r := &Resolver{Secrets: newTestStore(t)}
+	_, err := r.ExpandMap(map[string]string{"API_KEY": "${secret:nope}"})
+	assert.ErrorContains(t, err, "API_KEY: ")
+
+	out, err := r.ExpandMap(nil)
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+func TestHasAndIsReference(t *testing.T) {
+	assert.True(t, HasReference("Bearer ${secret:x}"))
+	assert.True(t, HasReference("${env:X}"))
+	assert.False(t, HasReference("${X}"))
+	assert.False(t, HasReference("${X} ${env:Y"))
+	assert.False(t, HasReference("plain"))
+	assert.False(t, HasReference("$${env:X}"))
+
+	assert.True(t, IsReference("${secret:x}"))
+	assert.False(t, IsReference("Bearer ${secret:x}"))
+	assert.False(t, IsReference("${a}${b}"))
+	assert.False(t, IsReference("${HOME}"))
+}
@@ -0,0 +1,276 @@
+// Package secrets keeps named secrets in an encrypted local file and expands
+// ${env:...}, ${file:...}, and ${secret:...} references in config values, so
+// a committed config can name a credential without containing it.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/standardbeagle/slop-mcp/internal/atomicfile"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/filelock"
+)
+
+// KeyEnvVar names an environment variable whose value, when set, is used to
+// derive the encryption key instead of the key file.
+const KeyEnvVar = "SLOP_MCP_SECRETS_KEY"
+
+// ErrNotFound is returned by Get for a name with no stored secret.
+var ErrNotFound = errors.New("secret not found")
+
+// Store is an encrypted file of named secrets. The file is sealed with
+// AES-256-GCM under a random key kept in a separate 0600 key file (or
+// derived from SLOP_MCP_SECRETS_KEY), so the secrets file alone reveals
+// nothing and the key can be held elsewhere.
+type Store struct {
+	path    string
+	keyPath string
+}
+
+// storeMu serializes secrets file access within the process; filelock does
+// the same across processes.
+var storeMu sync.Mutex
+
+// sealedFile is the on-disk form of the secrets file.
+type sealedFile struct {
+	Version int    `json:"version"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"` // AES-GCM sealed JSON object of name -> value
+}
+
+// NewStore returns the store in the user config directory.
+func NewStore() *Store {
+	dir := config.UserConfigDirPath()
+	if dir == "" {
+		return &Store{}
+	}
+	return &Store{
+		path:    filepath.Join(dir, "secrets.enc"),
+		keyPath: filepath.Join(dir, "secrets.key"),
+	}
+}
+
+// NewStoreWithPath returns a store backed by the given secrets and key files.
+func NewStoreWithPath(path, keyPath string) *Store {
+	return &Store{path: path, keyPath: keyPath}
+}
+
+// Path returns the secrets file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// KeyPath returns the key file path.
+func (s *Store) KeyPath() string {
+	return s.keyPath
+}
+
+// Get returns the secret stored under name, or ErrNotFound.
+func (s *Store) Get(name string) (string, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	m, err := s.loadUnlocked()
+	if err != nil {
+		return "", err
+	}
+	v, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return v, nil
+}
+
+// List returns the stored secret names, sorted.
+func (s *Store) List() ([]string, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	m, err := s.loadUnlocked()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Set stores value under name, replacing any previous value.
+func (s *Store) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	return s.update(func(m map[string]string) bool {
+		m[name] = value
+		return true
+	})
+}
+
+// Delete removes the secret stored under name; it reports whether there was one.
+func (s *Store) Delete(name string) (bool, error) {
+	var found bool
+	err := s.update(func(m map[string]string) bool {
+		_, found = m[name]
+		delete(m, name)
+		return found
+	})
+	return found, err
+}
+
+// ValidateName checks that name can be used in a ${secret:name} reference.
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("secret name is required")
+	}
+	if strings.ContainsAny(name, "{}$ \t\r\n") {
+		return fmt.Errorf("invalid secret name %q: must not contain braces, '$', or whitespace", name)
+	}
+	return nil
+}
+
+// update runs a load-modify-save cycle under both locks; fn reports whether
+// it changed anything worth saving.
+func (s *Store) update(fn func(map[string]string) bool) error {
+	if s.path == "" {
+		return fmt.Errorf("secrets store path not configured")
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	unlock, err := filelock.Lock(s.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unlock() }()
+
+	m, err := s.loadUnlocked()
+	if err != nil {
+		return err
+	}
+	if !fn(m) {
+		return nil
+	}
+	return s.saveUnlocked(m)
+}
+
+func (s *Store) loadUnlocked() (map[string]string, error) {
+	if s.path == "" {
+		return nil, fmt.Errorf("secrets store path not configured")
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sf sealedFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.path, err)
+	}
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, sf.Nonce, sf.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: wrong key or corrupted file", s.path)
+	}
+	m := make(map[string]string)
+	if err := json.Unmarshal(plain, &m); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.path, err)
+	}
+	return m, nil
+}
+
+func (s *Store) saveUnlocked(m map[string]string) error {
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sealedFile{
+		Version: 1,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0o600)
+}
+
+// cipher returns the AEAD for the store's key. With create set, a missing
+// key file is generated; otherwise it is an error.
+func (s *Store) cipher(create bool) (cipher.AEAD, error) {
+	key, err := s.key(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *Store) key(create bool) ([]byte, error) {
+	if v := os.Getenv(KeyEnvVar); v != "" {
+		sum := sha256.Sum256([]byte(v))
+		return sum[:], nil
+	}
+	if s.keyPath == "" {
+		return nil, fmt.Errorf("secrets key path not configured")
+	}
+	data, err := os.ReadFile(s.keyPath)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid secrets key in %s", s.keyPath)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if !create {
+		return nil, fmt.Errorf("secrets key %s not found; set %s or restore the key file", s.keyPath, KeyEnvVar)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), 0o700); err != nil {
+		return nil, err
+	}
+	if err := atomicfile.WriteFile(s.keyPath, []byte(hex.EncodeToString(key)+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
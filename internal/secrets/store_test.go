@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	t.Setenv(KeyEnvVar, "")
+	dir := t.TempDir()
+	return NewStoreWithPath(filepath.Join(dir, "secrets.enc"), filepath.Join(dir, "secrets.key"))
+}
+
+func TestStore_SetGetListDelete(t *testing.T) {
+	s := newTestStore(t)
+
+	names, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, names, "a missing file is an empty store")
+
+	require.NoError(t, s.Set("github_token", "ghp_abc123"))
+	require.NoError(t, s.Set("api_key", "k-1"))
+
+	v, err := s.Get("github_token")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_abc123", v)
+
+	names, err = s.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api_key", "github_token"}, names)
+
+	found, err := s.Delete("api_key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	_, err = s.Get("api_key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	found, err = s.Delete("api_key")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestStore_FileIsEncrypted(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.Set("token", "super-secret-value"))
+
+	data, err := os.ReadFile(s.Path())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "super-secret-value")
+	assert.NotContains(t, string(data), "token")
+
+	if runtime.GOOS != "windows" {
+		for _, p := range []string{s.Path(), s.KeyPath()} {
+			info, err := os.Stat(p)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), p)
+		}
+	}
+}
+
+func TestStore_WrongOrMissingKey(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.Set("token", "v"))
+
+	t.Setenv(KeyEnvVar, "some other key")
+	_, err := s.Get("token")
+	assert.ErrorContains(t, err, "wrong key")
+
+	t.Setenv(KeyEnvVar, "")
+	require.NoError(t, os.Remove(s.KeyPath()))
+	_, err = s.Get("token")
+	assert.ErrorContains(t, err, "not found")
+	assert.Error(t, s.Set("other", "v"), "a lost key must not be replaced over existing secrets")
+}
+
+func TestStore_KeyFromEnv(t *testing.T) {
+	s := newTestStore(t)
+	t.Setenv(KeyEnvVar, "passphrase")
+	require.NoError(t, s.Set("token", "v"))
+	_, err := os.Stat(s.KeyPath())
+	assert.True(t, os.IsNotExist(err), "no key file is written when the key comes from the environment")
+
+	other := NewStoreWithPath(s.Path(), filepath.Join(t.TempDir(), "unused.key"))
+	v, err := other.Get("token")
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+}
+
+func TestValidateName(t *testing.T) {
+	assert.NoError(t, ValidateName("github_token"))
+	assert.NoError(t, ValidateName("team/api-key"))
+	for _, bad := range []string{"", "a b", "a}", "${x}"} {
+		assert.Error(t, ValidateName(bad), bad)
+	}
+}
@@ -117,7 +117,11 @@ func (s *Server) handleCustomizeTools(
 	req *mcp.CallToolRequest,
 	in CustomizeToolsInput,
 ) (*mcp.CallToolResult, customizeToolsOutput, error) {
-	if s.overrideStore == nil {
+	store, err := s.overridesFor(ctx)
+	if err != nil {
+		return nil, customizeToolsOutput{}, err
+	}
+	if store == nil {
 		return nil, customizeToolsOutput{}, fmt.Errorf("override store not initialized")
 	}
 	// Fail fast on an invalid scope rather than silently writing to the wrong
@@ -125,29 +129,49 @@ func (s *Server) handleCustomizeTools(
 	if !overrides.IsValidScope(in.Scope) {
 		return nil, customizeToolsOutput{}, fmt.Errorf("invalid scope %q: must be one of user, project, local", in.Scope)
 	}
+	// An isolated HTTP session writes only its private local scope: the user
+	// and project scopes are files shared by every client.
+	if tenantFrom(ctx) != nil && customizeWrites(in.Action) {
+		switch overrides.Scope(in.Scope) {
+		case "":
+			in.Scope = string(overrides.ScopeLocal)
+		case overrides.ScopeLocal:
+		default:
+			return nil, customizeToolsOutput{}, fmt.Errorf("scope %s is shared by every client; isolated HTTP sessions can only write scope local", in.Scope)
+		}
+	}
 	switch in.Action {
 	case "set_override":
-		return s.customizeSetOverride(ctx, in)
+		return s.customizeSetOverride(ctx, store, in)
 	case "remove_override":
-		return s.customizeRemoveOverride(ctx, in)
+		return s.customizeRemoveOverride(ctx, store, in)
 	case "list_overrides":
-		return s.customizeListOverrides(ctx, in)
+		return s.customizeListOverrides(ctx, store, in)
 	case "define_custom":
-		return s.customizeDefineCustom(ctx, in)
+		return s.customizeDefineCustom(ctx, store, in)
 	case "remove_custom":
-		return s.customizeRemoveCustom(in)
+		return s.customizeRemoveCustom(store, in)
 	case "list_custom":
-		return s.customizeListCustom(ctx, in)
+		return s.customizeListCustom(ctx, store, in)
 	case "export":
-		return s.customizeExport(in)
+		return s.customizeExport(store, in)
 	case "import":
-		return s.customizeImport(in)
+		return s.customizeImport(store, in)
 	default:
 		return nil, customizeToolsOutput{}, fmt.Errorf("unknown action: %q", in.Action)
 	}
 }
 
-func (s *Server) customizeSetOverride(ctx context.Context, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+// customizeWrites reports whether a customize_tools action modifies the store.
+func customizeWrites(action string) bool {
+	switch action {
+	case "set_override", "remove_override", "define_custom", "remove_custom", "import":
+		return true
+	}
+	return false
+}
+
+func (s *Server) customizeSetOverride(ctx context.Context, store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
 	if in.MCP == "" {
 		return nil, customizeToolsOutput{}, fmt.Errorf("mcp is required for set_override")
 	}
@@ -181,7 +205,7 @@ func (s *Server) customizeSetOverride(ctx context.Context, in CustomizeToolsInpu
 		Params:      in.Params,
 		SourceHash:  hash,
 	}
-	if err := s.overrideStore.SetOverride(scope, in.MCP+"."+in.Tool, entry); err != nil {
+	if err := store.SetOverride(scope, in.MCP+"."+in.Tool, entry); err != nil {
 		return nil, customizeToolsOutput{}, fmt.Errorf("storing override: %w", err)
 	}
 
@@ -198,7 +222,7 @@ func (s *Server) customizeSetOverride(ctx context.Context, in CustomizeToolsInpu
 	return nil, out, nil
 }
 
-func (s *Server) customizeRemoveOverride(_ context.Context, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+func (s *Server) customizeRemoveOverride(_ context.Context, store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
 	if in.MCP == "" {
 		return nil, customizeToolsOutput{}, fmt.Errorf("mcp is required for remove_override")
 	}
@@ -206,7 +230,7 @@ func (s *Server) customizeRemoveOverride(_ context.Context, in CustomizeToolsInp
 		return nil, customizeToolsOutput{}, fmt.Errorf("tool is required for remove_override")
 	}
 
-	n, err := s.overrideStore.RemoveOverride(overrides.Scope(in.Scope), in.MCP+"."+in.Tool)
+	n, err := store.RemoveOverride(overrides.Scope(in.Scope), in.MCP+"."+in.Tool)
 	if err != nil {
 		return nil, customizeToolsOutput{}, fmt.Errorf("removing override: %w", err)
 	}
@@ -223,8 +247,8 @@ func (s *Server) customizeRemoveOverride(_ context.Context, in CustomizeToolsInp
 	return nil, out, nil
 }
 
-func (s *Server) customizeListOverrides(_ context.Context, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
-	all := s.overrideStore.ListOverrides()
+func (s *Server) customizeListOverrides(_ context.Context, store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+	all := store.ListOverrides()
 
 	type flatEntry struct {
 		key   string
@@ -293,7 +317,7 @@ func (s *Server) upstreamToolInfo(mcpName, toolName string) (string, map[string]
 			// The index applies override descriptions; UpstreamDescription
 			// returns the original server description so hashes computed from
 			// this value reflect the true upstream, not the override.
-			return t.UpstreamDescription(), extractParamDescs(t.InputSchema), nil
+			return t.UpstreamDescription(), overrides.ParamDescriptions(t.InputSchema), nil
 		}
 	}
 	return "", nil, fmt.Errorf("tool %q not found in MCP %q", toolName, mcpName)
@@ -330,7 +354,7 @@ func (s *Server) staleStatus(key, storedHash string) (string, string, map[string
 	return staleStatusFresh, "", nil
 }
 
-func (s *Server) customizeDefineCustom(_ context.Context, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+func (s *Server) customizeDefineCustom(_ context.Context, store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
 	if in.Name == "" {
 		return nil, customizeToolsOutput{}, fmt.Errorf("name is required for define_custom")
 	}
@@ -427,7 +451,7 @@ func (s *Server) customizeDefineCustom(_ context.Context, in CustomizeToolsInput
 		Body:        in.Body,
 		DependsOn:   deps,
 	}
-	if err := s.overrideStore.SetCustom(scope, in.Name, ct); err != nil {
+	if err := store.SetCustom(scope, in.Name, ct); err != nil {
 		return nil, customizeToolsOutput{}, fmt.Errorf("storing custom tool: %w", err)
 	}
 
@@ -512,12 +536,12 @@ func isSupportedCustomSchemaType(typ string) bool {
 	}
 }
 
-func (s *Server) customizeRemoveCustom(in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+func (s *Server) customizeRemoveCustom(store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
 	if in.Name == "" {
 		return nil, customizeToolsOutput{}, fmt.Errorf("name is required for remove_custom")
 	}
 
-	n, err := s.overrideStore.RemoveCustom(overrides.Scope(in.Scope), in.Name)
+	n, err := store.RemoveCustom(overrides.Scope(in.Scope), in.Name)
 	if err != nil {
 		return nil, customizeToolsOutput{}, fmt.Errorf("removing custom tool: %w", err)
 	}
@@ -532,8 +556,8 @@ func (s *Server) customizeRemoveCustom(in CustomizeToolsInput) (*mcp.CallToolRes
 	return nil, out, nil
 }
 
-func (s *Server) customizeListCustom(_ context.Context, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
-	all := s.overrideStore.ListCustom()
+func (s *Server) customizeListCustom(_ context.Context, store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+	all := store.ListCustom()
 
 	var entries []customToolEntry
 	for scope, m := range all {
@@ -599,14 +623,14 @@ func (s *Server) customizeListCustom(_ context.Context, in CustomizeToolsInput)
 	return nil, out, nil
 }
 
-func (s *Server) customizeExport(in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+func (s *Server) customizeExport(store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
 	sel := overrides.Selector{
 		MCP:           in.MCP,
 		Keys:          in.Keys,
 		IncludeCustom: in.IncludeCustom,
 		Scope:         overrides.Scope(in.Scope),
 	}
-	pack, err := s.overrideStore.Export(sel)
+	pack, err := store.Export(sel)
 	if err != nil {
 		return nil, customizeToolsOutput{}, err
 	}
@@ -619,7 +643,7 @@ func (s *Server) customizeExport(in CustomizeToolsInput) (*mcp.CallToolResult, c
 	return nil, out, nil
 }
 
-func (s *Server) customizeImport(in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
+func (s *Server) customizeImport(store *overrides.Store, in CustomizeToolsInput) (*mcp.CallToolResult, customizeToolsOutput, error) {
 	if in.Data == "" {
 		return nil, customizeToolsOutput{}, fmt.Errorf("data is required for import (JSON pack)")
 	}
@@ -631,7 +655,7 @@ func (s *Server) customizeImport(in CustomizeToolsInput) (*mcp.CallToolResult, c
 	if scope == "" {
 		scope = overrides.ScopeUser
 	}
-	rep, err := s.overrideStore.Import(pack, scope, in.Overwrite)
+	rep, err := store.Import(pack, scope, in.Overwrite)
 	if err != nil {
 		return nil, customizeToolsOutput{}, err
 	}
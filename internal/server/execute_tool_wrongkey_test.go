@@ -158,6 +158,8 @@ func TestToolWrappersReturnErrorForMissingParams(t *testing.T) {
 		{name: "slop_help", call: s.wrapSlopHelp},
 		{name: "agnt_watch", call: s.wrapAgntWatch},
 		{name: "customize_tools", call: s.wrapCustomizeTools},
+		{name: "read_resource", call: s.wrapReadResource},
+		{name: "get_prompt", call: s.wrapGetPrompt},
 	}
 
 	for _, tt := range tests {
@@ -60,6 +60,8 @@ type SearchToolsInput struct {
 	MCPName string `json:"mcp_name,omitempty" jsonschema:"Filter to a specific MCP server"`
 	Limit   int    `json:"limit,omitempty" jsonschema:"Maximum number of results to return (default: 20, max: 100)"`
 	Offset  int    `json:"offset,omitempty" jsonschema:"Number of results to skip for pagination (default: 0)"`
+	// IncludePrompts also searches prompts of connected MCPs.
+	IncludePrompts bool `json:"include_prompts,omitempty" jsonschema:"Also search MCP prompts"`
 }
 
 // SearchToolsOutput is the output for the search_tools tool.
@@ -69,6 +71,9 @@ type SearchToolsOutput struct {
 	Limit   int                 `json:"limit"`    // Limit applied
 	Offset  int                 `json:"offset"`   // Offset applied
 	HasMore bool                `json:"has_more"` // True if more results available
+	// Prompts holds matching MCP prompts when include_prompts is set (capped
+	// at limit; not paginated).
+	Prompts []registry.PromptInfo `json:"prompts,omitempty"`
 }
 
 // overrideView carries the resolved override state for a single tool.
@@ -83,51 +88,33 @@ type overrideView struct {
 
 // overrideFor returns the resolved override (if any) and stale status for a tool.
 // upstreamParams should be the property-level descriptions from the input schema.
-func (s *Server) overrideFor(mcpName, toolName, upstreamDesc string, upstreamParams map[string]string) overrideView {
-	if s.overrideStore == nil {
+func (s *Server) overrideFor(ctx context.Context, mcpName, toolName, upstreamDesc string, upstreamParams map[string]string) overrideView {
+	store, err := s.overridesFor(ctx)
+	if err != nil || store == nil {
 		return overrideView{}
 	}
-	entry, ok := s.overrideStore.GetOverride(mcpName + "." + toolName)
+	entry, ok := store.GetOverride(mcpName + "." + toolName)
 	if !ok {
 		return overrideView{}
 	}
-	currentHash := overrides.ComputeHash(upstreamDesc, upstreamParams)
 	return overrideView{
 		Description: entry.Description,
 		Params:      entry.Params,
 		Scope:       entry.Scope,
 		Hash:        entry.SourceHash,
-		Stale:       entry.SourceHash != currentHash,
+		Stale:       entry.Stale(upstreamDesc, upstreamParams),
 		HasOverride: true,
 	}
 }
 
-// extractParamDescs builds a map of param name → description from an input schema.
-func extractParamDescs(inputSchema map[string]any) map[string]string {
-	if inputSchema == nil {
-		return nil
-	}
-	props, ok := inputSchema["properties"].(map[string]any)
-	if !ok {
-		return nil
-	}
-	out := make(map[string]string, len(props))
-	for name, raw := range props {
-		if prop, ok := raw.(map[string]any); ok {
-			if desc, ok := prop["description"].(string); ok {
-				out[name] = desc
-			}
-		}
-	}
-	return out
-}
-
 func (s *Server) handleSearchTools(
 	ctx context.Context,
 	req *mcp.CallToolRequest,
 	input SearchToolsInput,
 ) (*mcp.CallToolResult, SearchToolsOutput, error) {
 	tools := s.registry.SearchTools(input.Query, input.MCPName)
+	tools = filterVisible(ctx, s, tools, func(t registry.ToolInfo) string { return t.MCPName })
+	tools = append(tools, s.tenantCustomTools(ctx, input.Query, input.MCPName)...)
 
 	// Include CLI tools if not filtering by specific MCP (or filtering by "cli")
 	if input.MCPName == "" || input.MCPName == "cli" {
@@ -145,14 +132,15 @@ func (s *Server) handleSearchTools(
 			})
 		}
 	}
+	tools = filterPermittedTools(ctx, tools)
 
 	// Apply overrides to tool descriptions / stale flags.
 	if s.overrideStore != nil {
 		for i, t := range tools {
-			upstreamParams := extractParamDescs(t.InputSchema)
+			upstreamParams := overrides.ParamDescriptions(t.InputSchema)
 			// t.Description may already carry the override (applied by buildIndex);
 			// hash against the true upstream description for stale detection.
-			ov := s.overrideFor(t.MCPName, t.Name, t.UpstreamDescription(), upstreamParams)
+			ov := s.overrideFor(ctx, t.MCPName, t.Name, t.UpstreamDescription(), upstreamParams)
 			if ov.HasOverride {
 				tools[i].Description = ov.Description
 				if ov.Stale {
@@ -199,12 +187,22 @@ func (s *Server) handleSearchTools(
 		tools[i].InputSchema = nil
 	}
 
+	var prompts []registry.PromptInfo
+	if input.IncludePrompts {
+		prompts = s.registry.SearchPrompts(input.Query, input.MCPName)
+		prompts = filterVisible(ctx, s, prompts, func(p registry.PromptInfo) string { return p.MCPName })
+		if len(prompts) > limit {
+			prompts = prompts[:limit]
+		}
+	}
+
 	return nil, SearchToolsOutput{
 		Tools:   tools,
 		Total:   total,
 		Limit:   limit,
 		Offset:  offset,
 		HasMore: offset+len(tools) < total,
+		Prompts: prompts,
 	}, nil
 }
 
@@ -238,7 +236,10 @@ func (s *Server) handleExecuteTool(
 	// Routing contract: custom tools are addressed explicitly via mcp_name
 	// "_custom" (mcp_name is always required, enforced above).
 	if s.overrideStore != nil && input.MCPName == "_custom" {
-		if ct, ok := s.overrideStore.GetCustom(input.ToolName); ok {
+		if err := s.checkToolAllowed(ctx, "_custom", input.ToolName); err != nil {
+			return nil, nil, err
+		}
+		if ct, ok := s.lookupCustom(ctx, input.ToolName); ok {
 			result, err := s.executeCustomTool(ctx, ct, input.Parameters)
 			if err != nil {
 				return errorResult(err), nil, nil
@@ -254,6 +255,9 @@ func (s *Server) handleExecuteTool(
 		if cli.IsCLITool(toolName) {
 			toolName = cli.StripCLIPrefix(toolName)
 		}
+		if err := s.checkToolAllowed(ctx, "cli", toolName); err != nil {
+			return nil, nil, err
+		}
 
 		result, err := s.cliRegistry.Execute(ctx, toolName, input.Parameters)
 		if err != nil {
@@ -267,6 +271,13 @@ func (s *Server) handleExecuteTool(
 		return nil, result, nil
 	}
 
+	if err := s.checkToolAllowed(ctx, input.MCPName, input.ToolName); err != nil {
+		return nil, nil, err
+	}
+	if s.hiddenByTenant(ctx, input.MCPName) {
+		return nil, nil, hiddenMCPError(input.MCPName)
+	}
+
 	result, err := s.registry.ExecuteTool(ctx, input.MCPName, input.ToolName, input.Parameters)
 	if err != nil {
 		return nil, nil, err
@@ -486,9 +497,11 @@ func (e *slopError) Error() string {
 
 // ManageMCPsInput is the input for the manage_mcps tool.
 type ManageMCPsInput struct {
-	Action  string            `json:"action" jsonschema:"Action to perform: register, unregister, reconnect, list, status, health_check, or list_stale_overrides"`
-	Name    string            `json:"name,omitempty" jsonschema:"MCP server name (required for register/unregister/reconnect, optional for health_check)"`
-	Type    string            `json:"type,omitempty" jsonschema:"Transport type: command (default), sse, or streamable"`
+	Action  string            `json:"action" jsonschema:"Action to perform: register, unregister, reconnect, list, status, health_check, list_stale_overrides, cache_inspect, cache_flush, or logs"`
+	Name    string            `json:"name,omitempty" jsonschema:"MCP server name (required for register/unregister/reconnect/logs, optional for health_check/cache_inspect/cache_flush)"`
+	Tool    string            `json:"tool,omitempty" jsonschema:"Tool name to limit cache_flush to (requires name)"`
+	Lines   int               `json:"lines,omitempty" jsonschema:"Number of most recent stderr lines for logs (default: all buffered)"`
+	Type    string            `json:"type,omitempty" jsonschema:"Transport type: command (default), sse, streamable, or websocket"`
 	Command string            `json:"command,omitempty" jsonschema:"Command executable for command transport"`
 	Args    []string          `json:"args,omitempty" jsonschema:"Command arguments"`
 	Env     map[string]string `json:"env,omitempty" jsonschema:"Environment variables"`
@@ -506,6 +519,8 @@ type ManageMCPsOutput struct {
 	HealthChecks []registry.HealthCheckResult `json:"health_checks,omitempty"`
 	Affected     int                          `json:"affected,omitempty"`
 	Entries      []any                        `json:"entries,omitempty"`
+	Results      []registry.CachedResultInfo  `json:"cached_results,omitempty"`
+	Logs         []string                     `json:"logs,omitempty"`
 }
 
 func (s *Server) handleManageMCPs(
@@ -542,6 +557,21 @@ func (s *Server) handleManageMCPs(
 			return nil, ManageMCPsOutput{}, fmt.Errorf("invalid scope: %s (must be memory, user, or project)", scope)
 		}
 
+		// An isolated session registers into its own namespace: config files
+		// are shared by every client, and names must not shadow another
+		// client's MCP (or a configured one) in the shared registry.
+		t := tenantFrom(ctx)
+		if t != nil {
+			if scope != "memory" {
+				return nil, ManageMCPsOutput{}, fmt.Errorf("scope %s writes config shared by every client; isolated HTTP sessions can only register with scope memory", scope)
+			}
+			s.tenants.registerMu.Lock()
+			defer s.tenants.registerMu.Unlock()
+			if s.registry.GetState(input.Name) != "" && !t.ownsMCP(input.Name) {
+				return nil, ManageMCPsOutput{}, fmt.Errorf("MCP %s already exists; register under a different name", input.Name)
+			}
+		}
+
 		cfg := config.MCPConfig{
 			Name:    input.Name,
 			Type:    transportType,
@@ -559,6 +589,9 @@ func (s *Server) handleManageMCPs(
 		if err := s.registry.Connect(ctx, cfg); err != nil {
 			return nil, ManageMCPsOutput{}, fmt.Errorf("failed to register MCP: %w", err)
 		}
+		if t != nil {
+			s.claimMCP(t, input.Name)
+		}
 
 		// Persist to file if not memory scope
 		if scope != "memory" {
@@ -593,9 +626,22 @@ func (s *Server) handleManageMCPs(
 			return nil, ManageMCPsOutput{}, fmt.Errorf("name is required for unregister action")
 		}
 
+		t := tenantFrom(ctx)
+		if t != nil && !t.ownsMCP(input.Name) {
+			if !s.mcpVisible(ctx, input.Name) {
+				return nil, ManageMCPsOutput{}, hiddenMCPError(input.Name)
+			}
+			if s.registry.GetState(input.Name) != "" {
+				return nil, ManageMCPsOutput{}, fmt.Errorf("MCP %s is shared by every client; an isolated session can only unregister MCPs it registered", input.Name)
+			}
+		}
+
 		if err := s.registry.Unregister(ctx, input.Name); err != nil {
 			return nil, ManageMCPsOutput{}, err
 		}
+		if t != nil {
+			s.releaseMCP(t, input.Name)
+		}
 
 		return nil, ManageMCPsOutput{
 			Message: fmt.Sprintf("Unregistered MCP: %s", input.Name),
@@ -606,6 +652,10 @@ func (s *Server) handleManageMCPs(
 			return nil, ManageMCPsOutput{}, fmt.Errorf("name is required for reconnect action")
 		}
 
+		if !s.mcpVisible(ctx, input.Name) {
+			return nil, ManageMCPsOutput{}, hiddenMCPError(input.Name)
+		}
+
 		if err := s.registry.Reconnect(ctx, input.Name); err != nil {
 			return nil, ManageMCPsOutput{}, fmt.Errorf("failed to reconnect MCP: %w", err)
 		}
@@ -616,17 +666,20 @@ func (s *Server) handleManageMCPs(
 
 	case "list":
 		return nil, ManageMCPsOutput{
-			MCPs: s.registry.List(),
+			MCPs: filterVisible(ctx, s, s.registry.List(), func(m registry.MCPStatus) string { return m.Name }),
 		}, nil
 
 	case "status":
 		return nil, ManageMCPsOutput{
-			Status: s.registry.Status(),
+			Status: filterVisible(ctx, s, s.registry.Status(), func(m registry.MCPFullStatus) string { return m.Name }),
 		}, nil
 
 	case "health_check":
 		// Perform health check on specific MCP or all connected MCPs
-		results := s.registry.HealthCheck(ctx, input.Name)
+		var results []registry.HealthCheckResult
+		if input.Name == "" || s.mcpVisible(ctx, input.Name) {
+			results = filterVisible(ctx, s, s.registry.HealthCheck(ctx, input.Name), func(r registry.HealthCheckResult) string { return r.Name })
+		}
 		msg := ""
 		if input.Name != "" {
 			if len(results) > 0 {
@@ -650,7 +703,14 @@ func (s *Server) handleManageMCPs(
 
 	case "list_stale_overrides":
 		// Delegate to customize_tools list_overrides with stale_only:true
-		_, out, err := s.customizeListOverrides(ctx, CustomizeToolsInput{
+		store, err := s.overridesFor(ctx)
+		if err != nil {
+			return nil, ManageMCPsOutput{}, fmt.Errorf("failed to list stale overrides: %w", err)
+		}
+		if store == nil {
+			return nil, ManageMCPsOutput{}, fmt.Errorf("failed to list stale overrides: override store not initialized")
+		}
+		_, out, err := s.customizeListOverrides(ctx, store, CustomizeToolsInput{
 			Action:    "list_overrides",
 			StaleOnly: true,
 		})
@@ -667,8 +727,66 @@ func (s *Server) handleManageMCPs(
 			Entries:  entries,
 		}, nil
 
+	case "cache_inspect":
+		if input.Name != "" && !s.mcpVisible(ctx, input.Name) {
+			return nil, ManageMCPsOutput{}, hiddenMCPError(input.Name)
+		}
+		results := filterVisible(ctx, s, s.registry.CachedResults(input.Name), func(r registry.CachedResultInfo) string { return r.MCPName })
+		return nil, ManageMCPsOutput{
+			Message: fmt.Sprintf("%d memoized results", len(results)),
+			Results: results,
+		}, nil
+
+	case "cache_flush":
+		if input.Tool != "" && input.Name == "" {
+			return nil, ManageMCPsOutput{}, fmt.Errorf("name is required when tool is given for cache_flush")
+		}
+		if input.Name != "" && !s.mcpVisible(ctx, input.Name) {
+			return nil, ManageMCPsOutput{}, hiddenMCPError(input.Name)
+		}
+		// A restricted caller flushes only the MCPs it can see.
+		names := []string{input.Name}
+		if input.Name == "" && (s.tenants != nil || tokenFrom(ctx) != nil) {
+			names = nil
+			seen := make(map[string]bool)
+			for _, r := range filterVisible(ctx, s, s.registry.CachedResults(""), func(r registry.CachedResultInfo) string { return r.MCPName }) {
+				if !seen[r.MCPName] {
+					seen[r.MCPName] = true
+					names = append(names, r.MCPName)
+				}
+			}
+		}
+		flushed := 0
+		for _, name := range names {
+			n, err := s.registry.FlushResults(name, input.Tool)
+			flushed += n
+			if err != nil {
+				return nil, ManageMCPsOutput{}, fmt.Errorf("failed to flush result cache: %w", err)
+			}
+		}
+		return nil, ManageMCPsOutput{
+			Message:  fmt.Sprintf("Flushed %d memoized results", flushed),
+			Affected: flushed,
+		}, nil
+
+	case "logs":
+		if input.Name == "" {
+			return nil, ManageMCPsOutput{}, fmt.Errorf("name is required for logs action")
+		}
+		if !s.mcpVisible(ctx, input.Name) {
+			return nil, ManageMCPsOutput{}, hiddenMCPError(input.Name)
+		}
+		lines, err := s.registry.StderrLines(input.Name, input.Lines)
+		if err != nil {
+			return nil, ManageMCPsOutput{}, err
+		}
+		return nil, ManageMCPsOutput{
+			Message: fmt.Sprintf("%d stderr lines from %s", len(lines), input.Name),
+			Logs:    lines,
+		}, nil
+
 	default:
-		return nil, ManageMCPsOutput{}, fmt.Errorf("invalid action: %s (must be register, unregister, reconnect, list, status, health_check, or list_stale_overrides)", input.Action)
+		return nil, ManageMCPsOutput{}, fmt.Errorf("invalid action: %s (must be register, unregister, reconnect, list, status, health_check, list_stale_overrides, cache_inspect, cache_flush, or logs)", input.Action)
 	}
 }
 
@@ -735,15 +853,29 @@ func (s *Server) handleAuthMCP(
 			return nil, AuthMCPOutput{}, fmt.Errorf("MCP '%s' does not have a URL configured; OAuth requires HTTP transport", input.Name)
 		}
 
-		flow := &auth.OAuthFlow{
-			ServerName: input.Name,
-			ServerURL:  serverURL,
-			Store:      store,
-		}
+		var result *auth.AuthResult
+		if mcpCfg != nil && mcpCfg.OAuth != nil {
+			flow, err := registry.NewClientCredentialsFlow(*mcpCfg)
+			if err != nil {
+				return nil, AuthMCPOutput{}, err
+			}
+			defer flow.HTTPClient.CloseIdleConnections()
+			result, err = flow.Authenticate(ctx)
+			if err != nil {
+				return nil, AuthMCPOutput{}, fmt.Errorf("client_credentials grant failed: %w", err)
+			}
+		} else {
+			flow := &auth.OAuthFlow{
+				ServerName: input.Name,
+				ServerURL:  serverURL,
+				Store:      store,
+			}
 
-		result, err := flow.DiscoverAndAuth(ctx)
-		if err != nil {
-			return nil, AuthMCPOutput{}, fmt.Errorf("OAuth flow failed: %w", err)
+			var err error
+			result, err = flow.DiscoverAndAuth(ctx)
+			if err != nil {
+				return nil, AuthMCPOutput{}, fmt.Errorf("OAuth flow failed: %w", err)
+			}
 		}
 
 		// Reconnect the MCP to use the new credentials
@@ -878,7 +1010,7 @@ func (s *Server) handleGetMetadata(
 ) (*mcp.CallToolResult, GetMetadataOutput, error) {
 	// If a specific MCP is requested and it's cached, connect it first
 	// so we can fetch prompts, resources, etc.
-	if input.MCPName != "" {
+	if input.MCPName != "" && s.mcpVisible(ctx, input.MCPName) {
 		if state := s.registry.GetState(input.MCPName); state == registry.StateCached {
 			if err := s.registry.EnsureConnected(ctx, input.MCPName); err != nil {
 				s.logger.Debug("failed to lazy-connect for metadata", "mcp_name", input.MCPName, "error", err)
@@ -887,6 +1019,10 @@ func (s *Server) handleGetMetadata(
 	}
 
 	allMetadata := s.registry.GetMetadata(ctx)
+	allMetadata = filterVisible(ctx, s, allMetadata, func(m registry.MCPMetadata) string { return m.Name })
+	for i := range allMetadata {
+		allMetadata[i].Tools = filterPermittedTools(ctx, allMetadata[i].Tools)
+	}
 
 	// Filter by MCP name if specified
 	metadata := allMetadata
@@ -932,8 +1068,8 @@ func (s *Server) handleGetMetadata(
 				// cached MCPs return index tools where Description already
 				// carries the override (SourceDescription holds the original).
 				upstreamDesc := tool.UpstreamDescription()
-				upstreamParams := extractParamDescs(tool.InputSchema)
-				ov := s.overrideFor(metadata[i].Name, tool.Name, upstreamDesc, upstreamParams)
+				upstreamParams := overrides.ParamDescriptions(tool.InputSchema)
+				ov := s.overrideFor(ctx, metadata[i].Name, tool.Name, upstreamDesc, upstreamParams)
 				if !ov.HasOverride {
 					continue
 				}
@@ -1034,10 +1170,11 @@ func writeOutputFile(path string, data []byte) error {
 
 // findMCPConfig looks up an MCP config by name from the loaded config.
 func (s *Server) findMCPConfig(name string) (*config.MCPConfig, error) {
-	if s.config == nil || s.config.MCPs == nil {
+	loaded := s.currentConfig()
+	if loaded == nil || loaded.MCPs == nil {
 		return nil, fmt.Errorf("no config loaded")
 	}
-	if cfg, ok := s.config.MCPs[name]; ok {
+	if cfg, ok := loaded.MCPs[name]; ok {
 		return &cfg, nil
 	}
 	return nil, fmt.Errorf("MCP '%s' not found in config", name)
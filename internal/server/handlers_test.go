@@ -1988,6 +1988,49 @@ func TestManageMCPs_ListStaleOverrides_DelegatesCorrectly(t *testing.T) {
 	require.Contains(t, outStr, `"stale":true`, "expected stale entry in output")
 }
 
+// TestManageMCPs_CacheActions covers cache_inspect and cache_flush argument
+// handling; the memoization itself is tested in the registry.
+func TestManageMCPs_CacheActions(t *testing.T) {
+	s := mockServer([]registry.ToolInfo{{Name: "echo", MCPName: "test-mcp"}})
+	ctx := context.Background()
+
+	_, out, err := s.handleManageMCPs(ctx, nil, ManageMCPsInput{Action: "cache_inspect", Name: "test-mcp"})
+	require.NoError(t, err)
+	assert.Empty(t, out.Results)
+	assert.Equal(t, "0 memoized results", out.Message)
+
+	_, out, err = s.handleManageMCPs(ctx, nil, ManageMCPsInput{Action: "cache_flush", Name: "test-mcp", Tool: "echo"})
+	require.NoError(t, err)
+	assert.Zero(t, out.Affected)
+
+	_, _, err = s.handleManageMCPs(ctx, nil, ManageMCPsInput{Action: "cache_flush", Tool: "echo"})
+	assert.ErrorContains(t, err, "name is required")
+}
+
+func TestManageMCPs_Logs(t *testing.T) {
+	s := mockServer(nil)
+	ctx := context.Background()
+
+	_, _, err := s.handleManageMCPs(ctx, nil, ManageMCPsInput{Action: "logs"})
+	assert.ErrorContains(t, err, "name is required")
+
+	_, _, err = s.handleManageMCPs(ctx, nil, ManageMCPsInput{Action: "logs", Name: "missing"})
+	var notFound *registry.MCPNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	err = s.registry.Connect(ctx, config.MCPConfig{
+		Name:    "crashy",
+		Type:    "stdio",
+		Command: "sh",
+		Args:    []string{"-c", "echo 'loading' >&2; echo 'boom' >&2; exit 1"},
+	})
+	require.Error(t, err)
+
+	_, out, err := s.handleManageMCPs(ctx, nil, ManageMCPsInput{Action: "logs", Name: "crashy", Lines: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"boom"}, out.Logs)
+}
+
 // TestHandleSlopReference tests the slop_reference MCP tool.
 func TestHandleSlopReference(t *testing.T) {
 	s := mockServer([]registry.ToolInfo{})
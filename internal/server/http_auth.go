@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/cli"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+)
+
+// readOnlyDeniedTools are the meta-tools a read_only token may not call:
+// each changes the MCP set, stored credentials, or tool overrides.
+var readOnlyDeniedTools = map[string]struct{}{
+	"manage_mcps":     {},
+	"auth_mcp":        {},
+	"customize_tools": {},
+}
+
+// httpTokens loads the token file named by SLOP_MCP_HTTP_TOKEN_FILE, or
+// returns nil when it is unset.
+func httpTokens() ([]config.HTTPToken, error) {
+	path := strings.TrimSpace(os.Getenv("SLOP_MCP_HTTP_TOKEN_FILE"))
+	if path == "" {
+		return nil, nil
+	}
+	if strings.TrimSpace(os.Getenv("SLOP_MCP_HTTP_TOKEN")) != "" {
+		return nil, fmt.Errorf("set SLOP_MCP_HTTP_TOKEN or SLOP_MCP_HTTP_TOKEN_FILE, not both")
+	}
+	tokens, err := config.LoadTokenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading SLOP_MCP_HTTP_TOKEN_FILE: %w", err)
+	}
+	return tokens, nil
+}
+
+// httpTokenKey is the TokenInfo.Extra key holding the matched *config.HTTPToken.
+const httpTokenKey = "slop_mcp_token"
+
+// requireHTTPTokens wraps h so every request must carry the secret of one of
+// tokens as "Authorization: Bearer <secret>". The matched token travels in
+// the request context as SDK TokenInfo (UserID = token name), which the
+// streamable transport also uses to pin each session to the token that
+// opened it.
+func requireHTTPTokens(tokens []config.HTTPToken, logger logging.Logger, h http.Handler) http.Handler {
+	verify := func(_ context.Context, secret string, r *http.Request) (*sdkauth.TokenInfo, error) {
+		// Compare against every token so timing does not reveal which one
+		// (or whether any) shares a prefix with the presented secret.
+		var match *config.HTTPToken
+		for i := range tokens {
+			if subtle.ConstantTimeCompare([]byte(secret), []byte(tokens[i].Secret)) == 1 {
+				match = &tokens[i]
+			}
+		}
+		if match == nil {
+			logger.Warn("rejected HTTP request with unknown bearer token", "remote_addr", r.RemoteAddr)
+			return nil, fmt.Errorf("%w: unknown bearer token", sdkauth.ErrInvalidToken)
+		}
+		return &sdkauth.TokenInfo{
+			UserID: match.Name,
+			// Token file entries do not expire; the SDK requires a deadline.
+			Expiration: time.Now().Add(24 * time.Hour),
+			Extra:      map[string]any{httpTokenKey: match},
+		}, nil
+	}
+	return sdkauth.RequireBearerToken(verify, nil)(h)
+}
+
+// tokenFrom returns the token-file entry the caller authenticated with, or
+// nil when no token file is in use.
+func tokenFrom(ctx context.Context) *config.HTTPToken {
+	info := sdkauth.TokenInfoFromContext(ctx)
+	if info == nil {
+		return nil
+	}
+	tok, _ := info.Extra[httpTokenKey].(*config.HTTPToken)
+	return tok
+}
+
+// PermissionDeniedError is returned when the caller's HTTP token does not
+// permit a call. It is also attached to the tool result as structured content.
+type PermissionDeniedError struct {
+	Token    string `json:"token"`
+	MCPName  string `json:"mcp_name,omitempty"`
+	ToolName string `json:"tool_name,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+func (e *PermissionDeniedError) Error() string {
+	target := e.ToolName
+	if e.MCPName != "" && e.ToolName != "" {
+		target = e.MCPName + "." + e.ToolName
+	} else if e.MCPName != "" {
+		target = "MCP " + e.MCPName
+	}
+	return fmt.Sprintf("permission denied: token %q may not use %s: %s", e.Token, target, e.Reason)
+}
+
+// structured is the StructuredContent form of the error.
+func (e *PermissionDeniedError) structured() map[string]any {
+	out := map[string]any{
+		"error":  "permission_denied",
+		"token":  e.Token,
+		"reason": e.Reason,
+	}
+	if e.MCPName != "" {
+		out["mcp_name"] = e.MCPName
+	}
+	if e.ToolName != "" {
+		out["tool_name"] = e.ToolName
+	}
+	return out
+}
+
+// deny logs a permission denial with the token name and returns it as an error.
+func (s *Server) deny(e *PermissionDeniedError) error {
+	s.logger.Warn("denied call by HTTP token permissions",
+		"token", e.Token, "mcp_name", e.MCPName, "tool_name", e.ToolName, "reason", e.Reason)
+	return e
+}
+
+// checkMCPAllowed returns a *PermissionDeniedError when the caller's token
+// does not list mcpName.
+func (s *Server) checkMCPAllowed(ctx context.Context, mcpName string) error {
+	tok := tokenFrom(ctx)
+	if tok == nil || tok.AllowsMCP(mcpName) {
+		return nil
+	}
+	return s.deny(&PermissionDeniedError{Token: tok.Name, MCPName: mcpName, Reason: "MCP is not in the token's mcps list"})
+}
+
+// checkToolAllowed returns a *PermissionDeniedError when the caller's token
+// does not permit calling toolName on mcpName.
+func (s *Server) checkToolAllowed(ctx context.Context, mcpName, toolName string) error {
+	if err := s.checkMCPAllowed(ctx, mcpName); err != nil {
+		return err
+	}
+	tok := tokenFrom(ctx)
+	if tok == nil || tokenAllowsTool(tok, mcpName, toolName) {
+		return nil
+	}
+	return s.deny(&PermissionDeniedError{Token: tok.Name, MCPName: mcpName, ToolName: toolName, Reason: "tool is not in the token's tools list"})
+}
+
+// tokenAllowsTool is tok.AllowsTool with CLI tools matched by their bare
+// name, however the caller spelled them.
+func tokenAllowsTool(tok *config.HTTPToken, mcpName, toolName string) bool {
+	if mcpName == "cli" {
+		toolName = cli.StripCLIPrefix(toolName)
+	}
+	return tok.AllowsTool(mcpName, toolName)
+}
+
+// filterPermittedTools drops the tools the caller's HTTP token may not call.
+func filterPermittedTools(ctx context.Context, tools []registry.ToolInfo) []registry.ToolInfo {
+	tok := tokenFrom(ctx)
+	if tok == nil {
+		return tools
+	}
+	permitted := tools[:0:0]
+	for _, t := range tools {
+		if tokenAllowsTool(tok, t.MCPName, t.Name) {
+			permitted = append(permitted, t)
+		}
+	}
+	return permitted
+}
+
+// permissionMiddleware refuses the state-changing meta-tools to read_only
+// tokens before they reach their handlers.
+func (s *Server) permissionMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "tools/call" {
+			return next(ctx, method, req)
+		}
+		tok := tokenFrom(ctx)
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if tok == nil || !tok.ReadOnly || !ok {
+			return next(ctx, method, req)
+		}
+		if _, denied := readOnlyDeniedTools[params.Name]; denied {
+			return errorResult(s.deny(&PermissionDeniedError{
+				Token:    tok.Name,
+				ToolName: params.Name,
+				Reason:   "token is read_only",
+			})), nil
+		}
+		return next(ctx, method, req)
+	}
+}
+
+// permissionDenied unwraps a *PermissionDeniedError from err.
+func permissionDenied(err error) (*PermissionDeniedError, bool) {
+	var pd *PermissionDeniedError
+	ok := errors.As(err, &pd)
+	return pd, ok
+}
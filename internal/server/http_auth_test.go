@@ -1,9 +1,20 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRequireBearerToken(t *testing.T) {
@@ -44,3 +55,123 @@ func TestRequireBearerToken(t *testing.T) {
 		})
 	}
 }
+
+const testTokenFile = `token "reader" {
+    secret "reader-secret"
+    mcps "test-mcp"
+    tools "echo"
+    read_only true
+}
+token "admin" {
+    secret "admin-secret"
+}`
+
+// newTokenFileTestServer serves s.httpHandler() with SLOP_MCP_HTTP_TOKEN_FILE
+// pointing at testTokenFile and the given pins.
+func newTokenFileTestServer(t *testing.T, pins ...config.PinConfig) *httptest.Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.kdl")
+	require.NoError(t, os.WriteFile(path, []byte(testTokenFile), 0o600))
+	t.Setenv("SLOP_MCP_HTTP_TOKEN", "")
+	t.Setenv("SLOP_MCP_HTTP_TOKEN_FILE", path)
+
+	s := mockServer([]registry.ToolInfo{
+		{Name: "echo", MCPName: "test-mcp"},
+		{Name: "delete", MCPName: "test-mcp"},
+	})
+	s.registry.AddToolsForTesting("other-mcp", []registry.ToolInfo{{Name: "echo", MCPName: "other-mcp"}})
+	s.logger = logging.Nop()
+	s.config = config.NewConfig()
+	s.config.Pins = pins
+	s.mcpServer = mcp.NewServer(&mcp.Implementation{Name: serverName, Version: serverVersion}, nil)
+	s.registerTools()
+	s.syncPinnedTools()
+
+	handler, err := s.httpHandler()
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// requirePermissionDenied asserts res is a structured permission_denied error.
+func requirePermissionDenied(t *testing.T, res *mcp.CallToolResult, toolName string) {
+	t.Helper()
+	require.True(t, res.IsError)
+	data, err := json.Marshal(res.StructuredContent)
+	require.NoError(t, err)
+	var denied map[string]any
+	require.NoError(t, json.Unmarshal(data, &denied))
+	assert.Equal(t, "permission_denied", denied["error"])
+	assert.Equal(t, "reader", denied["token"])
+	assert.Equal(t, toolName, denied["tool_name"])
+}
+
+func TestHTTPHandler_TokenFileFiltersPinnedTools(t *testing.T) {
+	ts := newTokenFileTestServer(t, config.PinConfig{Pattern: "*.echo"}, config.PinConfig{Pattern: "test-mcp.delete"})
+	connect := func(secret string) *mcp.ClientSession {
+		cs, err := connectStreamable(t, ts.URL+"/mcp", &http.Client{Transport: bearerTransport{token: secret}})
+		require.NoError(t, err)
+		return cs
+	}
+
+	admin := listedTools(t, connect("admin-secret"))
+	assert.Contains(t, admin, "test-mcp_echo")
+	assert.Contains(t, admin, "test-mcp_delete")
+	assert.Contains(t, admin, "other-mcp_echo")
+
+	reader := connect("reader-secret")
+	listed := listedTools(t, reader)
+	assert.Contains(t, listed, "test-mcp_echo")
+	assert.Contains(t, listed, "search_tools", "meta-tools stay listed")
+	assert.NotContains(t, listed, "test-mcp_delete", "a tool outside the token's tools list is not listed")
+	assert.NotContains(t, listed, "other-mcp_echo", "an MCP outside the token's mcps list is not listed")
+
+	res, err := reader.CallTool(context.Background(), &mcp.CallToolParams{Name: "test-mcp_delete", Arguments: map[string]any{}})
+	require.NoError(t, err)
+	requirePermissionDenied(t, res, "delete")
+}
+
+func TestHTTPTokens_ExclusiveWithSingleToken(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_TOKEN", "s3cret")
+	t.Setenv("SLOP_MCP_HTTP_TOKEN_FILE", "tokens.kdl")
+	_, err := httpTokens()
+	assert.ErrorContains(t, err, "not both")
+}
+
+func TestHTTPHandler_TokenFile(t *testing.T) {
+	ts := newTokenFileTestServer(t)
+	connect := func(secret string) (*mcp.ClientSession, error) {
+		return connectStreamable(t, ts.URL+"/mcp", &http.Client{Transport: bearerTransport{token: secret}})
+	}
+
+	_, err := connect("nope")
+	require.Error(t, err, "unknown tokens are rejected")
+
+	reader, err := connect("reader-secret")
+	require.NoError(t, err)
+	call := func(cs *mcp.ClientSession, name string, args map[string]any) *mcp.CallToolResult {
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+		require.NoError(t, err)
+		return res
+	}
+
+	requirePermissionDenied(t, call(reader, "manage_mcps", map[string]any{"action": "list"}), "manage_mcps")
+	requirePermissionDenied(t, call(reader, "execute_tool", map[string]any{"mcp_name": "test-mcp", "tool_name": "delete"}), "delete")
+	res := call(reader, "execute_tool", map[string]any{"mcp_name": "other-mcp", "tool_name": "echo"})
+	require.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, "mcps list")
+
+	res = call(reader, "search_tools", map[string]any{})
+	require.False(t, res.IsError)
+	var out SearchToolsOutput
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out))
+	require.Len(t, out.Tools, 1, "search lists only the tools the token may call")
+	assert.Equal(t, "test-mcp", out.Tools[0].MCPName)
+	assert.Equal(t, "echo", out.Tools[0].Name)
+
+	admin, err := connect("admin-secret")
+	require.NoError(t, err)
+	res = call(admin, "manage_mcps", map[string]any{"action": "list"})
+	assert.False(t, res.IsError)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHTTPTestServer serves s.httpHandler() with the meta-tools registered.
+func newHTTPTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := mockServer([]registry.ToolInfo{{Name: "echo", MCPName: "test-mcp"}})
+	s.logger = logging.Nop()
+	s.mcpServer = mcp.NewServer(&mcp.Implementation{Name: serverName, Version: serverVersion}, nil)
+	s.registerTools()
+
+	handler, err := s.httpHandler()
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// bearerTransport adds an Authorization header to every request.
+type bearerTransport struct{ token string }
+
+func (b bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func connectStreamable(t *testing.T, endpoint string, httpClient *http.Client) (*mcp.ClientSession, error) {
+	t.Helper()
+	client := mcp.NewClient(&mcp.Implementation{Name: "agent", Version: "test"}, nil)
+	cs, err := client.Connect(context.Background(), &mcp.StreamableClientTransport{
+		Endpoint:   endpoint,
+		HTTPClient: httpClient,
+	}, nil)
+	if err == nil {
+		t.Cleanup(func() { _ = cs.Close() })
+	}
+	return cs, err
+}
+
+func TestHTTPHandler_StreamableEndpoint(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_TOKEN", "")
+	ts := newHTTPTestServer(t)
+
+	cs, err := connectStreamable(t, ts.URL+"/mcp", nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, cs.ID(), "streamable sessions carry an Mcp-Session-Id")
+
+	tools, err := cs.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	names := make([]string, 0, len(tools.Tools))
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	assert.Contains(t, names, "search_tools")
+	assert.Contains(t, names, "execute_tool")
+}
+
+func TestHTTPHandler_SSEStillServed(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_TOKEN", "")
+	ts := newHTTPTestServer(t)
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "agent", Version: "test"}, nil)
+	cs, err := client.Connect(context.Background(), &mcp.SSEClientTransport{Endpoint: ts.URL + "/"}, nil)
+	require.NoError(t, err)
+	defer cs.Close()
+
+	_, err = cs.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+}
+
+func TestHTTPHandler_StreamableRequiresBearerToken(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_TOKEN", "s3cret")
+	ts := newHTTPTestServer(t)
+
+	_, err := connectStreamable(t, ts.URL+"/mcp", nil)
+	require.Error(t, err, "unauthenticated streamable connect must be rejected")
+
+	cs, err := connectStreamable(t, ts.URL+"/mcp", &http.Client{Transport: bearerTransport{token: "s3cret"}})
+	require.NoError(t, err)
+	_, err = cs.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+}
+
+func TestHTTPSessionTimeout(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_SESSION_TIMEOUT", "")
+	assert.Equal(t, defaultHTTPSessionTimeout, httpSessionTimeout())
+
+	t.Setenv("SLOP_MCP_HTTP_SESSION_TIMEOUT", "5m")
+	assert.Equal(t, "5m0s", httpSessionTimeout().String())
+
+	t.Setenv("SLOP_MCP_HTTP_SESSION_TIMEOUT", "0")
+	assert.Zero(t, httpSessionTimeout())
+
+	t.Setenv("SLOP_MCP_HTTP_SESSION_TIMEOUT", "bogus")
+	assert.Equal(t, defaultHTTPSessionTimeout, httpSessionTimeout())
+}
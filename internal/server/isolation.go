@@ -0,0 +1,370 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/builtins"
+	"github.com/standardbeagle/slop-mcp/internal/overrides"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+)
+
+// isolationMode selects how HTTP clients are partitioned into tenants.
+type isolationMode string
+
+const (
+	// isolationOff shares one session store, MCP set, and override store
+	// across every client (the default).
+	isolationOff isolationMode = ""
+	// isolationSession gives every MCP session (Mcp-Session-Id) its own
+	// tenant, dropped when the session ends.
+	isolationSession isolationMode = "session"
+	// isolationToken keys tenants on the named token from
+	// SLOP_MCP_HTTP_TOKEN_FILE the client presents, so every connection made
+	// with one token shares a tenant.
+	isolationToken isolationMode = "token"
+)
+
+// httpIsolationMode reads SLOP_MCP_HTTP_ISOLATION: "session", "token", or
+// "off" (the default when unset).
+func httpIsolationMode() (isolationMode, error) {
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("SLOP_MCP_HTTP_ISOLATION"))); v {
+	case "", "off":
+		return isolationOff, nil
+	case string(isolationSession):
+		return isolationSession, nil
+	case string(isolationToken):
+		return isolationToken, nil
+	default:
+		return isolationOff, fmt.Errorf("invalid SLOP_MCP_HTTP_ISOLATION %q: must be session, token, or off", v)
+	}
+}
+
+// tenant is the state private to one isolated client: its session memory,
+// the MCPs it registered at runtime, and its local-scope overrides. Upstream
+// connections stay in the shared registry, so MCPs from the config are
+// pooled across tenants; a tenant's own MCPs are just hidden from the rest.
+type tenant struct {
+	key      string
+	sessions *builtins.SessionStore
+
+	mu        sync.Mutex
+	mcps      map[string]struct{}
+	overrides *overrides.Store
+	dir       string // local-scope override root; removed with the tenant
+}
+
+// tenants tracks the isolated clients of an HTTP server.
+type tenants struct {
+	mode isolationMode
+
+	mu     sync.Mutex
+	byKey  map[string]*tenant
+	owners map[string]string // runtime MCP name -> owning tenant key
+
+	// registerMu serializes the name check and connect of register so two
+	// tenants cannot claim the same MCP name at once.
+	registerMu sync.Mutex
+}
+
+func newTenants(mode isolationMode) *tenants {
+	return &tenants{
+		mode:   mode,
+		byKey:  make(map[string]*tenant),
+		owners: make(map[string]string),
+	}
+}
+
+func newTenant(key string) *tenant {
+	return &tenant{
+		key:      key,
+		sessions: builtins.NewSessionStore(),
+		mcps:     make(map[string]struct{}),
+	}
+}
+
+type tenantKey struct{}
+
+// withTenant returns a context carrying t.
+func withTenant(ctx context.Context, t *tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// tenantFrom returns the tenant attached to ctx, or nil when isolation is off
+// or the call did not arrive over an isolated session.
+func tenantFrom(ctx context.Context) *tenant {
+	t, _ := ctx.Value(tenantKey{}).(*tenant)
+	return t
+}
+
+// isolationMiddleware attaches the calling client's tenant to every request
+// it sends, so handlers downstream see only that tenant's state.
+func (s *Server) isolationMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if t := s.tenantFor(ctx, req); t != nil {
+			ctx = withTenant(ctx, t)
+		}
+		return next(ctx, method, req)
+	}
+}
+
+// tenantFor resolves (creating on first use) the tenant a request belongs to.
+// In session mode a new tenant is dropped once its session ends.
+func (s *Server) tenantFor(ctx context.Context, req mcp.Request) *tenant {
+	ss, ok := req.GetSession().(*mcp.ServerSession)
+	if !ok || ss == nil {
+		return nil
+	}
+
+	var key string
+	switch s.tenants.mode {
+	case isolationSession:
+		key = "session-" + ss.ID()
+		if ss.ID() == "" {
+			// SSE sessions carry no ID; the session itself is the identity.
+			key = fmt.Sprintf("session-%p", ss)
+		}
+	case isolationToken:
+		// httpHandler refuses token isolation without a token file, so
+		// every request here carries a named token.
+		tok := tokenFrom(ctx)
+		if tok == nil {
+			return nil
+		}
+		key = "token-" + tok.Name
+	default:
+		return nil
+	}
+
+	s.tenants.mu.Lock()
+	t, ok := s.tenants.byKey[key]
+	if !ok {
+		t = newTenant(key)
+		s.tenants.byKey[key] = t
+	}
+	s.tenants.mu.Unlock()
+
+	if !ok && s.tenants.mode == isolationSession {
+		go func() {
+			_ = ss.Wait()
+			s.dropTenant(t)
+		}()
+	}
+	return t
+}
+
+// dropTenant unregisters the MCPs t registered and removes its private state.
+func (s *Server) dropTenant(t *tenant) {
+	s.tenants.mu.Lock()
+	if s.tenants.byKey[t.key] == t {
+		delete(s.tenants.byKey, t.key)
+	}
+	s.tenants.mu.Unlock()
+
+	t.mu.Lock()
+	names := make([]string, 0, len(t.mcps))
+	for name := range t.mcps {
+		names = append(names, name)
+	}
+	t.mcps = make(map[string]struct{})
+	dir := t.dir
+	t.overrides, t.dir = nil, ""
+	t.mu.Unlock()
+
+	for _, name := range names {
+		if err := s.registry.Unregister(context.Background(), name); err != nil {
+			s.logger.Debug("failed to unregister tenant MCP", "tenant", t.key, "mcp_name", name, "error", err)
+		}
+		s.tenants.mu.Lock()
+		if s.tenants.owners[name] == t.key {
+			delete(s.tenants.owners, name)
+		}
+		s.tenants.mu.Unlock()
+	}
+	if dir != "" {
+		_ = os.RemoveAll(dir)
+	}
+	s.logger.Debug("dropped isolated tenant", "tenant", t.key, "mcps", len(names))
+}
+
+// closeTenants drops every tenant; called on server shutdown.
+func (s *Server) closeTenants() {
+	if s.tenants == nil {
+		return
+	}
+	s.tenants.mu.Lock()
+	all := make([]*tenant, 0, len(s.tenants.byKey))
+	for _, t := range s.tenants.byKey {
+		all = append(all, t)
+	}
+	s.tenants.mu.Unlock()
+	for _, t := range all {
+		s.dropTenant(t)
+	}
+}
+
+// sessionStoreFor returns the session memory SLOP scripts run with: the
+// caller's tenant store when isolated, else the server-wide one.
+func (s *Server) sessionStoreFor(ctx context.Context) *builtins.SessionStore {
+	if t := tenantFrom(ctx); t != nil {
+		return t.sessions
+	}
+	return s.sessionStore
+}
+
+// mcpVisible reports whether the caller may see and use mcpName: it is not
+// another tenant's runtime MCP, and the caller's HTTP token lists it.
+func (s *Server) mcpVisible(ctx context.Context, mcpName string) bool {
+	if tok := tokenFrom(ctx); tok != nil && !tok.AllowsMCP(mcpName) {
+		return false
+	}
+	return !s.hiddenByTenant(ctx, mcpName)
+}
+
+// hiddenByTenant reports whether mcpName was registered at runtime by a
+// tenant other than the caller's; such MCPs are visible to their owner only.
+func (s *Server) hiddenByTenant(ctx context.Context, mcpName string) bool {
+	if s.tenants == nil {
+		return false
+	}
+	s.tenants.mu.Lock()
+	owner, owned := s.tenants.owners[mcpName]
+	s.tenants.mu.Unlock()
+	if !owned {
+		return false
+	}
+	t := tenantFrom(ctx)
+	return t == nil || t.key != owner
+}
+
+// hiddenMCPError is returned for an MCP that exists but belongs to another
+// tenant; it reads exactly like an unknown MCP.
+func hiddenMCPError(mcpName string) error {
+	return &registry.MCPNotFoundError{Name: mcpName}
+}
+
+// claimMCP records that t registered mcpName.
+func (s *Server) claimMCP(t *tenant, mcpName string) {
+	t.mu.Lock()
+	t.mcps[mcpName] = struct{}{}
+	t.mu.Unlock()
+	s.tenants.mu.Lock()
+	s.tenants.owners[mcpName] = t.key
+	s.tenants.mu.Unlock()
+}
+
+// releaseMCP forgets that t registered mcpName.
+func (s *Server) releaseMCP(t *tenant, mcpName string) {
+	t.mu.Lock()
+	delete(t.mcps, mcpName)
+	t.mu.Unlock()
+	s.tenants.mu.Lock()
+	if s.tenants.owners[mcpName] == t.key {
+		delete(s.tenants.owners, mcpName)
+	}
+	s.tenants.mu.Unlock()
+}
+
+// ownsMCP reports whether t registered mcpName.
+func (t *tenant) ownsMCP(mcpName string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.mcps[mcpName]
+	return ok
+}
+
+// overridesFor returns the override store the caller reads and writes. An
+// isolated tenant gets its own store that shares the user and project roots
+// but keeps local scope in a private directory, opened on first use.
+func (s *Server) overridesFor(ctx context.Context) (*overrides.Store, error) {
+	t := tenantFrom(ctx)
+	if t == nil || s.overrideStore == nil {
+		return s.overrideStore, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.overrides != nil {
+		return t.overrides, nil
+	}
+	dir, err := os.MkdirTemp("", "slop-mcp-tenant-")
+	if err != nil {
+		return nil, fmt.Errorf("creating local override root: %w", err)
+	}
+	opts := s.overrideStore.Options()
+	opts.LocalRoot = dir
+	store, err := overrides.OpenStore(opts)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("overrides store: %w", err)
+	}
+	t.overrides, t.dir = store, dir
+	return store, nil
+}
+
+// lookupCustom finds a custom tool for execute_tool: the caller's own store
+// first, then the shared one, whose custom tools are what search lists.
+func (s *Server) lookupCustom(ctx context.Context, name string) (overrides.CustomTool, bool) {
+	store, err := s.overridesFor(ctx)
+	if err == nil && store != nil {
+		if ct, ok := store.GetCustom(name); ok {
+			return ct, true
+		}
+	}
+	if store != s.overrideStore && s.overrideStore != nil {
+		return s.overrideStore.GetCustom(name)
+	}
+	return overrides.CustomTool{}, false
+}
+
+// filterVisible drops the items whose MCP the caller may not see.
+func filterVisible[T any](ctx context.Context, s *Server, items []T, mcpName func(T) string) []T {
+	if s.tenants == nil && tokenFrom(ctx) == nil {
+		return items
+	}
+	visible := items[:0:0]
+	for _, item := range items {
+		if s.mcpVisible(ctx, mcpName(item)) {
+			visible = append(visible, item)
+		}
+	}
+	return visible
+}
+
+// tenantCustomTools lists the caller's private (local-scope) custom tools
+// matching query, in the shape search_tools returns. Shared custom tools are
+// already in the registry index.
+func (s *Server) tenantCustomTools(ctx context.Context, query, mcpName string) []registry.ToolInfo {
+	if tenantFrom(ctx) == nil || (mcpName != "" && mcpName != "_custom") {
+		return nil
+	}
+	store, err := s.overridesFor(ctx)
+	if err != nil || store == nil {
+		return nil
+	}
+	local := store.ListCustom()[overrides.ScopeLocal]
+	names := make([]string, 0, len(local))
+	for name := range local {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]registry.ToolInfo, 0, len(names))
+	for _, name := range names {
+		ct := local[name]
+		if query != "" && !matchesQuery(name, ct.Description, query) {
+			continue
+		}
+		out = append(out, registry.ToolInfo{
+			Name:        name,
+			Description: ct.Description,
+			MCPName:     "_custom",
+			InputSchema: ct.InputSchema,
+		})
+	}
+	return out
+}
@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/overrides"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIsolatedServer returns a mock server in session isolation mode with an
+// override store, plus contexts for two tenants.
+func newIsolatedServer(t *testing.T) (*Server, context.Context, context.Context) {
+	t.Helper()
+	s := mockServer([]registry.ToolInfo{{Name: "echo", MCPName: "test-mcp", Description: "Echo input"}})
+	s.logger = logging.Nop()
+	s.tenants = newTenants(isolationSession)
+	store, err := overrides.OpenStore(overrides.StoreOptions{UserRoot: t.TempDir(), LocalRoot: t.TempDir()})
+	require.NoError(t, err)
+	s.SetOverrideStoreForTesting(store)
+	t.Cleanup(s.closeTenants)
+
+	tenantCtx := func(key string) context.Context {
+		tn := newTenant(key)
+		s.tenants.byKey[key] = tn
+		return withTenant(context.Background(), tn)
+	}
+	return s, tenantCtx("session-a"), tenantCtx("session-b")
+}
+
+func TestHTTPIsolationMode(t *testing.T) {
+	for v, want := range map[string]isolationMode{
+		"":        isolationOff,
+		"off":     isolationOff,
+		"session": isolationSession,
+		"Token":   isolationToken,
+	} {
+		t.Setenv("SLOP_MCP_HTTP_ISOLATION", v)
+		got, err := httpIsolationMode()
+		require.NoError(t, err, v)
+		assert.Equal(t, want, got, v)
+	}
+
+	t.Setenv("SLOP_MCP_HTTP_ISOLATION", "per-user")
+	_, err := httpIsolationMode()
+	assert.ErrorContains(t, err, "SLOP_MCP_HTTP_ISOLATION")
+}
+
+func TestHTTPHandler_TokenIsolationRequiresTokenFile(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_ISOLATION", "token")
+	t.Setenv("SLOP_MCP_HTTP_TOKEN_FILE", "")
+	for _, token := range []string{"", "shared-secret"} {
+		t.Setenv("SLOP_MCP_HTTP_TOKEN", token)
+		s := mockServer(nil)
+		s.logger = logging.Nop()
+		_, err := s.httpHandler()
+		assert.ErrorContains(t, err, "SLOP_MCP_HTTP_TOKEN_FILE", "token %q", token)
+		assert.Nil(t, s.tenants)
+	}
+}
+
+func TestIsolation_SessionStorePerTenant(t *testing.T) {
+	s, ctxA, ctxB := newIsolatedServer(t)
+
+	assert.Same(t, s.sessionStore, s.sessionStoreFor(context.Background()), "non-isolated calls share the server store")
+	assert.NotSame(t, s.sessionStore, s.sessionStoreFor(ctxA))
+	assert.NotSame(t, s.sessionStoreFor(ctxA), s.sessionStoreFor(ctxB))
+	assert.Same(t, s.sessionStoreFor(ctxA), s.sessionStoreFor(ctxA))
+}
+
+func TestIsolation_RuntimeMCPHiddenFromOtherTenants(t *testing.T) {
+	s, ctxA, ctxB := newIsolatedServer(t)
+	s.registry.AddToolsForTesting("private-mcp", []registry.ToolInfo{{Name: "secret", MCPName: "private-mcp"}})
+	s.registry.MarkCachedForTesting("private-mcp")
+	s.claimMCP(tenantFrom(ctxA), "private-mcp")
+
+	mcpNames := func(ctx context.Context) []string {
+		_, out, err := s.handleSearchTools(ctx, nil, SearchToolsInput{})
+		require.NoError(t, err)
+		var names []string
+		for _, tool := range out.Tools {
+			names = append(names, tool.MCPName)
+		}
+		return names
+	}
+	assert.Contains(t, mcpNames(ctxA), "private-mcp")
+	assert.NotContains(t, mcpNames(ctxB), "private-mcp")
+	assert.Contains(t, mcpNames(ctxB), "test-mcp", "configured MCPs stay shared")
+
+	_, _, err := s.handleExecuteTool(ctxB, nil, ExecuteToolInput{MCPName: "private-mcp", ToolName: "secret"})
+	var notFound *registry.MCPNotFoundError
+	require.ErrorAs(t, err, &notFound)
+
+	res, err := s.wrapExecuteTool(ctxB, &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{
+		Name:      "execute_tool",
+		Arguments: json.RawMessage(`{"mcp_name":"private-mcp","tool_name":"secret"}`),
+	}})
+	require.NoError(t, err)
+	require.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, "MCP server not found: private-mcp")
+
+	_, _, err = s.handleManageMCPs(ctxB, nil, ManageMCPsInput{Action: "unregister", Name: "private-mcp"})
+	require.ErrorAs(t, err, &notFound)
+
+	_, status, err := s.handleManageMCPs(ctxB, nil, ManageMCPsInput{Action: "status"})
+	require.NoError(t, err)
+	for _, st := range status.Status {
+		assert.NotEqual(t, "private-mcp", st.Name)
+	}
+
+	_, _, err = s.handleManageMCPs(ctxA, nil, ManageMCPsInput{Action: "unregister", Name: "private-mcp"})
+	require.NoError(t, err)
+	assert.True(t, s.mcpVisible(ctxB, "private-mcp"), "ownership is released on unregister")
+}
+
+func TestIsolation_RegisterRestrictions(t *testing.T) {
+	s, ctxA, ctxB := newIsolatedServer(t)
+	s.registry.MarkCachedForTesting("shared-mcp")
+
+	_, _, err := s.handleManageMCPs(ctxA, nil, ManageMCPsInput{Action: "register", Name: "mine", Command: "true", Scope: "user"})
+	assert.ErrorContains(t, err, "scope memory")
+
+	_, _, err = s.handleManageMCPs(ctxA, nil, ManageMCPsInput{Action: "register", Name: "shared-mcp", Command: "true"})
+	assert.ErrorContains(t, err, "already exists")
+
+	_, _, err = s.handleManageMCPs(ctxB, nil, ManageMCPsInput{Action: "unregister", Name: "shared-mcp"})
+	assert.ErrorContains(t, err, "shared by every client")
+	assert.Equal(t, registry.StateCached, s.registry.GetState("shared-mcp"))
+}
+
+func TestIsolation_LocalOverridesPerTenant(t *testing.T) {
+	s, ctxA, ctxB := newIsolatedServer(t)
+
+	_, out, err := s.handleCustomizeTools(ctxA, nil, CustomizeToolsInput{
+		Action:      "set_override",
+		MCP:         "test-mcp",
+		Tool:        "echo",
+		Description: "Echo, as tenant A sees it",
+	})
+	require.NoError(t, err)
+	require.Len(t, out.Entries, 1)
+	assert.Equal(t, "local", out.Entries[0].Scope, "isolated writes default to local scope")
+
+	assert.Equal(t, "Echo, as tenant A sees it", s.overrideFor(ctxA, "test-mcp", "echo", "Echo input", nil).Description)
+	assert.False(t, s.overrideFor(ctxB, "test-mcp", "echo", "Echo input", nil).HasOverride)
+	assert.False(t, s.overrideFor(context.Background(), "test-mcp", "echo", "Echo input", nil).HasOverride,
+		"the shared store is untouched")
+
+	_, _, err = s.handleCustomizeTools(ctxA, nil, CustomizeToolsInput{
+		Action:      "set_override",
+		MCP:         "test-mcp",
+		Tool:        "echo",
+		Description: "for everyone",
+		Scope:       "user",
+	})
+	assert.ErrorContains(t, err, "scope local")
+}
+
+func TestIsolation_CustomToolsPerTenant(t *testing.T) {
+	s, ctxA, ctxB := newIsolatedServer(t)
+
+	_, _, err := s.handleCustomizeTools(ctxA, nil, CustomizeToolsInput{
+		Action:      "define_custom",
+		Name:        "greet",
+		Description: "Say hello",
+		InputSchema: map[string]any{"type": "object"},
+		Body:        `"hello"`,
+	})
+	require.NoError(t, err)
+
+	find := func(ctx context.Context) bool {
+		_, out, err := s.handleSearchTools(ctx, nil, SearchToolsInput{Query: "greet"})
+		require.NoError(t, err)
+		for _, tool := range out.Tools {
+			if tool.MCPName == "_custom" && tool.Name == "greet" {
+				return true
+			}
+		}
+		return false
+	}
+	assert.True(t, find(ctxA))
+	assert.False(t, find(ctxB))
+
+	_, ok := s.lookupCustom(ctxB, "greet")
+	assert.False(t, ok)
+}
+
+func TestHTTPHandler_SessionIsolation(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_TOKEN", "")
+	t.Setenv("SLOP_MCP_HTTP_ISOLATION", "session")
+
+	s := mockServer([]registry.ToolInfo{{Name: "echo", MCPName: "test-mcp", Description: "Echo input"}})
+	s.logger = logging.Nop()
+	s.mcpServer = mcp.NewServer(&mcp.Implementation{Name: serverName, Version: serverVersion}, nil)
+	s.registerTools()
+	store, err := overrides.OpenStore(overrides.StoreOptions{UserRoot: t.TempDir()})
+	require.NoError(t, err)
+	s.SetOverrideStoreForTesting(store)
+	handler, err := s.httpHandler()
+	require.NoError(t, err)
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	a, err := connectStreamable(t, ts.URL+"/mcp", nil)
+	require.NoError(t, err)
+	b, err := connectStreamable(t, ts.URL+"/mcp", nil)
+	require.NoError(t, err)
+
+	res, err := a.CallTool(context.Background(), &mcp.CallToolParams{
+		Name: "customize_tools",
+		Arguments: map[string]any{
+			"action":      "set_override",
+			"mcp":         "test-mcp",
+			"tool":        "echo",
+			"description": "Echo for A",
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+
+	echoDescription := func(cs *mcp.ClientSession) string {
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+			Name:      "search_tools",
+			Arguments: map[string]any{"query": "echo"},
+		})
+		require.NoError(t, err)
+		require.False(t, res.IsError)
+		require.Len(t, res.Content, 1)
+		var out SearchToolsOutput
+		require.NoError(t, json.Unmarshal([]byte(res.Content[0].(*mcp.TextContent).Text), &out))
+		require.NotEmpty(t, out.Tools)
+		return out.Tools[0].Description
+	}
+	assert.Equal(t, "Echo for A", echoDescription(a))
+	assert.Equal(t, "Echo input", echoDescription(b))
+
+	require.NoError(t, a.Close())
+	assert.Eventually(t, func() bool {
+		s.tenants.mu.Lock()
+		defer s.tenants.mu.Unlock()
+		return len(s.tenants.byKey) == 1
+	}, 2*time.Second, 10*time.Millisecond, "a closed session's tenant is dropped")
+}
+
+func TestHTTPHandler_SessionIsolationSSE(t *testing.T) {
+	t.Setenv("SLOP_MCP_HTTP_TOKEN", "")
+	t.Setenv("SLOP_MCP_HTTP_ISOLATION", "session")
+	ts := newHTTPTestServer(t)
+
+	connectSSE := func() *mcp.ClientSession {
+		client := mcp.NewClient(&mcp.Implementation{Name: "agent", Version: "test"}, nil)
+		cs, err := client.Connect(context.Background(), &mcp.SSEClientTransport{Endpoint: ts.URL + "/"}, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = cs.Close() })
+		return cs
+	}
+	a, b := connectSSE(), connectSSE()
+
+	register := func(cs *mcp.ClientSession, scope string) *mcp.CallToolResult {
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+			Name:      "manage_mcps",
+			Arguments: map[string]any{"action": "register", "name": "mine", "command": "true", "scope": scope},
+		})
+		require.NoError(t, err)
+		return res
+	}
+	// Both SSE clients are isolated: a non-memory scope is refused for each.
+	for _, cs := range []*mcp.ClientSession{a, b} {
+		res := register(cs, "user")
+		require.True(t, res.IsError)
+		assert.Contains(t, res.Content[0].(*mcp.TextContent).Text, "scope memory")
+	}
+}
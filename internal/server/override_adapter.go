@@ -1,6 +1,9 @@
 package server
 
 import (
+	"context"
+	"strings"
+
 	"github.com/standardbeagle/slop-mcp/internal/overrides"
 	"github.com/standardbeagle/slop-mcp/internal/registry"
 )
@@ -42,3 +45,30 @@ func (p *storeOverrideProvider) CustomTools() []registry.CustomToolDecl {
 	}
 	return out
 }
+
+// onToolsChanged runs after an upstream MCP re-listed its tools in response to
+// notifications/tools/list_changed. The index has already been rebuilt with
+// overrides applied; this re-checks the MCP's overrides against the new
+// upstream descriptions so one that now masks a changed tool is surfaced.
+func (s *Server) onToolsChanged(mcpName string) {
+	if stale := s.staleOverridesFor(mcpName); len(stale) > 0 {
+		s.logger.Warn("tool overrides are stale after upstream tool list change",
+			"mcp_name", mcpName, "tools", strings.Join(stale, ", "))
+	}
+}
+
+// staleOverridesFor returns the names of mcpName's tools whose override was
+// recorded against a different upstream description or parameter set.
+func (s *Server) staleOverridesFor(mcpName string) []string {
+	if s.overrideStore == nil {
+		return nil
+	}
+	var stale []string
+	for _, t := range s.registry.SearchTools("", mcpName) {
+		ov := s.overrideFor(context.Background(), t.MCPName, t.Name, t.UpstreamDescription(), overrides.ParamDescriptions(t.InputSchema))
+		if ov.HasOverride && ov.Stale {
+			stale = append(stale, t.Name)
+		}
+	}
+	return stale
+}
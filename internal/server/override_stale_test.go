@@ -189,3 +189,19 @@ func TestGetMetadata_ParamMerge_DoesNotPolluteIndex(t *testing.T) {
 	require.Len(t, lo.Entries, 1)
 	require.False(t, lo.Entries[0].Stale, "param merge must not corrupt stale detection")
 }
+
+// TestStaleOverridesFor_AfterUpstreamChange covers the tools/list_changed
+// path: once the MCP re-lists a tool with a new description, its override is
+// reported stale.
+func TestStaleOverridesFor_AfterUpstreamChange(t *testing.T) {
+	s := newStaleTestServer(t)
+	setOverride(t, s, "SHORT override")
+	require.Empty(t, s.staleOverridesFor("mock"))
+
+	s.registry.AddToolsForTesting("mock", []registry.ToolInfo{
+		{Name: "tool_one", Description: "Rewritten upstream description", MCPName: "mock"},
+	})
+	s.onToolsChanged("mock")
+
+	require.Equal(t, []string{"tool_one"}, s.staleOverridesFor("mock"))
+}
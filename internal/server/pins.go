@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+)
+
+// builtinToolNames are the tools registerTools adds; a pin cannot take one
+// of their names.
+var builtinToolNames = map[string]bool{
+	"search_tools":    true,
+	"execute_tool":    true,
+	"run_slop":        true,
+	"manage_mcps":     true,
+	"auth_mcp":        true,
+	"get_metadata":    true,
+	"slop_reference":  true,
+	"slop_help":       true,
+	"agnt_watch":      true,
+	"customize_tools": true,
+	"read_resource":   true,
+	"get_prompt":      true,
+}
+
+// pinnedTool is an upstream tool registered on the outer server under its
+// own name.
+type pinnedTool struct {
+	mcpName  string
+	toolName string
+	tool     *mcp.Tool
+}
+
+// syncPinnedTools brings the pinned tools registered on the outer server in
+// line with the config's pins and the current index, which carries override
+// descriptions and the latest upstream schemas. Pinned tools whose pin,
+// description, or schema changed are re-added and ones no longer pinned are
+// removed; the SDK sends tools/list_changed for either. It runs after every
+// index rebuild and config reload.
+func (s *Server) syncPinnedTools() {
+	if s.mcpServer == nil {
+		return
+	}
+	var pins []config.PinConfig
+	if cfg := s.currentConfig(); cfg != nil {
+		pins = cfg.Pins
+	}
+
+	// Read the index under the lock so a sync that saw an older index can
+	// never apply after one that saw a newer index.
+	s.pinMu.Lock()
+	defer s.pinMu.Unlock()
+
+	want, problems := resolvePins(pins, s.pinnableTools())
+	if msg := strings.Join(problems, "; "); msg != s.pinProblems {
+		s.pinProblems = msg
+		if msg != "" {
+			s.logger.Warn("some pinned tools are not exposed", "problems", msg)
+		}
+	}
+
+	var gone []string
+	for name := range s.pinned {
+		if _, ok := want[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+	if len(gone) > 0 {
+		sort.Strings(gone)
+		s.mcpServer.RemoveTools(gone...)
+	}
+	for name, p := range want {
+		if have, ok := s.pinned[name]; ok && reflect.DeepEqual(have, p) {
+			continue
+		}
+		s.mcpServer.AddTool(p.tool, s.pinnedToolHandler(p.mcpName, p.toolName))
+	}
+	s.pinned = want
+}
+
+// pinnedToolsFilter leaves the pinned tools the caller may not use out of
+// tools/list: those of an MCP its HTTP token does not list or hides from its
+// tenant, and tools the token does not list. forwardToolCall refuses calls
+// of them either way; this keeps them out of the listing too.
+func (s *Server) pinnedToolsFilter(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		res, err := next(ctx, method, req)
+		listed, ok := res.(*mcp.ListToolsResult)
+		if method != "tools/list" || err != nil || !ok {
+			return res, err
+		}
+		s.pinMu.Lock()
+		pinned := s.pinned
+		s.pinMu.Unlock()
+
+		tok := tokenFrom(ctx)
+		tools := listed.Tools[:0:0]
+		for _, t := range listed.Tools {
+			if p, ok := pinned[t.Name]; ok {
+				if !s.mcpVisible(ctx, p.mcpName) || (tok != nil && !tokenAllowsTool(tok, p.mcpName, p.toolName)) {
+					continue
+				}
+			}
+			tools = append(tools, t)
+		}
+		out := *listed
+		out.Tools = tools
+		return &out, nil
+	}
+}
+
+// pinnableTools lists the indexed tools a pin may expose: those of MCPs from
+// the config files. Pinned tools are listed to every client, so tools of MCPs
+// registered at runtime with scope memory, which may belong to one isolated
+// session, are left out even when a glob pin matches them.
+func (s *Server) pinnableTools() []registry.ToolInfo {
+	runtime := make(map[string]bool)
+	for _, c := range s.registry.AllConfigs() {
+		if c.Source == config.SourceRuntime {
+			runtime[c.Name] = true
+		}
+	}
+	tools := s.registry.SearchTools("", "")
+	out := tools[:0:0]
+	for _, t := range tools {
+		if !runtime[t.MCPName] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// resolvePins matches pins against the indexed tools and names each match:
+// the pin's alias, or pinnedToolName. A tool is pinned once; aliased pins are
+// applied first so an alias wins over a glob that also matches the tool,
+// and otherwise the first pin in config order wins. A name taken by a
+// built-in tool or another pinned tool, and a tool whose input schema is not
+// an object, is left out and described in problems.
+func resolvePins(pins []config.PinConfig, tools []registry.ToolInfo) (map[string]pinnedTool, []string) {
+	ordered := make([]config.PinConfig, 0, len(pins))
+	for _, pin := range pins {
+		if pin.Alias != "" {
+			ordered = append(ordered, pin)
+		}
+	}
+	for _, pin := range pins {
+		if pin.Alias == "" {
+			ordered = append(ordered, pin)
+		}
+	}
+
+	want := make(map[string]pinnedTool)
+	done := make(map[string]bool) // "mcp.tool" keys already pinned
+	var problems []string
+	for _, pin := range ordered {
+		for _, t := range tools {
+			key := t.MCPName + "." + t.Name
+			if t.MCPName == "_custom" || done[key] || !pin.Match(t.MCPName, t.Name) {
+				continue
+			}
+			name := pin.Alias
+			if name == "" {
+				name = pinnedToolName(t.MCPName, t.Name)
+			}
+			if builtinToolNames[name] {
+				problems = append(problems, fmt.Sprintf("%s: %q is a built-in tool name", key, name))
+				continue
+			}
+			if other, ok := want[name]; ok {
+				problems = append(problems, fmt.Sprintf("%s: %q is already pinned for %s.%s", key, name, other.mcpName, other.toolName))
+				continue
+			}
+			schema := t.InputSchema
+			if schema == nil {
+				schema = map[string]any{"type": "object"}
+			} else if schema["type"] != "object" {
+				problems = append(problems, fmt.Sprintf("%s: input schema is not an object", key))
+				continue
+			}
+			done[key] = true
+			want[name] = pinnedTool{
+				mcpName:  t.MCPName,
+				toolName: t.Name,
+				tool: &mcp.Tool{
+					Name:        name,
+					Description: t.Description,
+					InputSchema: schema,
+				},
+			}
+		}
+	}
+	sort.Strings(problems)
+	return want, problems
+}
+
+// pinnedToolName is the default top-level name of a pinned tool: mcp_tool,
+// with characters clients reject in tool names replaced by '_'.
+func pinnedToolName(mcpName, toolName string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, mcpName+"_"+toolName)
+}
+
+// pinnedToolHandler forwards a call of a pinned tool to its upstream MCP
+// the way execute_tool does: same timeout, policy, and tenant checks, and
+// the arguments passed through as raw JSON.
+func (s *Server) pinnedToolHandler(mcpName, toolName string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx = withCaller(ctx, req, true)
+		args, err := callToolArguments(req)
+		if err != nil {
+			return errorResult(err), nil
+		}
+		if err := validateExecuteToolParameters(args); err != nil {
+			return errorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+		}
+		ctx, cancel := s.boundToolCall(ctx, mcpName, toolName)
+		defer cancel()
+		return s.forwardToolCall(ctx, mcpName, toolName, args), nil
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPinTestServer returns a server with the given pins wired the way New
+// wires them, and a client session connected to it.
+func newPinTestServer(t *testing.T, pins ...config.PinConfig) (*Server, *mcp.ClientSession) {
+	t.Helper()
+	s := mockServer(nil)
+	s.logger = logging.Nop()
+	s.config = config.NewConfig()
+	s.config.Pins = pins
+	s.mcpServer = mcp.NewServer(&mcp.Implementation{Name: serverName, Version: serverVersion}, &mcp.ServerOptions{
+		Capabilities: &mcp.ServerCapabilities{Tools: &mcp.ToolCapabilities{ListChanged: true}},
+	})
+	s.registerTools()
+	s.registry.SetIndexChangedHandler(s.syncPinnedTools)
+	t.Cleanup(func() { _ = s.registry.Close() })
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.mcpServer.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ss.Close() })
+	cs, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cs.Close() })
+	return s, cs
+}
+
+// listedTools returns the descriptions of the server's tools by name.
+func listedTools(t *testing.T, cs *mcp.ClientSession) map[string]string {
+	t.Helper()
+	res, err := cs.ListTools(context.Background(), nil)
+	require.NoError(t, err)
+	out := make(map[string]string, len(res.Tools))
+	for _, tool := range res.Tools {
+		out[tool.Name] = tool.Description
+	}
+	return out
+}
+
+type pinOverrides struct {
+	descriptions map[string]string
+}
+
+func (p *pinOverrides) OverrideFor(mcpName, toolName string) (string, map[string]string, string, bool) {
+	d, ok := p.descriptions[mcpName+"."+toolName]
+	return d, nil, "", ok
+}
+
+func (p *pinOverrides) CustomTools() []registry.CustomToolDecl { return nil }
+
+func TestBuiltinToolNamesMatchRegisteredTools(t *testing.T) {
+	_, cs := newPinTestServer(t)
+	listed := listedTools(t, cs)
+	assert.Len(t, listed, len(builtinToolNames))
+	for name := range listed {
+		assert.True(t, builtinToolNames[name], "%s is missing from builtinToolNames", name)
+	}
+}
+
+func TestResolvePins(t *testing.T) {
+	object := map[string]any{"type": "object"}
+	tools := []registry.ToolInfo{
+		{MCPName: "fs", Name: "read_file", InputSchema: object},
+		{MCPName: "fs", Name: "write_file", InputSchema: object},
+		{MCPName: "my.git", Name: "log", Description: "git log"},
+		{MCPName: "odd", Name: "scalar", InputSchema: map[string]any{"type": "string"}},
+		{MCPName: "_custom", Name: "mine", InputSchema: object},
+	}
+
+	want, problems := resolvePins([]config.PinConfig{
+		{Pattern: "fs.*"},
+		{Pattern: "fs.read_file", Alias: "read"},
+		{Pattern: "my.git.log"},
+		{Pattern: "odd.*"},
+		{Pattern: "*.mine"},
+	}, tools)
+	require.Len(t, want, 2)
+	assert.Equal(t, "read_file", want["read"].toolName, "an alias wins over a glob")
+	assert.Equal(t, "write_file", want["fs_write_file"].toolName)
+	assert.Contains(t, problems, "odd.scalar: input schema is not an object")
+	assert.Len(t, problems, 1, "custom tools are never pinned: %v", problems)
+
+	want, problems = resolvePins([]config.PinConfig{
+		{Pattern: "fs.read_file", Alias: "search_tools"},
+		{Pattern: "fs.write_file", Alias: "edit"},
+		{Pattern: "my*.log", Alias: "edit"},
+	}, tools)
+	assert.Len(t, want, 1)
+	assert.Equal(t, []string{
+		`fs.read_file: "search_tools" is a built-in tool name`,
+		`my.git.log: "edit" is already pinned for fs.write_file`,
+	}, problems)
+
+	assert.Equal(t, "my_git_tool.v2", pinnedToolName("my git", "tool.v2"))
+}
+
+func TestSyncPinnedTools_FollowsIndexOverridesAndConfig(t *testing.T) {
+	s, cs := newPinTestServer(t, config.PinConfig{Pattern: "fs.read_*"})
+	assert.NotContains(t, listedTools(t, cs), "fs_read_file", "nothing is pinned before the tools are known")
+
+	s.registry.AddToolsForTesting("fs", []registry.ToolInfo{
+		{MCPName: "fs", Name: "read_file", Description: "Read a file"},
+		{MCPName: "fs", Name: "write_file", Description: "Write a file"},
+	})
+	listed := listedTools(t, cs)
+	assert.Equal(t, "Read a file", listed["fs_read_file"])
+	assert.NotContains(t, listed, "fs_write_file")
+
+	s.registry.SetOverrideProvider(&pinOverrides{descriptions: map[string]string{"fs.read_file": "Read a project file"}})
+	assert.Equal(t, "Read a project file", listedTools(t, cs)["fs_read_file"], "override descriptions are used")
+
+	cfg := config.NewConfig()
+	cfg.Pins = []config.PinConfig{{Pattern: "fs.write_file", Alias: "write"}}
+	s.configMu.Lock()
+	s.config = cfg
+	s.configMu.Unlock()
+	s.syncPinnedTools()
+	listed = listedTools(t, cs)
+	assert.NotContains(t, listed, "fs_read_file")
+	assert.Equal(t, "Write a file", listed["write"])
+
+	s.registry.AddToolsForTesting("fs", nil)
+	assert.NotContains(t, listedTools(t, cs), "write", "tools gone upstream are unpinned")
+}
+
+func TestSyncPinnedTools_SkipsRuntimeMCPs(t *testing.T) {
+	s, cs := newPinTestServer(t, config.PinConfig{Pattern: "*.read_*"})
+	s.registry.SetConfigured(config.MCPConfig{Name: "scratch", Type: "stdio", Command: "x", Source: config.SourceRuntime})
+	s.registry.AddToolsForTesting("scratch", []registry.ToolInfo{{MCPName: "scratch", Name: "read_secret", Description: "A tenant's tool"}})
+	s.registry.AddToolsForTesting("fs", []registry.ToolInfo{{MCPName: "fs", Name: "read_file", Description: "Read a file"}})
+
+	listed := listedTools(t, cs)
+	assert.Contains(t, listed, "fs_read_file")
+	assert.NotContains(t, listed, "scratch_read_secret", "a scope memory MCP is never listed to every client")
+}
+
+func TestPinnedToolForwardsCall(t *testing.T) {
+	upstream := mcp.NewServer(&mcp.Implementation{Name: "upstream", Version: "1.0.0"}, nil)
+	upstream.AddTool(&mcp.Tool{
+		Name:        "echo",
+		Description: "Echo the arguments",
+		InputSchema: map[string]any{"type": "object"},
+	}, func(_ context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(req.Params.Arguments)}}}, nil
+	})
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return upstream }, nil))
+	t.Cleanup(ts.Close)
+
+	s, cs := newPinTestServer(t, config.PinConfig{Pattern: "up.echo", Alias: "echo"})
+	ctx := context.Background()
+	require.NoError(t, s.registry.Connect(ctx, config.MCPConfig{Name: "up", Type: "streamable", URL: ts.URL}))
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "echo", Arguments: json.RawMessage(`{"id":12345678901234567890}`)})
+	require.NoError(t, err)
+	require.False(t, res.IsError, "%+v", res.Content)
+	require.Len(t, res.Content, 1)
+	assert.JSONEq(t, `{"id":12345678901234567890}`, res.Content[0].(*mcp.TextContent).Text)
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+)
+
+// GetPromptInput is the input for the get_prompt tool.
+type GetPromptInput struct {
+	MCPName    string         `json:"mcp_name"`
+	PromptName string         `json:"prompt_name"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+}
+
+func (s *Server) handleGetPrompt(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input GetPromptInput,
+) (*mcp.CallToolResult, *registry.PromptResult, error) {
+	if input.MCPName == "" {
+		return nil, nil, fmt.Errorf("mcp_name is required")
+	}
+	if input.PromptName == "" {
+		return nil, nil, fmt.Errorf("prompt_name is required (find prompts with search_tools include_prompts=true)")
+	}
+
+	if err := s.checkMCPAllowed(ctx, input.MCPName); err != nil {
+		return nil, nil, err
+	}
+	if s.hiddenByTenant(ctx, input.MCPName) {
+		return nil, nil, hiddenMCPError(input.MCPName)
+	}
+
+	result, err := s.registry.GetPrompt(ctx, input.MCPName, input.PromptName, input.Arguments)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, result, nil
+}
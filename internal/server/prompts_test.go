@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleGetPrompt_Validation(t *testing.T) {
+	s := mockServer(nil)
+	ctx := context.Background()
+
+	_, _, err := s.handleGetPrompt(ctx, &mcp.CallToolRequest{}, GetPromptInput{PromptName: "p"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mcp_name is required")
+
+	_, _, err = s.handleGetPrompt(ctx, &mcp.CallToolRequest{}, GetPromptInput{MCPName: "lib"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prompt_name is required")
+}
+
+func TestHandleGetPrompt_MCPNotFound(t *testing.T) {
+	s := mockServer(nil)
+
+	_, _, err := s.handleGetPrompt(context.Background(), &mcp.CallToolRequest{}, GetPromptInput{
+		MCPName:    "missing",
+		PromptName: "p",
+	})
+	var notFound *registry.MCPNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestHandleSearchTools_PromptsOnlyWhenRequested(t *testing.T) {
+	s := mockServer([]registry.ToolInfo{{Name: "review", Description: "Review code", MCPName: "test-mcp"}})
+	ctx := context.Background()
+
+	_, output, err := s.handleSearchTools(ctx, &mcp.CallToolRequest{}, SearchToolsInput{Query: "review"})
+	require.NoError(t, err)
+	assert.Nil(t, output.Prompts)
+	assert.Equal(t, 1, output.Total)
+
+	// No connected MCP serves prompts: the field stays empty and tool
+	// results are unaffected.
+	_, output, err = s.handleSearchTools(ctx, &mcp.CallToolRequest{}, SearchToolsInput{Query: "review", IncludePrompts: true})
+	require.NoError(t, err)
+	assert.Empty(t, output.Prompts)
+	assert.Equal(t, 1, output.Total)
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/standardbeagle/slop-mcp/internal/cache"
+	"github.com/standardbeagle/slop-mcp/internal/cli"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+)
+
+// ConfigReloadIntervalEnvVar overrides how often serve checks its config
+// files for changes ("0" turns hot reload off).
+const ConfigReloadIntervalEnvVar = "SLOP_MCP_RELOAD_INTERVAL"
+
+// defaultConfigReloadInterval is how often the config files are checked.
+const defaultConfigReloadInterval = 2 * time.Second
+
+// configReloadInterval returns the config poll interval, honoring
+// SLOP_MCP_RELOAD_INTERVAL (a Go duration; "0" disables reloading).
+func configReloadInterval() time.Duration {
+	if v := strings.TrimSpace(os.Getenv(ConfigReloadIntervalEnvVar)); v != "" {
+		if v == "0" {
+			return 0
+		}
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultConfigReloadInterval
+}
+
+// EnableConfigReload makes Start watch the user, project, and local config
+// files of projectDir, the files they include, and the CLI tool directories,
+// applying edits while the server runs. Reloads select the given profiles.
+// It must be called before Start; serve enables it, servers embedded
+// elsewhere keep the config they were built with.
+func (s *Server) EnableConfigReload(projectDir string, profiles []string) {
+	s.reloadDir = projectDir
+	s.reloadProfiles = profiles
+}
+
+// currentConfig returns the config loaded at startup or by the last reload.
+func (s *Server) currentConfig() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// configReload lists what one reload changed, by MCP name.
+type configReload struct {
+	Added       []string // newly configured and connecting
+	Removed     []string // gone from every config file and unregistered
+	Reconnected []string // command, URL, env, or headers changed
+	Updated     []string // other settings changed, applied in place
+}
+
+func (c configReload) empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Reconnected) == 0 && len(c.Updated) == 0
+}
+
+// watchConfig polls the watched files every interval until ctx ends and
+// reloads after any of them changed. Polling needs no platform file-event
+// API and also sees editors that save by renaming a new file into place.
+func (s *Server) watchConfig(ctx context.Context, interval time.Duration) {
+	last := fingerprintPaths(s.configWatchPaths())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		fp := fingerprintPaths(s.configWatchPaths())
+		if fp == last {
+			continue
+		}
+		last = fp
+		if _, err := s.reloadConfig(ctx); err != nil {
+			// A half-saved or mistyped file must not tear down working MCPs:
+			// keep serving the last good config until the next edit.
+			s.logger.Warn("config reload failed; keeping the running config", "error", err)
+		}
+	}
+}
+
+// configWatchPaths returns the files and directories whose changes trigger
+// a reload: the three config tiers, the files the running config included,
+// and the CLI tool directories.
+func (s *Server) configWatchPaths() []string {
+	paths := []string{
+		config.UserConfigPath(),
+		config.ProjectConfigPath(s.reloadDir),
+		config.LocalConfigPath(s.reloadDir),
+	}
+	if cfg := s.currentConfig(); cfg != nil {
+		paths = append(paths, cfg.Files...)
+	}
+	return append(paths, cliToolDirs(s.reloadDir)...)
+}
+
+// fingerprintPaths hashes the contents of paths, and of the .kdl files in
+// those that are directories. A missing path hashes as absent, so creating
+// or deleting a file changes the fingerprint.
+func fingerprintPaths(paths []string) string {
+	h := sha256.New()
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		fmt.Fprintf(h, "%s\n", p)
+		info, err := os.Stat(p)
+		if err != nil {
+			h.Write([]byte("absent\n"))
+			continue
+		}
+		if !info.IsDir() {
+			hashFile(h, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".kdl") {
+				continue
+			}
+			fmt.Fprintf(h, "%s\n", e.Name())
+			hashFile(h, filepath.Join(p, e.Name()))
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func hashFile(w io.Writer, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = io.Copy(w, f)
+}
+
+// reloadConfig re-reads the config files and brings the registry in line
+// with them: added MCPs connect, removed ones are unregistered, ones whose
+// connection settings changed reconnect (see needsReconnect), and other edits
+// apply in place. MCPs registered at runtime with scope memory are left
+// alone. CLI tools and pinned tools are reloaded too; the pins are the only
+// listed tools a reload can change, and syncPinnedTools sends clients
+// tools/list_changed when they do. A config that fails to parse changes
+// nothing and is returned as an error.
+func (s *Server) reloadConfig(ctx context.Context) (configReload, error) {
+	cfg, err := config.LoadProfiles(s.reloadDir, s.reloadProfiles)
+	if err != nil {
+		return configReload{}, err
+	}
+
+	var res configReload
+	running := make(map[string]config.MCPConfig)
+	for _, c := range s.registry.AllConfigs() {
+		running[c.Name] = c
+	}
+	connect := config.NewConfig()
+
+	for name, have := range running {
+		if _, ok := cfg.MCPs[name]; ok || have.Source == config.SourceRuntime {
+			continue
+		}
+		if err := s.registry.Unregister(ctx, name); err != nil {
+			s.logger.Warn("config reload: cannot unregister MCP", "mcp_name", name, "error", err)
+			continue
+		}
+		res.Removed = append(res.Removed, name)
+	}
+
+	for name, want := range cfg.MCPs {
+		have, ok := running[name]
+		switch {
+		case !ok:
+			s.registry.SetConfigured(want)
+			connect.MCPs[name] = want
+			res.Added = append(res.Added, name)
+		case have.Source == config.SourceRuntime:
+			// A runtime registration shadows the file entry of the same name
+			// until it is unregistered.
+			continue
+		case needsReconnect(have, want):
+			if err := s.registry.Unregister(ctx, name); err != nil {
+				s.logger.Warn("config reload: cannot reconnect MCP", "mcp_name", name, "error", err)
+				continue
+			}
+			s.registry.SetConfigured(want)
+			connect.MCPs[name] = want
+			res.Reconnected = append(res.Reconnected, name)
+		case !reflect.DeepEqual(have, want):
+			if _, err := s.registry.UpdateConfig(ctx, want); err != nil {
+				s.logger.Warn("config reload: cannot update MCP", "mcp_name", name, "error", err)
+				continue
+			}
+			res.Updated = append(res.Updated, name)
+		}
+	}
+
+	s.configMu.Lock()
+	s.config = cfg
+	s.configMu.Unlock()
+	s.syncPinnedTools()
+
+	cliChanged := s.reloadCLITools()
+
+	if len(connect.MCPs) > 0 {
+		if cached := s.registry.LoadCache(connect); cached > 0 {
+			s.logger.Info("loaded MCPs from cache", "count", cached)
+		}
+		go func() {
+			if err := s.registry.ConnectFromConfig(ctx, connect); err != nil {
+				s.logger.Debug("error connecting to MCPs", "error", err)
+			}
+		}()
+	}
+
+	sort.Strings(res.Added)
+	sort.Strings(res.Removed)
+	sort.Strings(res.Reconnected)
+	sort.Strings(res.Updated)
+	if !res.empty() || cliChanged {
+		s.logger.Info("config reloaded",
+			"added", res.Added,
+			"removed", res.Removed,
+			"reconnected", res.Reconnected,
+			"updated", res.Updated,
+			"cli_tools_changed", cliChanged)
+	}
+	return res, nil
+}
+
+// needsReconnect reports whether moving an MCP from config have to want
+// needs a new session: its connection settings (cache.ConfigHash) changed, or
+// its sampling setting did, since whether sampling is advertised to the MCP
+// is fixed when the session is made. So does any oauth change: the hash
+// leaves out a literal client secret, and the session's client_credentials
+// flow holds the secret it resolved at connect.
+func needsReconnect(have, want config.MCPConfig) bool {
+	return cache.ConfigHash(have) != cache.ConfigHash(want) ||
+		have.Sampling != want.Sampling ||
+		!reflect.DeepEqual(have.OAuth, want.OAuth)
+}
+
+// reloadCLITools re-reads the CLI tool directories into a fresh registry
+// and swaps it in, reporting whether the tool set changed.
+func (s *Server) reloadCLITools() bool {
+	fresh := cli.NewRegistry()
+	s.loadCLIToolsInto(fresh, s.reloadDir)
+	if reflect.DeepEqual(fresh.List(), s.cliRegistry.List()) {
+		return false
+	}
+	s.cliRegistry.Replace(fresh)
+	return true
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/config"
+	"github.com/standardbeagle/slop-mcp/internal/logging"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReloadTestServer returns a server watching a temp project directory,
+// with the user config directory redirected to another temp directory.
+func newReloadTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := t.TempDir()
+	s := mockServer(nil)
+	s.logger = logging.Nop()
+	s.config = config.NewConfig()
+	s.EnableConfigReload(dir, nil)
+	t.Cleanup(func() { _ = s.registry.Close() })
+	return s, dir
+}
+
+func writeProjectConfig(t *testing.T, dir, kdl string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(config.ProjectConfigPath(dir), []byte(kdl), 0o644))
+}
+
+func configFor(s *Server, name string) (config.MCPConfig, bool) {
+	for _, c := range s.registry.AllConfigs() {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return config.MCPConfig{}, false
+}
+
+func TestReloadConfig_DiffsAgainstRegistry(t *testing.T) {
+	s, dir := newReloadTestServer(t)
+	ctx := context.Background()
+
+	project := config.ProjectConfigPath(dir)
+	s.registry.SetConfigured(config.MCPConfig{Name: "same", Type: "stdio", Command: "slop-reload-missing-a", Source: config.SourceProject, File: project})
+	s.registry.SetConfigured(config.MCPConfig{Name: "tuned", Type: "stdio", Command: "slop-reload-missing-b", Source: config.SourceProject, File: project})
+	s.registry.SetConfigured(config.MCPConfig{Name: "moved", Type: "stdio", Command: "slop-reload-missing-c", Source: config.SourceProject, File: project})
+	s.registry.SetConfigured(config.MCPConfig{Name: "dropped", Type: "stdio", Command: "slop-reload-missing-d", Source: config.SourceProject, File: project})
+	s.registry.SetConfigured(config.MCPConfig{Name: "scratch", Type: "stdio", Command: "slop-reload-missing-e", Source: config.SourceRuntime})
+
+	writeProjectConfig(t, dir, `
+mcp "same" {
+    command "slop-reload-missing-a"
+}
+mcp "tuned" {
+    command "slop-reload-missing-b"
+    timeout "5s"
+}
+mcp "moved" {
+    command "slop-reload-missing-c2"
+}
+mcp "fresh" {
+    command "slop-reload-missing-f"
+}
+`)
+
+	res, err := s.reloadConfig(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fresh"}, res.Added)
+	assert.Equal(t, []string{"dropped"}, res.Removed, "runtime registrations are not removed")
+	assert.Equal(t, []string{"moved"}, res.Reconnected)
+	assert.Equal(t, []string{"tuned"}, res.Updated)
+
+	cfg, ok := configFor(s, "moved")
+	require.True(t, ok)
+	assert.Equal(t, "slop-reload-missing-c2", cfg.Command)
+	cfg, ok = configFor(s, "tuned")
+	require.True(t, ok)
+	assert.Equal(t, "5s", cfg.Timeout)
+	_, ok = configFor(s, "scratch")
+	assert.True(t, ok)
+	_, ok = configFor(s, "dropped")
+	assert.False(t, ok)
+
+	got, err := s.findMCPConfig("fresh")
+	require.NoError(t, err, "the server config is replaced too")
+	assert.Equal(t, "slop-reload-missing-f", got.Command)
+
+	res, err = s.reloadConfig(ctx)
+	require.NoError(t, err)
+	assert.True(t, res.empty(), "a second reload of the same files changes nothing: %+v", res)
+}
+
+func TestReloadConfig_SamplingDenyReconnects(t *testing.T) {
+	s, dir := newReloadTestServer(t)
+	ctx := context.Background()
+
+	upstream := mcp.NewServer(&mcp.Implementation{Name: "writer", Version: "1.0.0"}, nil)
+	upstream.AddTool(&mcp.Tool{Name: "ask", InputSchema: map[string]any{"type": "object"}},
+		func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if p := req.Session.InitializeParams(); p.Capabilities == nil || p.Capabilities.Sampling == nil {
+				return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "no sampling capability"}}}, nil
+			}
+			res, err := req.Session.CreateMessage(ctx, &mcp.CreateMessageParams{
+				Messages:  []*mcp.SamplingMessage{{Role: "user", Content: &mcp.TextContent{Text: "hello"}}},
+				MaxTokens: 10,
+			})
+			if err != nil {
+				return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}}}, nil
+			}
+			return &mcp.CallToolResult{Content: []mcp.Content{res.Content}}, nil
+		})
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return upstream }, nil))
+	t.Cleanup(ts.Close)
+	// Cleanups run last-in first-out: close the registry's sessions before ts.
+	t.Cleanup(func() { _ = s.registry.Close() })
+
+	// The downstream client that made the call, and would be asked to sample.
+	var sampled atomic.Int32
+	outer := mcp.NewServer(&mcp.Implementation{Name: "slop-mcp", Version: "test"}, nil)
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	downstream, err := outer.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+	agent, err := mcp.NewClient(&mcp.Implementation{Name: "agent", Version: "test"}, &mcp.ClientOptions{
+		CreateMessageHandler: func(context.Context, *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+			sampled.Add(1)
+			return &mcp.CreateMessageResult{Role: "assistant", Model: "m", Content: &mcp.TextContent{Text: "sampled"}}, nil
+		},
+	}).Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = agent.Close() })
+
+	ask := func() string {
+		t.Helper()
+		callCtx := registry.WithCaller(ctx, &registry.Caller{Session: downstream})
+		res, err := s.registry.ExecuteToolRaw(callCtx, "writer", "ask", nil)
+		require.NoError(t, err)
+		require.Len(t, res.Content, 1)
+		return res.Content[0].(*mcp.TextContent).Text
+	}
+
+	writeProjectConfig(t, dir, fmt.Sprintf("mcp \"writer\" {\n    url %q\n}\n", ts.URL))
+	_, err = s.reloadConfig(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "sampled", ask())
+
+	writeProjectConfig(t, dir, fmt.Sprintf("mcp \"writer\" {\n    url %q\n    sampling \"deny\"\n}\n", ts.URL))
+	res, err := s.reloadConfig(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"writer"}, res.Reconnected, "sampling is fixed per session")
+	assert.Equal(t, "no sampling capability", ask())
+	assert.Equal(t, int32(1), sampled.Load(), "no sampling request is relayed after the reload")
+}
+
+func TestNeedsReconnect_OAuthSecret(t *testing.T) {
+	have := config.MCPConfig{Name: "api", Type: "http", URL: "https://api/mcp",
+		OAuth: &config.OAuthConfig{ClientID: "ci", ClientSecret: "old", TokenEndpoint: "https://auth/token"}}
+	want := have
+	want.OAuth = &config.OAuthConfig{ClientID: "ci", ClientSecret: "new", TokenEndpoint: "https://auth/token"}
+	assert.True(t, needsReconnect(have, want), "a literal secret is not in the hash but still reconnects")
+	want.OAuth.ClientSecret = "old"
+	assert.False(t, needsReconnect(have, want))
+}
+
+func TestReloadConfig_ParseErrorKeepsRunningConfig(t *testing.T) {
+	s, dir := newReloadTestServer(t)
+	s.registry.SetConfigured(config.MCPConfig{Name: "keep", Type: "stdio", Command: "slop-reload-missing", Source: config.SourceProject})
+
+	writeProjectConfig(t, dir, `mcp "keep" {`)
+	_, err := s.reloadConfig(context.Background())
+	require.Error(t, err)
+	_, ok := configFor(s, "keep")
+	assert.True(t, ok, "a broken file must not unregister anything")
+}
+
+func TestReloadConfig_ReloadsCLITools(t *testing.T) {
+	s, dir := newReloadTestServer(t)
+	cliDir := filepath.Join(dir, ".slop-mcp", "cli")
+	require.NoError(t, os.MkdirAll(cliDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(cliDir, "tools.kdl"), []byte(`
+cli "greet" {
+    description "Say hello"
+    command "echo"
+}
+`), 0o644))
+
+	require.True(t, s.reloadCLITools())
+	require.NotNil(t, s.cliRegistry.Get("greet"))
+	assert.False(t, s.reloadCLITools(), "unchanged directories are not a change")
+
+	require.NoError(t, os.Remove(filepath.Join(cliDir, "tools.kdl")))
+	require.True(t, s.reloadCLITools())
+	assert.Nil(t, s.cliRegistry.Get("greet"))
+}
+
+func TestReloadConfig_NotifiesOnlyWhenToolsChange(t *testing.T) {
+	s, dir := newReloadTestServer(t)
+	s.mcpServer = mcp.NewServer(&mcp.Implementation{Name: serverName, Version: serverVersion}, &mcp.ServerOptions{
+		Capabilities: &mcp.ServerCapabilities{Tools: &mcp.ToolCapabilities{ListChanged: true}},
+	})
+	s.registerTools()
+	s.registry.AddToolsForTesting("fs", []registry.ToolInfo{{MCPName: "fs", Name: "read_file", Description: "Read a file"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := s.mcpServer.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+	defer ss.Close()
+
+	changed := make(chan struct{}, 1)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test"}, &mcp.ClientOptions{
+		ToolListChangedHandler: func(context.Context, *mcp.ToolListChangedRequest) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		},
+	})
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	defer cs.Close()
+
+	// A new MCP is reached through search_tools; the listed tools stay the same.
+	writeProjectConfig(t, dir, `
+mcp "fresh" {
+    command "slop-reload-missing"
+}
+`)
+	_, err = s.reloadConfig(ctx)
+	require.NoError(t, err)
+	select {
+	case <-changed:
+		t.Fatal("tools/list_changed sent although the listed tools did not change")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	writeProjectConfig(t, dir, `
+mcp "fresh" {
+    command "slop-reload-missing"
+}
+pin "fs.read_file"
+`)
+	_, err = s.reloadConfig(ctx)
+	require.NoError(t, err)
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tools/list_changed notification after a reload that pinned a tool")
+	}
+	assert.Contains(t, listedTools(t, cs), "fs_read_file")
+}
+
+func TestFingerprintPaths(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.kdl")
+	sub := filepath.Join(dir, "cli")
+	paths := []string{file, sub}
+
+	absent := fingerprintPaths(paths)
+	require.NoError(t, os.WriteFile(file, []byte("a"), 0o644))
+	created := fingerprintPaths(paths)
+	assert.NotEqual(t, absent, created)
+
+	require.NoError(t, os.WriteFile(file, []byte("b"), 0o644))
+	edited := fingerprintPaths(paths)
+	assert.NotEqual(t, created, edited, "same size, new content")
+
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "notes.txt"), []byte("x"), 0o644))
+	withDir := fingerprintPaths(paths)
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "notes.txt"), []byte("y"), 0o644))
+	assert.Equal(t, withDir, fingerprintPaths(paths), "only .kdl files in directories count")
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "tools.kdl"), []byte("cli"), 0o644))
+	assert.NotEqual(t, withDir, fingerprintPaths(paths))
+}
+
+func TestConfigReloadInterval(t *testing.T) {
+	t.Setenv(ConfigReloadIntervalEnvVar, "")
+	assert.Equal(t, defaultConfigReloadInterval, configReloadInterval())
+	t.Setenv(ConfigReloadIntervalEnvVar, "250ms")
+	assert.Equal(t, 250*time.Millisecond, configReloadInterval())
+	t.Setenv(ConfigReloadIntervalEnvVar, "0")
+	assert.Equal(t, time.Duration(0), configReloadInterval())
+	t.Setenv(ConfigReloadIntervalEnvVar, "soon")
+	assert.Equal(t, defaultConfigReloadInterval, configReloadInterval())
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+)
+
+// ReadResourceInput is the input for the read_resource tool.
+type ReadResourceInput struct {
+	MCPName string         `json:"mcp_name"`
+	URI     string         `json:"uri"`
+	Vars    map[string]any `json:"vars,omitempty"`
+}
+
+func (s *Server) handleReadResource(
+	ctx context.Context,
+	req *mcp.CallToolRequest,
+	input ReadResourceInput,
+) (*mcp.CallToolResult, *registry.ResourceReadResult, error) {
+	if input.MCPName == "" {
+		return nil, nil, fmt.Errorf("mcp_name is required")
+	}
+	if input.URI == "" {
+		return nil, nil, fmt.Errorf("uri is required (a resource URI, or a resource template URI with vars)")
+	}
+
+	if err := s.checkMCPAllowed(ctx, input.MCPName); err != nil {
+		return nil, nil, err
+	}
+	if s.hiddenByTenant(ctx, input.MCPName) {
+		return nil, nil, hiddenMCPError(input.MCPName)
+	}
+
+	result, err := s.registry.ReadResource(ctx, input.MCPName, input.URI, input.Vars)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, result, nil
+}
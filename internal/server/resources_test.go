@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleReadResource_Validation(t *testing.T) {
+	s := mockServer(nil)
+	ctx := context.Background()
+
+	_, _, err := s.handleReadResource(ctx, &mcp.CallToolRequest{}, ReadResourceInput{URI: "file:///x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mcp_name is required")
+
+	_, _, err = s.handleReadResource(ctx, &mcp.CallToolRequest{}, ReadResourceInput{MCPName: "docs"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "uri is required")
+}
+
+func TestHandleReadResource_MCPNotFound(t *testing.T) {
+	s := mockServer(nil)
+
+	_, _, err := s.handleReadResource(context.Background(), &mcp.CallToolRequest{}, ReadResourceInput{
+		MCPName: "missing",
+		URI:     "file:///x",
+	})
+	require.Error(t, err)
+	var notFound *registry.MCPNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestWrapReadResource_TemplateErrorSurfaces(t *testing.T) {
+	s := mockServer(nil)
+
+	result, err := s.wrapReadResource(context.Background(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Arguments: []byte(`{"mcp_name":"docs","uri":"file:///{path}","vars":{"nope":"x"}}`)},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, extractErrorText(t, result), `requires variable "path"`)
+}
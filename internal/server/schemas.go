@@ -25,6 +25,10 @@ var searchToolsInputSchema = json.RawMessage(`{
 		"offset": {
 			"type": "integer",
 			"description": "Results to skip for pagination (default: 0)"
+		},
+		"include_prompts": {
+			"type": "boolean",
+			"description": "Also search prompts of connected MCPs (returned in prompts, capped at limit)"
 		}
 	},
 	"additionalProperties": false
@@ -78,15 +82,23 @@ var manageMCPsInputSchema = json.RawMessage(`{
 	"properties": {
 		"action": {
 			"type": "string",
-			"description": "Action: register, unregister, reconnect, list, status, health_check, or list_stale_overrides"
+			"description": "Action: register, unregister, reconnect, list, status, health_check, list_stale_overrides, cache_inspect, cache_flush, or logs"
 		},
 		"name": {
 			"type": "string",
-			"description": "MCP name (required for register/unregister/reconnect)"
+			"description": "MCP name (required for register/unregister/reconnect/logs; scopes cache_inspect/cache_flush)"
+		},
+		"tool": {
+			"type": "string",
+			"description": "Tool name to limit cache_flush to (requires name)"
+		},
+		"lines": {
+			"type": "integer",
+			"description": "Most recent stderr lines to return for logs (default: all buffered)"
 		},
 		"type": {
 			"type": "string",
-			"description": "Transport: command (default), sse, or streamable"
+			"description": "Transport: command (default), sse, streamable, or websocket"
 		},
 		"command": {
 			"type": "string",
@@ -283,3 +295,47 @@ var customizeToolsInputSchema = json.RawMessage(`{
 	"required": ["action"],
 	"additionalProperties": false
 }`)
+
+// readResourceInputSchema is the input schema for read_resource.
+var readResourceInputSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"mcp_name": {
+			"type": "string",
+			"description": "MCP that serves the resource"
+		},
+		"uri": {
+			"type": "string",
+			"description": "Resource URI, or resource template URI when vars is set"
+		},
+		"vars": {
+			"type": "object",
+			"description": "Template variables (RFC 6570); every template variable is required",
+			"additionalProperties": true
+		}
+	},
+	"required": ["mcp_name", "uri"],
+	"additionalProperties": false
+}`)
+
+// getPromptInputSchema is the input schema for get_prompt.
+var getPromptInputSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"mcp_name": {
+			"type": "string",
+			"description": "MCP that serves the prompt"
+		},
+		"prompt_name": {
+			"type": "string",
+			"description": "Prompt to render"
+		},
+		"arguments": {
+			"type": "object",
+			"description": "Prompt arguments; non-string values are JSON-encoded",
+			"additionalProperties": true
+		}
+	},
+	"required": ["mcp_name", "prompt_name"],
+	"additionalProperties": false
+}`)
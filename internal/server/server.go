@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -42,6 +44,22 @@ type Server struct {
 	sessionStore  *builtins.SessionStore
 	memoryStore   *builtins.MemoryStore
 	overrideStore *overrides.Store
+	// configMu guards config, which a config reload replaces.
+	configMu sync.RWMutex
+	// reloadDir is the project directory whose config files are watched
+	// for changes; empty when hot reload is off.
+	reloadDir string
+	// reloadProfiles are the config profiles a reload selects.
+	reloadProfiles []string
+	// tenants holds per-client state in isolated HTTP mode; nil otherwise.
+	tenants *tenants
+	// httpSetup installs the HTTP-only receiving middleware exactly once.
+	httpSetup sync.Once
+	// pinMu guards pinned and pinProblems, the pinned tools registered on
+	// mcpServer and the last reported reasons some pins are not exposed.
+	pinMu       sync.Mutex
+	pinned      map[string]pinnedTool
+	pinProblems string
 }
 
 // openOverrideStore builds and opens the overrides store using standard config paths,
@@ -93,13 +111,15 @@ func New(ctx context.Context, mcps []config.MCPConfig) (*Server, error) {
 		},
 		&mcp.ServerOptions{
 			Capabilities: &mcp.ServerCapabilities{
-				Tools: &mcp.ToolCapabilities{},
+				Tools: &mcp.ToolCapabilities{ListChanged: true},
 			},
 		},
 	)
 
 	// Register all tools
 	s.registerTools()
+	s.registry.SetToolsChangedHandler(s.onToolsChanged)
+	s.registry.SetIndexChangedHandler(s.syncPinnedTools)
 
 	// Connect to provided MCPs
 	for _, mcpCfg := range mcps {
@@ -137,13 +157,15 @@ func NewFromConfig(cfg *config.Config) (*Server, error) {
 		},
 		&mcp.ServerOptions{
 			Capabilities: &mcp.ServerCapabilities{
-				Tools: &mcp.ToolCapabilities{},
+				Tools: &mcp.ToolCapabilities{ListChanged: true},
 			},
 		},
 	)
 
 	// Register all tools
 	s.registerTools()
+	s.registry.SetToolsChangedHandler(s.onToolsChanged)
+	s.registry.SetIndexChangedHandler(s.syncPinnedTools)
 
 	return s, nil
 }
@@ -153,14 +175,15 @@ func NewFromConfig(cfg *config.Config) (*Server, error) {
 // MCP connections are established asynchronously.
 // Cached MCPs are loaded from disk immediately and lazy-connect on demand.
 func (s *Server) Start(ctx context.Context) error {
-	interval, err := healthCheckInterval(s.config)
+	cfg := s.currentConfig()
+	interval, err := healthCheckInterval(cfg)
 	if err != nil {
 		return err
 	}
 
 	// Register all configured MCPs first (so status shows them immediately)
-	for _, cfg := range s.config.MCPs {
-		s.registry.SetConfigured(cfg)
+	for _, mcpCfg := range cfg.MCPs {
+		s.registry.SetConfigured(mcpCfg)
 	}
 
 	// Load CLI tools from configured directories
@@ -168,7 +191,7 @@ func (s *Server) Start(ctx context.Context) error {
 
 	// Load cached tool metadata (non-dynamic MCPs with valid cache)
 	// Cached MCPs skip eager connection and lazy-connect on first execute_tool
-	cached := s.registry.LoadCache(s.config)
+	cached := s.registry.LoadCache(cfg)
 	if cached > 0 {
 		s.logger.Info("loaded MCPs from cache", "count", cached)
 	}
@@ -178,11 +201,18 @@ func (s *Server) Start(ctx context.Context) error {
 			return err
 		}
 	}
+	s.registry.StartIdleReaper()
+
+	if s.reloadDir != "" {
+		if interval := configReloadInterval(); interval > 0 {
+			go s.watchConfig(ctx, interval)
+		}
+	}
 
 	// Connect to MCPs in background to avoid blocking server startup
 	// Cached MCPs are skipped (ConnectFromConfig checks for StateCached)
 	go func() {
-		if err := s.registry.ConnectFromConfig(ctx, s.config); err != nil {
+		if err := s.registry.ConnectFromConfig(ctx, cfg); err != nil {
 			// Debug level: individual connection errors stored in registry state
 			s.logger.Debug("error connecting to MCPs", "error", err)
 		}
@@ -220,27 +250,36 @@ func healthCheckInterval(cfg *config.Config) (string, error) {
 
 // loadCLITools loads CLI tool definitions from standard directories.
 func (s *Server) loadCLITools() {
-	// User-level CLI tools: $XDG_CONFIG_HOME/slop-mcp/cli/ or ~/.config/slop-mcp/cli/
-	if userConfigDir := config.UserConfigDirPath(); userConfigDir != "" {
-		userCLIDir := filepath.Join(userConfigDir, "cli")
-		if err := s.cliRegistry.LoadFromDirectory(userCLIDir); err != nil {
-			s.logger.Debug("failed to load user CLI tools", "dir", userCLIDir, "error", err)
-		}
+	projectDir, _ := os.Getwd()
+	s.loadCLIToolsInto(s.cliRegistry, projectDir)
+
+	// Log loaded tools count
+	if count := s.cliRegistry.Count(); count > 0 {
+		s.logger.Info("loaded CLI tools", "count", count)
 	}
+}
 
-	// Project-level CLI tools: .slop-mcp/cli/
-	cwd, err := os.Getwd()
-	if err == nil {
-		projectCLIDir := filepath.Join(cwd, ".slop-mcp", "cli")
-		if err := s.cliRegistry.LoadFromDirectory(projectCLIDir); err != nil {
-			s.logger.Debug("failed to load project CLI tools", "dir", projectCLIDir, "error", err)
+// loadCLIToolsInto loads the CLI tool definitions of cliToolDirs into reg.
+func (s *Server) loadCLIToolsInto(reg *cli.Registry, projectDir string) {
+	for _, dir := range cliToolDirs(projectDir) {
+		if err := reg.LoadFromDirectory(dir); err != nil {
+			s.logger.Debug("failed to load CLI tools", "dir", dir, "error", err)
 		}
 	}
+}
 
-	// Log loaded tools count
-	if count := s.cliRegistry.Count(); count > 0 {
-		s.logger.Info("loaded CLI tools", "count", count)
+// cliToolDirs returns the directories CLI tools are loaded from, in load
+// order: $XDG_CONFIG_HOME/slop-mcp/cli/ (or ~/.config/slop-mcp/cli/), then
+// the project's .slop-mcp/cli/, whose tools win on a name clash.
+func cliToolDirs(projectDir string) []string {
+	var dirs []string
+	if userConfigDir := config.UserConfigDirPath(); userConfigDir != "" {
+		dirs = append(dirs, filepath.Join(userConfigDir, "cli"))
 	}
+	if projectDir != "" {
+		dirs = append(dirs, filepath.Join(projectDir, ".slop-mcp", "cli"))
+	}
+	return dirs
 }
 
 // RunStdio runs the server using stdio transport.
@@ -268,38 +307,108 @@ func requireBearerToken(token string, h http.Handler) http.Handler {
 	})
 }
 
-// RunHTTP runs the server using HTTP/SSE transport.
-func (s *Server) RunHTTP(ctx context.Context, port int) error {
-	if err := s.Start(ctx); err != nil {
-		return err
+// defaultHTTPSessionTimeout closes streamable HTTP sessions that have seen no
+// request for this long, so abandoned clients do not pin session state
+// forever. Overridable via SLOP_MCP_HTTP_SESSION_TIMEOUT.
+const defaultHTTPSessionTimeout = 30 * time.Minute
+
+// httpSessionTimeout returns the streamable HTTP idle-session timeout,
+// honoring SLOP_MCP_HTTP_SESSION_TIMEOUT (a Go duration; "0" disables).
+func httpSessionTimeout() time.Duration {
+	if v := strings.TrimSpace(os.Getenv("SLOP_MCP_HTTP_SESSION_TIMEOUT")); v != "" {
+		if v == "0" {
+			return 0
+		}
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
 	}
+	return defaultHTTPSessionTimeout
+}
 
-	addr := fmt.Sprintf(":%d", port)
+// httpHandler builds the HTTP mode routes: the streamable HTTP transport on
+// /mcp, the legacy SSE transport on /, and the diagnostic endpoints, all
+// behind the optional bearer-token gate. With SLOP_MCP_HTTP_ISOLATION set,
+// each client session (or bearer token) also gets its own tenant state.
+func (s *Server) httpHandler() (http.Handler, error) {
+	mode, err := httpIsolationMode()
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := httpTokens()
+	if err != nil {
+		return nil, err
+	}
+	if mode == isolationToken && tokens == nil {
+		// A single SLOP_MCP_HTTP_TOKEN (or no auth) would put every client in
+		// one tenant, so the isolation asked for would do nothing.
+		return nil, fmt.Errorf("SLOP_MCP_HTTP_ISOLATION=token requires SLOP_MCP_HTTP_TOKEN_FILE")
+	}
+	s.httpSetup.Do(func() {
+		if mode != isolationOff {
+			s.tenants = newTenants(mode)
+			s.mcpServer.AddReceivingMiddleware(s.isolationMiddleware)
+			s.logger.Info("HTTP clients are isolated", "isolation", string(mode))
+		}
+		if tokens != nil {
+			s.mcpServer.AddReceivingMiddleware(s.permissionMiddleware)
+		}
+		if mode != isolationOff || tokens != nil {
+			s.mcpServer.AddReceivingMiddleware(s.pinnedToolsFilter)
+		}
+	})
 
-	// Create SSE handler
-	sseHandler := mcp.NewSSEHandler(func(r *http.Request) *mcp.Server {
+	getServer := func(*http.Request) *mcp.Server {
 		return s.mcpServer
-	}, nil)
+	}
+
+	// Streamable HTTP keeps per-session state keyed by Mcp-Session-Id. The
+	// event store lets a client that lost its stream resume with
+	// Last-Event-ID instead of missing responses and notifications.
+	streamableHandler := mcp.NewStreamableHTTPHandler(getServer, &mcp.StreamableHTTPOptions{
+		EventStore:     mcp.NewMemoryEventStore(nil),
+		SessionTimeout: httpSessionTimeout(),
+	})
+	sseHandler := mcp.NewSSEHandler(getServer, nil)
 
 	mux := http.NewServeMux()
+	mux.Handle("/mcp", streamableHandler)
 	mux.Handle("/", sseHandler)
 	mux.HandleFunc("/status", s.handleHTTPStatus)
 	mux.HandleFunc("/metadata", s.handleHTTPMetadata)
+	mux.HandleFunc("/logs", s.handleHTTPLogs)
 
-	// Optional bearer-token gate over every route (SSE transport + the
+	// Optional bearer-token gate over every route (both transports + the
 	// diagnostic endpoints). HTTP mode is otherwise unauthenticated and, bound
 	// to all interfaces, would expose the MCP inventory and tool descriptions to
 	// anything that can reach the port.
-	var handler http.Handler = mux
+	if tokens != nil {
+		s.logger.Info("HTTP mode requires a bearer token from SLOP_MCP_HTTP_TOKEN_FILE", "tokens", len(tokens))
+		return requireHTTPTokens(tokens, s.logger, mux), nil
+	}
 	if token := strings.TrimSpace(os.Getenv("SLOP_MCP_HTTP_TOKEN")); token != "" {
-		handler = requireBearerToken(token, mux)
 		s.logger.Info("HTTP mode requires bearer token (SLOP_MCP_HTTP_TOKEN)")
-	} else {
-		s.logger.Warn("HTTP mode is UNAUTHENTICATED; set SLOP_MCP_HTTP_TOKEN to require a bearer token, or bind to a trusted interface only")
+		return requireBearerToken(token, mux), nil
+	}
+	s.logger.Warn("HTTP mode is UNAUTHENTICATED; set SLOP_MCP_HTTP_TOKEN to require a bearer token, or bind to a trusted interface only")
+	return mux, nil
+}
+
+// RunHTTP runs the server over HTTP: streamable HTTP on /mcp and SSE on /.
+func (s *Server) RunHTTP(ctx context.Context, port int) error {
+	handler, err := s.httpHandler()
+	if err != nil {
+		return err
+	}
+	if err := s.Start(ctx); err != nil {
+		return err
 	}
 
-	// SSE streams are long-lived: ReadTimeout/WriteTimeout would kill active
-	// event streams, so only header-read and keep-alive idle are bounded.
+	addr := fmt.Sprintf(":%d", port)
+
+	// SSE and streamable GET streams are long-lived: ReadTimeout/WriteTimeout
+	// would kill active event streams, so only header-read and keep-alive idle
+	// are bounded.
 	srv := &http.Server{
 		Addr:              addr,
 		Handler:           handler,
@@ -307,7 +416,7 @@ func (s *Server) RunHTTP(ctx context.Context, port int) error {
 		IdleTimeout:       120 * time.Second,
 	}
 
-	s.logger.Info("slop-mcp server running", "addr", addr)
+	s.logger.Info("slop-mcp server running", "addr", addr, "streamable", "/mcp", "sse", "/")
 
 	errCh := make(chan error, 1)
 	go func() { errCh <- srv.ListenAndServe() }()
@@ -351,6 +460,24 @@ func (s *Server) handleHTTPMetadata(w http.ResponseWriter, r *http.Request) {
 	writeHTTPJSON(w, result, err)
 }
 
+func (s *Server) handleHTTPLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	input := ManageMCPsInput{Action: "logs", Name: r.URL.Query().Get("name")}
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "lines must be an integer", http.StatusBadRequest)
+			return
+		}
+		input.Lines = n
+	}
+	_, result, err := s.handleManageMCPs(r.Context(), nil, input)
+	writeHTTPJSON(w, result, err)
+}
+
 func writeHTTPJSON(w http.ResponseWriter, v any, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	if err != nil {
@@ -364,6 +491,7 @@ func writeHTTPJSON(w http.ResponseWriter, v any, err error) {
 // Close closes all MCP connections and the override store.
 func (s *Server) Close() error {
 	var errs []error
+	s.closeTenants()
 	if s.overrideStore != nil {
 		if err := s.overrideStore.Close(); err != nil {
 			errs = append(errs, err)
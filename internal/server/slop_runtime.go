@@ -41,9 +41,10 @@ func (s *Server) newSlopRuntime(ctx context.Context) *slop.Runtime {
 	builtins.RegisterJWT(rt)
 	builtins.RegisterTemplate(rt)
 
-	// Thread-safe session store (overrides SLOP's default store_*).
-	if s.sessionStore != nil {
-		builtins.RegisterSession(rt, s.sessionStore)
+	// Thread-safe session store (overrides SLOP's default store_*); private
+	// to the caller's tenant in isolated HTTP mode.
+	if store := s.sessionStoreFor(ctx); store != nil {
+		builtins.RegisterSession(rt, store)
 	}
 
 	// Persistent memory functions.
@@ -56,6 +57,9 @@ func (s *Server) newSlopRuntime(ctx context.Context) *slop.Runtime {
 	// scope (so hyphenated identifiers still resolve) without connecting.
 	// EnsureConnected dials on first use.
 	for _, cfg := range s.registry.AllConfigs() {
+		if !s.mcpVisible(ctx, cfg.Name) {
+			continue
+		}
 		rt.RegisterExternalService(cfg.Name, &registrySlopService{
 			server: s,
 			ctx:    ctx,
@@ -64,8 +68,12 @@ func (s *Server) newSlopRuntime(ctx context.Context) *slop.Runtime {
 	}
 
 	// CLI tools as a service (accessible as cli.tool_name() in scripts).
-	if s.cliRegistry.Count() > 0 {
-		rt.RegisterExternalService("cli", cli.NewSlopService(ctx, s.cliRegistry))
+	if s.cliRegistry.Count() > 0 && s.mcpVisible(ctx, "cli") {
+		var svc slop.Service = cli.NewSlopService(ctx, s.cliRegistry)
+		if tokenFrom(ctx) != nil {
+			svc = &permittedSlopService{server: s, ctx: ctx, name: "cli", next: svc}
+		}
+		rt.RegisterExternalService("cli", svc)
 	}
 
 	return rt
@@ -129,8 +137,33 @@ type registrySlopService struct {
 	name   string
 }
 
+// permittedSlopService applies the caller's HTTP token tool list to a
+// service that does not go through the registry (the CLI tools).
+type permittedSlopService struct {
+	server *Server
+	ctx    context.Context
+	name   string
+	next   slop.Service
+}
+
+func (p *permittedSlopService) Call(method string, args []slop.Value, kwargs map[string]slop.Value) (slop.Value, error) {
+	if err := p.server.checkToolAllowed(p.ctx, p.name, method); err != nil {
+		return nil, err
+	}
+	return p.next.Call(method, args, kwargs)
+}
+
 // Call forwards service.method(args, kwargs) to the registry-managed MCP.
+// read_resource is answered from the MCP's resources unless the MCP itself
+// exposes a tool by that name, which always wins.
 func (m *registrySlopService) Call(method string, args []slop.Value, kwargs map[string]slop.Value) (slop.Value, error) {
+	if method == "read_resource" && !m.server.registry.HasTool(m.name, method) {
+		return m.readResource(args, kwargs)
+	}
+	if err := m.server.checkToolAllowed(m.ctx, m.name, method); err != nil {
+		return nil, err
+	}
+
 	arguments := buildMCPArguments(args, kwargs)
 
 	result, err := m.server.registry.ExecuteToolRaw(m.ctx, m.name, method, arguments)
@@ -159,6 +192,35 @@ func (m *registrySlopService) Call(method string, args []slop.Value, kwargs map[
 	return resultToSlopValue(result), nil
 }
 
+// readResource implements mcp_name.read_resource(uri: ..., vars: {...}).
+// The uri may also be passed positionally. The result has the same shape as
+// the read_resource meta-tool output: {mcp_name, uri, contents}.
+func (m *registrySlopService) readResource(args []slop.Value, kwargs map[string]slop.Value) (slop.Value, error) {
+	var uri string
+	if v, ok := kwargs["uri"]; ok {
+		uri, _ = slop.ValueToGo(v).(string)
+	} else if len(args) > 0 {
+		uri, _ = slop.ValueToGo(args[0]).(string)
+	}
+	if uri == "" {
+		return nil, fmt.Errorf("%s.read_resource: uri is required", m.name)
+	}
+
+	var vars map[string]any
+	if v, ok := kwargs["vars"]; ok {
+		vars, ok = slop.ValueToGo(v).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.read_resource: vars must be a map", m.name)
+		}
+	}
+
+	result, err := m.server.registry.ReadResource(m.ctx, m.name, uri, vars)
+	if err != nil {
+		return nil, fmt.Errorf("MCP %q read_resource %q: %w", m.name, uri, err)
+	}
+	return slop.GoToValue(normalizeJSONValue(result)), nil
+}
+
 // buildMCPArguments converts SLOP call args/kwargs into an MCP arguments map,
 // mirroring the SLOP runtime's own MCP service semantics: kwargs pass through
 // by name, positional args become arg0..argN, and a single positional arg
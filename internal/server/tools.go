@@ -8,17 +8,19 @@ import (
 	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/standardbeagle/slop-mcp/internal/registry"
 )
 
 // registerTools registers all server tools with manually crafted schemas.
 // This avoids the Go SDK's auto-generated schemas which use patterns like
 // "type": ["null", "object"] that Claude Code's strict validator rejects.
+// Pinned upstream tools are registered after the built-in ones.
 func (s *Server) registerTools() {
 	// 1. search_tools - Search registered MCP tools
 	s.mcpServer.AddTool(
 		&mcp.Tool{
 			Name:        "search_tools",
-			Description: "Fuzzy search tools across connected MCPs. Ranked results. Paginated (default: 20, max: 100). Use offset for next page. Response includes total and has_more.",
+			Description: "Fuzzy search tools across connected MCPs. Ranked results. Paginated (default: 20, max: 100). Use offset for next page. Response includes total and has_more. include_prompts=true also searches MCP prompts.",
 			InputSchema: searchToolsInputSchema,
 		},
 		s.wrapSearchTools,
@@ -83,7 +85,7 @@ Use slop_reference to browse built-in functions (map, filter, reduce, json_parse
 	s.mcpServer.AddTool(
 		&mcp.Tool{
 			Name:        "manage_mcps",
-			Description: "Manage MCP connections. Actions: register, unregister, reconnect, list, status, health_check, list_stale_overrides. Returns text.",
+			Description: "Manage MCP connections. Actions: register, unregister, reconnect, list, status, health_check, list_stale_overrides, cache_inspect, cache_flush, logs. Returns text.",
 			InputSchema: manageMCPsInputSchema,
 		},
 		s.wrapManageMCPs,
@@ -150,6 +152,28 @@ Use slop_reference to browse built-in functions (map, filter, reduce, json_parse
 		},
 		s.wrapCustomizeTools,
 	)
+
+	// 11. read_resource - Read an upstream MCP resource
+	s.mcpServer.AddTool(
+		&mcp.Tool{
+			Name:        "read_resource",
+			Description: "Read resource from MCP server by URI. Template URIs (from get_metadata resource_templates) expand with vars. Returns text/blob contents.",
+			InputSchema: readResourceInputSchema,
+		},
+		s.wrapReadResource,
+	)
+
+	// 12. get_prompt - Render an upstream MCP prompt
+	s.mcpServer.AddTool(
+		&mcp.Tool{
+			Name:        "get_prompt",
+			Description: "Render prompt from MCP server. Arguments validated against prompt's declared arguments. Find prompts via search_tools include_prompts=true. Returns messages.",
+			InputSchema: getPromptInputSchema,
+		},
+		s.wrapGetPrompt,
+	)
+
+	s.syncPinnedTools()
 }
 
 // Wrapper handlers that parse JSON manually and call the typed handlers.
@@ -221,13 +245,7 @@ func validateExecuteToolParameters(raw json.RawMessage) error {
 }
 
 func (s *Server) wrapExecuteTool(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Bound the tool call so a hung MCP cannot block the agent indefinitely.
-	// context.WithTimeout keeps any shorter deadline the client already set.
-	if t := executeToolTimeout(); t > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, t)
-		defer cancel()
-	}
+	ctx = withCaller(ctx, req, true)
 
 	args, err := callToolArguments(req)
 	if err != nil {
@@ -263,6 +281,9 @@ func (s *Server) wrapExecuteTool(ctx context.Context, req *mcp.CallToolRequest)
 		return errorResult(fmt.Errorf("tool_name is required")), nil
 	}
 
+	ctx, cancel := s.boundToolCall(ctx, input.MCPName, input.ToolName)
+	defer cancel()
+
 	// Local routes (custom tools and CLI tools) share handleExecuteTool so the
 	// routing contract lives in one place. Custom tools may declare integer
 	// params, so decode with json.Number and normalize to int64/float64 to keep
@@ -291,14 +312,53 @@ func (s *Server) wrapExecuteTool(ctx context.Context, req *mcp.CallToolRequest)
 		return toCallToolResult(output)
 	}
 
-	// For MCP tools, forward the raw parameters byte-for-byte so large integers
-	// keep their precision, and pass through the underlying MCP's raw response.
-	result, err := s.registry.ExecuteToolRawJSON(ctx, input.MCPName, input.ToolName, input.Parameters)
+	return s.forwardToolCall(ctx, input.MCPName, input.ToolName, input.Parameters), nil
+}
+
+// boundToolCall bounds a tool call so a hung MCP cannot block the agent
+// indefinitely. context.WithTimeout keeps any shorter deadline the client
+// already set. A tool with its own configured timeout is bounded per attempt
+// by the registry instead, so retries are not cut short.
+func (s *Server) boundToolCall(ctx context.Context, mcpName, toolName string) (context.Context, context.CancelFunc) {
+	if t := executeToolTimeout(); t > 0 && s.registry.ToolTimeout(mcpName, toolName) == 0 {
+		return context.WithTimeout(ctx, t)
+	}
+	return ctx, func() {}
+}
+
+// forwardToolCall calls an upstream MCP tool after the allow/deny and tenant
+// checks. The raw parameters are forwarded byte-for-byte so large integers
+// keep their precision, and the underlying MCP's raw response is passed
+// through.
+func (s *Server) forwardToolCall(ctx context.Context, mcpName, toolName string, params json.RawMessage) *mcp.CallToolResult {
+	if err := s.checkToolAllowed(ctx, mcpName, toolName); err != nil {
+		return errorResult(err)
+	}
+	if s.hiddenByTenant(ctx, mcpName) {
+		return errorResult(hiddenMCPError(mcpName))
+	}
+	result, err := s.registry.ExecuteToolRawJSON(ctx, mcpName, toolName, params)
 	if err != nil {
-		return errorResult(err), nil
+		return errorResult(err)
 	}
+	return result
+}
 
-	return result, nil
+// withCaller attaches the downstream session that sent req to ctx, so
+// sampling and elicitation requests an upstream MCP makes during the call are
+// relayed back to that session. interactive is true for a single execute_tool
+// call: elicitation is allowed and upstream progress is relayed under the
+// request's progress token. Scripts are not interactive; their many upstream
+// calls could not share one monotonic progress stream either.
+func withCaller(ctx context.Context, req *mcp.CallToolRequest, interactive bool) context.Context {
+	if req == nil || req.Session == nil {
+		return ctx
+	}
+	c := &registry.Caller{Session: req.Session, Interactive: interactive}
+	if interactive && req.Params != nil {
+		c.ProgressToken = req.Params.GetProgressToken()
+	}
+	return registry.WithCaller(ctx, c)
 }
 
 // decodeParamsPreservingInts unmarshals a JSON object into map[string]any while
@@ -352,7 +412,7 @@ func (s *Server) wrapRunSlop(ctx context.Context, req *mcp.CallToolRequest) (*mc
 		return errorResult(fmt.Errorf("invalid parameters: %w", err)), nil
 	}
 
-	_, output, err := s.handleRunSlop(ctx, req, input)
+	_, output, err := s.handleRunSlop(withCaller(ctx, req, false), req, input)
 	if err != nil {
 		return errorResult(err), nil
 	}
@@ -489,6 +549,42 @@ func (s *Server) wrapCustomizeTools(ctx context.Context, req *mcp.CallToolReques
 	return toCallToolResult(output)
 }
 
+func (s *Server) wrapReadResource(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := callToolArguments(req)
+	if err != nil {
+		return errorResult(err), nil
+	}
+	var input ReadResourceInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return errorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+
+	_, output, err := s.handleReadResource(ctx, req, input)
+	if err != nil {
+		return errorResult(err), nil
+	}
+
+	return toCallToolResult(output)
+}
+
+func (s *Server) wrapGetPrompt(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := callToolArguments(req)
+	if err != nil {
+		return errorResult(err), nil
+	}
+	var input GetPromptInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return errorResult(fmt.Errorf("invalid parameters: %w", err)), nil
+	}
+
+	_, output, err := s.handleGetPrompt(ctx, req, input)
+	if err != nil {
+		return errorResult(err), nil
+	}
+
+	return toCallToolResult(output)
+}
+
 // detectWrongParametersKey inspects raw execute_tool arguments for common
 // typos used in place of the canonical `parameters` field. Returns the
 // offending key and true when one is present with a non-empty value. Empty
@@ -529,10 +625,14 @@ func isEmptyJSONValue(v json.RawMessage) bool {
 
 // errorResult creates an error CallToolResult.
 func errorResult(err error) *mcp.CallToolResult {
-	return &mcp.CallToolResult{
+	result := &mcp.CallToolResult{
 		IsError: true,
 		Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
 	}
+	if pd, ok := permissionDenied(err); ok {
+		result.StructuredContent = pd.structured()
+	}
+	return result
 }
 
 // toCallToolResult converts any output to a CallToolResult with JSON text content.
@@ -0,0 +1,475 @@
+// Package websocket implements the parts of the WebSocket protocol (RFC
+// 6455) that JSON-RPC over WebSocket needs: the HTTP/1.1 upgrade handshake,
+// text and binary messages (fragmented or not), ping/pong, and the close
+// handshake. Extensions such as permessage-deflate are never negotiated.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// MCPSubprotocol is the subprotocol offered when dialing an MCP server.
+// Servers that do not select it are accepted too.
+const MCPSubprotocol = "mcp"
+
+// MaxMessageSize caps a received message, fragments included. A larger
+// message fails the connection.
+const MaxMessageSize = 32 << 20
+
+// Close status codes (RFC 6455 section 7.4.1).
+const (
+	CloseNormal        = 1000
+	CloseProtocolError = 1002
+	CloseTooBig        = 1009
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// acceptGUID is appended to the client key to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrClosed is returned by writes on a connection that was closed.
+var ErrClosed = errors.New("websocket: use of closed connection")
+
+// CloseError is returned by ReadMessage after the peer closed the
+// connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: connection closed by peer (code %d)", e.Code)
+	}
+	return fmt.Sprintf("websocket: connection closed by peer (code %d: %s)", e.Code, e.Reason)
+}
+
+// Conn is a WebSocket connection. ReadMessage must be called from one
+// goroutine at a time; WriteMessage, Ping, and Close may be called
+// concurrently with it and with each other.
+type Conn struct {
+	rwc      io.ReadWriteCloser
+	br       *bufio.Reader
+	client   bool // client frames are masked, server frames are not
+	protocol string
+	onPong   func([]byte)
+
+	writeMu   sync.Mutex
+	closed    atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+	done      chan struct{}
+}
+
+func newConn(rwc io.ReadWriteCloser, br *bufio.Reader, client bool, protocol string) *Conn {
+	return &Conn{rwc: rwc, br: br, client: client, protocol: protocol, done: make(chan struct{})}
+}
+
+// Dial opens a WebSocket connection to rawURL (ws, wss, http, or https)
+// with an upgrade request sent through client, so the client's transport
+// adds its headers and credentials to the handshake. The transport must
+// speak HTTP/1.1: HTTP/2 has no upgrade. ctx bounds the handshake only.
+func Dial(ctx context.Context, client *http.Client, rawURL string, protocols ...string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("websocket: unsupported url scheme %q", u.Scheme)
+	}
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	// The upgraded connection outlives the handshake, and an HTTP transport
+	// tears down a connection whose request context ends. Only a ctx that
+	// ends before the response arrives may cancel the request.
+	reqCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, cancel)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if len(protocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+
+	resp, err := client.Do(req)
+	if !stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		cancel()
+		if msg := strings.TrimSpace(string(body)); msg != "" {
+			return nil, fmt.Errorf("websocket handshake: %s: %s", resp.Status, msg)
+		}
+		return nil, fmt.Errorf("websocket handshake: %s", resp.Status)
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("websocket handshake: the HTTP transport does not support protocol upgrades")
+	}
+	fail := func(format string, args ...any) (*Conn, error) {
+		rwc.Close()
+		cancel()
+		return nil, fmt.Errorf("websocket handshake: "+format, args...)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return fail("server did not upgrade to websocket")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return fail("bad Sec-WebSocket-Accept")
+	}
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); ext != "" {
+		return fail("server selected unrequested extensions %q", ext)
+	}
+	protocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if protocol != "" && !containsToken(protocols, protocol) {
+		return fail("server selected unoffered subprotocol %q", protocol)
+	}
+	return newConn(&cancelOnClose{ReadWriteCloser: rwc, cancel: cancel}, bufio.NewReader(rwc), true, protocol), nil
+}
+
+// cancelOnClose releases the handshake request's context with the
+// connection.
+type cancelOnClose struct {
+	io.ReadWriteCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadWriteCloser.Close()
+	c.cancel()
+	return err
+}
+
+// Accept completes the server side of the handshake for r and takes over
+// its connection, selecting the first of protocols the client offered. On
+// failure an HTTP error has been written to w.
+func Accept(w http.ResponseWriter, r *http.Request, protocols ...string) (*Conn, error) {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, fmt.Errorf("websocket: not an upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("websocket: unsupported version %q", r.Header.Get("Sec-WebSocket-Version"))
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, fmt.Errorf("websocket: missing Sec-WebSocket-Key")
+	}
+	protocol := ""
+	for _, p := range protocols {
+		if headerHasToken(r.Header, "Sec-WebSocket-Protocol", p) {
+			protocol = p
+			break
+		}
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: response writer cannot be hijacked")
+	}
+	netConn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if protocol != "" {
+		resp += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	if _, err := brw.WriteString(resp + "\r\n"); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return newConn(netConn, brw.Reader, false, protocol), nil
+}
+
+// Subprotocol returns the subprotocol selected in the handshake, if any.
+func (c *Conn) Subprotocol() string { return c.protocol }
+
+// SetPongHandler sets fn to be called with the payload of each pong frame
+// ReadMessage reads. It must be set before reading starts.
+func (c *Conn) SetPongHandler(fn func(payload []byte)) { c.onPong = fn }
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} { return c.done }
+
+// ReadMessage returns the payload of the next text or binary message,
+// answering pings and handling pongs and fragments on the way. After the
+// peer closes the connection it returns a *CloseError.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	inMessage := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				c.Close()
+				return nil, err
+			}
+		case opPong:
+			if c.onPong != nil {
+				c.onPong(payload)
+			}
+		case opClose:
+			ce := &CloseError{Code: 1005}
+			if len(payload) >= 2 {
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Reason = string(payload[2:])
+			}
+			c.closeWith(CloseNormal, "")
+			return nil, ce
+		case opText, opBinary:
+			if inMessage {
+				return nil, c.fail(CloseProtocolError, "new message before the previous one ended")
+			}
+			if fin {
+				return payload, nil
+			}
+			msg, inMessage = payload, true
+		case opContinuation:
+			if !inMessage {
+				return nil, c.fail(CloseProtocolError, "continuation frame outside a message")
+			}
+			if len(msg)+len(payload) > MaxMessageSize {
+				return nil, c.fail(CloseTooBig, "message too big")
+			}
+			msg = append(msg, payload...)
+			if fin {
+				return msg, nil
+			}
+		default:
+			return nil, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", op))
+		}
+	}
+}
+
+// WriteMessage sends data as one text message.
+func (c *Conn) WriteMessage(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// Ping sends a ping frame; the pong arrives through the pong handler.
+func (c *Conn) Ping(payload []byte) error {
+	return c.writeFrame(opPing, payload)
+}
+
+// Close sends a close frame, without waiting for the peer's reply, and
+// closes the underlying connection. It unblocks a pending ReadMessage.
+func (c *Conn) Close() error {
+	return c.closeWith(CloseNormal, "")
+}
+
+// fail closes the connection with a protocol error code and returns the
+// error for the reader.
+func (c *Conn) fail(code int, reason string) error {
+	c.closeWith(code, reason)
+	return fmt.Errorf("websocket: %s", reason)
+}
+
+func (c *Conn) closeWith(code int, reason string) error {
+	c.closeOnce.Do(func() {
+		// Best effort: skip the close frame rather than wait behind a
+		// write that is stuck on a dead peer.
+		if c.writeMu.TryLock() {
+			payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+			_ = c.writeFrameLocked(opClose, append(payload, reason...))
+			c.writeMu.Unlock()
+		}
+		c.closed.Store(true)
+		c.closeErr = c.rwc.Close()
+		close(c.done)
+	})
+	return c.closeErr
+}
+
+// readFrame reads one frame, unmasking its payload.
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0f
+	if h[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	masked := h[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, c.fail(CloseProtocolError, "frame masking does not match the peer's role")
+	}
+
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= opClose && (!fin || n > 125) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if n > MaxMessageSize {
+		return false, 0, nil, c.fail(CloseTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeFrameLocked(op, payload)
+}
+
+// writeFrameLocked writes payload as a single final frame, masked when c is
+// the client. The caller must hold writeMu.
+func (c *Conn) writeFrameLocked(op byte, payload []byte) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|op)
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		buf = append(buf, mask[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		for i := range buf[start:] {
+			buf[start+i] ^= mask[i%4]
+		}
+	} else {
+		buf = append(buf, payload...)
+	}
+	_, err := c.rwc.Write(buf)
+	return err
+}
+
+// acceptKey computes Sec-WebSocket-Accept for a Sec-WebSocket-Key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken reports whether the comma-separated header name of h lists
+// token, ignoring case.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsToken(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
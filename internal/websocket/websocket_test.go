@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer serves WebSocket connections accepted with protocols to
+// handle, and returns the ws:// URL.
+func newTestServer(t *testing.T, handle func(*Conn), protocols ...string) string {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Accept(w, r, protocols...)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		handle(c)
+	}))
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func echo(c *Conn) {
+	for {
+		msg, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := c.WriteMessage(msg); err != nil {
+			return
+		}
+	}
+}
+
+func TestDialEchoesMessages(t *testing.T) {
+	url := newTestServer(t, echo, MCPSubprotocol)
+	c, err := Dial(context.Background(), http.DefaultClient, url, MCPSubprotocol)
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, MCPSubprotocol, c.Subprotocol())
+
+	for _, msg := range []string{`{"jsonrpc":"2.0"}`, strings.Repeat("x", 300), strings.Repeat("y", 70000)} {
+		require.NoError(t, c.WriteMessage([]byte(msg)))
+		got, err := c.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, msg, string(got))
+	}
+}
+
+func TestReadMessageJoinsFragmentsAndAnswersPings(t *testing.T) {
+	url := newTestServer(t, func(c *Conn) {
+		c.writeMu.Lock()
+		_ = c.rawFrame(false, opText, []byte("hel"))
+		_ = c.rawFrame(true, opPing, []byte("are you there"))
+		_ = c.rawFrame(true, opContinuation, []byte("lo"))
+		c.writeMu.Unlock()
+		_, _ = c.ReadMessage() // the pong, then the client's close
+	})
+	c, err := Dial(context.Background(), http.DefaultClient, url)
+	require.NoError(t, err)
+	defer c.Close()
+
+	got, err := c.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+}
+
+func TestPingGetsPong(t *testing.T) {
+	url := newTestServer(t, echo)
+	c, err := Dial(context.Background(), http.DefaultClient, url)
+	require.NoError(t, err)
+	defer c.Close()
+
+	pong := make(chan string, 1)
+	c.SetPongHandler(func(p []byte) { pong <- string(p) })
+	go func() { _, _ = c.ReadMessage() }()
+	require.NoError(t, c.Ping([]byte("hi")))
+	select {
+	case p := <-pong:
+		assert.Equal(t, "hi", p)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no pong")
+	}
+}
+
+func TestPeerCloseEndsRead(t *testing.T) {
+	url := newTestServer(t, func(c *Conn) { c.closeWith(4000, "bye") })
+	c, err := Dial(context.Background(), http.DefaultClient, url)
+	require.NoError(t, err)
+
+	_, err = c.ReadMessage()
+	var ce *CloseError
+	require.True(t, errors.As(err, &ce), "got %v", err)
+	assert.Equal(t, 4000, ce.Code)
+	assert.Equal(t, "bye", ce.Reason)
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("connection not closed after the peer's close")
+	}
+	assert.ErrorIs(t, c.WriteMessage([]byte("late")), ErrClosed)
+}
+
+func TestDialHandshakeErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "token required", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	_, err := Dial(context.Background(), http.DefaultClient, ts.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Contains(t, err.Error(), "token required")
+
+	_, err = Dial(context.Background(), http.DefaultClient, "ftp://example.com")
+	assert.Error(t, err)
+}
+
+func TestDialContextOnlyBoundsHandshake(t *testing.T) {
+	url := newTestServer(t, echo)
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := Dial(ctx, http.DefaultClient, url)
+	require.NoError(t, err)
+	defer c.Close()
+	cancel()
+
+	require.NoError(t, c.WriteMessage([]byte("still open")))
+	got, err := c.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "still open", string(got))
+}
+
+// rawFrame writes a frame with an explicit fin bit. The caller must hold
+// writeMu.
+func (c *Conn) rawFrame(fin bool, op byte, payload []byte) error {
+	b := op
+	if fin {
+		b |= 0x80
+	}
+	_, err := c.rwc.Write(append([]byte{b, byte(len(payload))}, payload...))
+	return err
+}